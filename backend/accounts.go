@@ -0,0 +1,292 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// ErrScriptTypeUnsupported is returned when creating an account of a script type the keystore
+// cannot sign for, e.g. taproot on a device whose protocol does not support it.
+var ErrScriptTypeUnsupported = errors.New("script type not supported by the keystore")
+
+// ErrAccountTypeUnavailable is returned when creating an account of a coin and script type for
+// which no accounts are loaded, e.g. because no keystore is registered or the type is inactive.
+var ErrAccountTypeUnavailable = errors.New("account type unavailable")
+
+// account returns the loaded account with the given code, or nil if no such account is loaded.
+func (backend *Backend) account(code string) accounts.Interface {
+	defer backend.accountsLock.RLock()()
+	for _, account := range backend.accounts {
+		if account.Code() == code {
+			return account
+		}
+	}
+	return nil
+}
+
+// removeAccount closes the loaded account with the given code and removes it from the backend,
+// together with the token accounts based on it.
+func (backend *Backend) removeAccount(code string) {
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		delete(backend.watchOnlyAccounts, code)
+	}()
+	defer backend.accountsLock.Lock()()
+	remainingAccounts := []accounts.Interface{}
+	for _, account := range backend.accounts {
+		parentCode := ""
+		if tokenAccount, ok := account.(*eth.TokenAccount); ok {
+			parentCode = tokenAccount.Parent().Code()
+		}
+		if account.Code() != code && parentCode != code {
+			remainingAccounts = append(remainingAccounts, account)
+			continue
+		}
+		backend.onAccountUninit(account)
+		account.Close()
+	}
+	backend.accounts = remainingAccounts
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+}
+
+// loadPersistedAccount adds the persisted account to the backend, unless it is archived, already
+// loaded or does not belong to the current network. Keystore accounts are only loaded if they are
+// derived from the registered keystores, or as watch-only accounts if their keystore is absent.
+func (backend *Backend) loadPersistedAccount(account config.Account) error {
+	if account.Archived || backend.account(account.Code) != nil {
+		return nil
+	}
+	coin, err := backend.Coin(account.CoinCode)
+	if err != nil {
+		backend.log.Errorf("skipping persisted account %s/%s, could not find coin",
+			account.CoinCode, account.Code)
+		return nil
+	}
+	if account.Keystore {
+		keystoreAccount, ok := backend.keystoreAccount(account.Code)
+		if ok && keystoreAccount.RootFingerprint == account.RootFingerprint {
+			backend.addKeystoreAccount(coin, account.Code, account.Name, account.Keypath, account.ScriptType)
+			return nil
+		}
+	}
+	if _, isTestnet := testnetCoins[account.CoinCode]; isTestnet != backend.Testing() {
+		// Don't load testnet accounts when running normally, nor mainnet accounts when running
+		// in testing mode
+		return nil
+	}
+	if account.Keystore {
+		backend.loadWatchOnlyAccount(coin, account)
+		return nil
+	}
+	if account.Configuration == nil {
+		return errp.Newf("the persisted account %s has no signing configuration", account.Code)
+	}
+	getSigningConfiguration := func() (*signing.Configuration, error) {
+		return account.Configuration, nil
+	}
+	return backend.CreateAndAddAccount(coin, account.Code, account.Name, getSigningConfiguration, false)
+}
+
+// encodeRootFingerprints returns the identifier of keystores with the given root fingerprints in
+// the accounts config, see config.Account.RootFingerprint.
+func encodeRootFingerprints(rootFingerprints [][4]byte) string {
+	encoded := ""
+	for _, rootFingerprint := range rootFingerprints {
+		encoded += hex.EncodeToString(rootFingerprint[:])
+	}
+	return encoded
+}
+
+// rootFingerprint returns the identifier of the registered keystores in the accounts config. It is
+// empty if no keystore is registered or if a keystore does not provide its root fingerprint.
+func (backend *Backend) rootFingerprint() string {
+	defer backend.keystoreAccountsLock.RLock()()
+	return encodeRootFingerprints(backend.rootFingerprints)
+}
+
+// claimLegacyKeystoreAccounts assigns the keystore accounts which were persisted before the root
+// fingerprints were recorded to the keystores with the given root fingerprint, unless these
+// keystores already have a persisted account with the same code.
+func (backend *Backend) claimLegacyKeystoreAccounts(rootFingerprint string) {
+	if rootFingerprint == "" {
+		return
+	}
+	hasLegacyAccounts := false
+	for _, account := range backend.config.AccountsConfig().Accounts {
+		if account.Keystore && account.RootFingerprint == "" {
+			hasLegacyAccounts = true
+		}
+	}
+	if !hasLegacyAccounts {
+		return
+	}
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		for index := range accountsConfig.Accounts {
+			account := &accountsConfig.Accounts[index]
+			if !account.Keystore || account.RootFingerprint != "" ||
+				accountsConfig.LookupForKeystore(account.Code, rootFingerprint) != nil {
+				continue
+			}
+			backend.log.WithField("code", account.Code).Info("assigning the account to the keystore")
+			account.RootFingerprint = rootFingerprint
+		}
+		return nil
+	})
+	if err != nil {
+		backend.log.WithError(err).Error("could not assign the persisted accounts to the keystore")
+	}
+}
+
+// keystoreAccountTypeActive returns whether the account type belongs to the registered keystores.
+// The account types are replaced whenever the keystores change.
+func (backend *Backend) keystoreAccountTypeActive(accountType *keystoreAccountType) bool {
+	defer backend.keystoreAccountsLock.RLock()()
+	for _, activeAccountType := range backend.keystoreAccountTypes {
+		if activeAccountType == accountType {
+			return true
+		}
+	}
+	return false
+}
+
+// keystoreAccount returns the description of the account with the given code if it is derived from
+// the registered keystores.
+func (backend *Backend) keystoreAccount(code string) (config.Account, bool) {
+	defer backend.keystoreAccountsLock.RLock()()
+	account, ok := backend.keystoreAccounts[code]
+	return account, ok
+}
+
+// modifyPersistedAccount applies f to the persisted account with the given code and returns the
+// modified account. Accounts derived from the registered keystores which are not persisted yet are
+// persisted first.
+func (backend *Backend) modifyPersistedAccount(code string, f func(*config.Account)) (config.Account, error) {
+	var modifiedAccount config.Account
+	rootFingerprint := backend.accountRootFingerprint(code)
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		persistedAccount := accountsConfig.LookupForKeystore(code, rootFingerprint)
+		if persistedAccount == nil {
+			keystoreAccount, ok := backend.keystoreAccount(code)
+			if !ok {
+				return errp.WithStack(ErrAccountNotPersisted)
+			}
+			accountsConfig.Accounts = append(accountsConfig.Accounts, keystoreAccount)
+			persistedAccount = &accountsConfig.Accounts[len(accountsConfig.Accounts)-1]
+		}
+		f(persistedAccount)
+		modifiedAccount = *persistedAccount
+		return nil
+	})
+	return modifiedAccount, err
+}
+
+// CreateKeystoreAccount creates the next account (the next BIP44 account index) of the given coin
+// and script type, derived from the main keystore, and persists it under the given name. If
+// the name is empty, a default name is used. It returns the code of the new account.
+//
+// Unlike in account discovery, the previous account does not need to have a transaction history:
+// the new account is persisted, so it is loaded even though discovery would not find it.
+func (backend *Backend) CreateKeystoreAccount(
+	coinCode string,
+	scriptType signing.ScriptType,
+	name string,
+) (string, error) {
+	keystores := backend.mainKeystores()
+	if keystores.Count() > 0 && !keystores.SupportsScriptType(scriptType) {
+		return "", errp.WithStack(ErrScriptTypeUnsupported)
+	}
+	var accountType *keystoreAccountType
+	func() {
+		defer backend.keystoreAccountsLock.RLock()()
+		for _, candidate := range backend.keystoreAccountTypes {
+			if candidate.coin.Code() == coinCode && candidate.scriptType == scriptType {
+				accountType = candidate
+			}
+		}
+	}()
+	if keystores.Count() == 0 || accountType == nil {
+		return "", errp.WithStack(ErrAccountTypeUnavailable)
+	}
+	lastAccountIndex := accountType.lastAccountIndex(backend.config.AccountsConfig())
+	return backend.persistKeystoreAccount(
+		accountType, lastAccountIndex+1, strings.TrimSpace(name))
+}
+
+// RenameAccount changes and persists the name of the account with the given code.
+func (backend *Backend) RenameAccount(code string, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errp.New("The account name must not be empty.")
+	}
+	account, err := backend.modifyPersistedAccount(code, func(account *config.Account) {
+		account.Name = name
+	})
+	if err != nil {
+		return err
+	}
+	if backend.account(code) == nil {
+		return nil
+	}
+	// Reload the account so that the new name is picked up.
+	backend.removeAccount(code)
+	return backend.loadPersistedAccount(account)
+}
+
+// SetAccountArchived archives or restores the account with the given code. Archived accounts are
+// not loaded, but their configuration and cached data are kept.
+func (backend *Backend) SetAccountArchived(code string, archived bool) error {
+	account, err := backend.modifyPersistedAccount(code, func(account *config.Account) {
+		account.Archived = archived
+	})
+	if err != nil {
+		return err
+	}
+	if archived {
+		backend.removeAccount(code)
+		return nil
+	}
+	return backend.loadPersistedAccount(account)
+}
+
+// ArchivedAccounts returns the archived accounts which can be restored, i.e. the ones of the
+// current network and, for accounts derived from a keystore, of the registered keystores.
+func (backend *Backend) ArchivedAccounts() []config.Account {
+	archivedAccounts := []config.Account{}
+	rootFingerprint := backend.rootFingerprint()
+	for _, account := range backend.config.AccountsConfig().Accounts {
+		if !account.Archived {
+			continue
+		}
+		if account.Keystore {
+			_, ok := backend.keystoreAccount(account.Code)
+			if !ok || account.RootFingerprint != rootFingerprint {
+				continue
+			}
+		} else if _, isTestnet := testnetCoins[account.CoinCode]; isTestnet != backend.Testing() {
+			continue
+		}
+		archivedAccounts = append(archivedAccounts, account)
+	}
+	return archivedAccounts
+}
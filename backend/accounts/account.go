@@ -49,9 +49,26 @@ type Interface interface {
 	VerifyAddress(addressID string) (bool, error)
 	ConvertToLegacyAddress(addressID string) (btcutil.Address, error)
 	Keystores() *keystore.Keystores
+	// SetTxNote stores a user defined note for the transaction with the given ID. An empty note
+	// removes it.
+	SetTxNote(txID string, note string) error
+	// ExportLabels returns the transaction notes and address labels of the account.
+	ExportLabels() ([]Label, error)
+	// ImportLabels stores the given transaction notes and address labels and returns the number of
+	// imported labels. Labels of other types or referring to addresses of other accounts are
+	// skipped.
+	ImportLabels([]Label) (int, error)
 }
 
 // Info holds account information.
 type Info struct {
 	SigningConfiguration *signing.Configuration `json:"signingConfiguration"`
+	// Descriptor and ChangeDescriptor are the output script descriptors of the receive and change
+	// addresses, if supported by the coin.
+	Descriptor       string `json:"descriptor,omitempty"`
+	ChangeDescriptor string `json:"changeDescriptor,omitempty"`
+	// PolicyHash identifies the spending policy of a multisig account, see
+	// signing.Configuration.PolicyHash(). The keystores do not display it, the cosigners compare
+	// it manually to make sure that they share the same wallet.
+	PolicyHash string `json:"policyHash,omitempty"`
 }
@@ -0,0 +1,85 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// LabelType is the type of record a label refers to. See the LabelType* constants.
+type LabelType string
+
+const (
+	// LabelTypeTx labels a transaction. The reference is the transaction ID.
+	LabelTypeTx LabelType = "tx"
+	// LabelTypeAddress labels an address. The reference is the encoded address.
+	LabelTypeAddress LabelType = "addr"
+)
+
+// Label is a label in the wallet label export format specified in
+// https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki. The format defines more label
+// types than the ones we use, which are kept when decoding so the caller can skip them.
+type Label struct {
+	Type  LabelType `json:"type"`
+	Ref   string    `json:"ref"`
+	Label string    `json:"label"`
+	// Origin optionally identifies the wallet the label was created in.
+	Origin string `json:"origin,omitempty"`
+}
+
+// EncodeLabels writes the labels in the BIP329 format, one JSON object per line.
+func EncodeLabels(writer io.Writer, labels []Label) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for _, label := range labels {
+		if err := encoder.Encode(label); err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// DecodeLabels reads labels in the BIP329 format. Empty lines are skipped.
+func DecodeLabels(reader io.Reader) ([]Label, error) {
+	labels := []Label{}
+	scanner := bufio.NewScanner(reader)
+	// Labels are not limited in length, so allow long lines.
+	scanner.Buffer(nil, 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var label Label
+		if err := json.Unmarshal(line, &label); err != nil {
+			return nil, errp.Newf("invalid label on line %d: %v", lineNumber, err)
+		}
+		if label.Type == "" || label.Ref == "" {
+			return nil, errp.Newf("invalid label on line %d: type and ref are required", lineNumber)
+		}
+		labels = append(labels, label)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return labels, nil
+}
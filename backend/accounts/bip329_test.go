@@ -0,0 +1,64 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accounts_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeLabels(t *testing.T) {
+	labels := []accounts.Label{
+		{
+			Type:  accounts.LabelTypeTx,
+			Ref:   "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd",
+			Label: "rent <March>",
+		},
+		{
+			Type:   accounts.LabelTypeAddress,
+			Ref:    "bc1q34aq5drpuwy3wgl9lhup9892qp6svr8ldzyy7c",
+			Label:  "invoice #123",
+			Origin: "wpkh([d34db33f/84'/0'/0'])",
+		},
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, accounts.EncodeLabels(&buffer, labels))
+	require.Equal(t,
+		`{"type":"tx","ref":"f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd","label":"rent <March>"}
+{"type":"addr","ref":"bc1q34aq5drpuwy3wgl9lhup9892qp6svr8ldzyy7c","label":"invoice #123","origin":"wpkh([d34db33f/84'/0'/0'])"}
+`,
+		buffer.String())
+
+	decoded, err := accounts.DecodeLabels(&buffer)
+	require.NoError(t, err)
+	require.Equal(t, labels, decoded)
+}
+
+func TestDecodeLabels(t *testing.T) {
+	decoded, err := accounts.DecodeLabels(strings.NewReader(
+		"\n{\"type\":\"xpub\",\"ref\":\"xpub661MyMwAqRbcF\",\"label\":\"Cold\"}\n\n"))
+	require.NoError(t, err)
+	require.Equal(t,
+		[]accounts.Label{{Type: "xpub", Ref: "xpub661MyMwAqRbcF", Label: "Cold"}}, decoded)
+
+	_, err = accounts.DecodeLabels(strings.NewReader("{\"type\":\"tx\",\"ref\":\"\"}"))
+	require.Error(t, err)
+	_, err = accounts.DecodeLabels(strings.NewReader("not json"))
+	require.Error(t, err)
+}
@@ -39,6 +39,9 @@ type AddressAndAmount struct {
 	Amount coin.Amount
 	// Ours is true if the address is one of our receive addresses.
 	Ours bool
+	// Label is the user defined label of the address. It is empty for addresses which are not ours
+	// or have not been labeled.
+	Label string
 }
 
 // Transaction models a transaction with common transaction info.
@@ -64,4 +67,7 @@ type Transaction interface {
 
 	// Addresses money was sent to / received on.
 	Addresses() []AddressAndAmount
+
+	// Note is the user defined note of the transaction. Empty if no note was added.
+	Note() string
 }
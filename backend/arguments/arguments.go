@@ -41,12 +41,13 @@ type Arguments struct {
 	// Testing stores whether the application is for regtest.
 	regtest bool
 
-	// Multisig stores whether the application is in multisig mode.
-	multisig bool
-
 	// devmode stores whether the application is in dev mode and, therefore, connects to the dev environment
 	devmode bool
 
+	// softwareKeystore stores whether a software keystore can be loaded from a mnemonic, which
+	// exposes the seed to this computer.
+	softwareKeystore bool
+
 	// log is the logger for this context
 	log *logrus.Entry
 }
@@ -56,8 +57,8 @@ func NewArguments(
 	mainDirectoryPath string,
 	testing bool,
 	regtest bool,
-	multisig bool,
 	devmode bool,
+	softwareKeystore bool,
 ) *Arguments {
 	if !testing && regtest {
 		panic("Cannot use -regtest with -mainnet.")
@@ -76,8 +77,8 @@ func NewArguments(
 		accountsConfigFilename: path.Join(mainDirectoryPath, "accounts.json"),
 		testing:                testing,
 		regtest:                regtest,
-		multisig:               multisig,
 		devmode:                devmode,
+		softwareKeystore:       softwareKeystore,
 		log:                    log,
 	}
 
@@ -122,7 +123,7 @@ func (arguments *Arguments) Regtest() bool {
 	return arguments.regtest
 }
 
-// Multisig returns whether the backend is in multisig mode.
-func (arguments *Arguments) Multisig() bool {
-	return arguments.multisig
+// SoftwareKeystore returns whether a software keystore can be loaded from a mnemonic.
+func (arguments *Arguments) SoftwareKeystore() bool {
+	return arguments.softwareKeystore
 }
@@ -22,16 +22,22 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/cloudfoundry-attic/jibber_jabber"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/arguments"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/electrum"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/electrum/client"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/nodeindexer"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/ltc"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/devices/device"
@@ -45,6 +51,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/rpc"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/text/language"
@@ -92,6 +99,10 @@ type AccountEvent struct {
 // ErrAccountAlreadyExists is returned if an account is being added which already exists.
 var ErrAccountAlreadyExists = errors.New("already exists")
 
+// ErrAccountNotPersisted is returned if a setting is changed for an account which is not stored in
+// the accounts configuration.
+var ErrAccountNotPersisted = errors.New("account not persisted")
+
 // Environment represents functionality where the implementation depends on the environment the app
 // runs in, e.g. Qt5/Mobile/webdev.
 type Environment interface {
@@ -110,7 +121,12 @@ type Backend struct {
 
 	notifier *Notifier
 
-	devices         map[string]device.Interface
+	devices map[string]device.Interface
+	// deviceKeystores are the registered keystores the devices provided, by device ID.
+	deviceKeystores map[string]keystore.Keystore
+	// keystores are the registered keystores, ordered by their cosigner index. The collection is
+	// shared with the multisig accounts, so it is modified in place when keystores are registered or
+	// deregistered. The keystore accounts sign with the main keystore only, see mainKeystores().
 	keystores       *keystore.Keystores
 	onAccountInit   func(accounts.Interface)
 	onAccountUninit func(accounts.Interface)
@@ -123,6 +139,21 @@ type Backend struct {
 	accounts     []accounts.Interface
 	accountsLock locker.Locker
 
+	// keystoreAccountTypes are the types of keystore accounts of which further accounts can be
+	// created.
+	keystoreAccountTypes []*keystoreAccountType
+	// keystoreAccounts describes all accounts derived from the registered keystores, including the
+	// archived ones, by account code.
+	keystoreAccounts map[string]config.Account
+	// rootFingerprints are the root fingerprints of the main keystore, or nil if it does not
+	// provide its fingerprint.
+	rootFingerprints [][4]byte
+	// watchOnlyAccounts are the root fingerprints of the loaded watch-only keystore accounts, by
+	// account code. These are keystore accounts loaded from their remembered signing configuration
+	// while their keystore is not registered.
+	watchOnlyAccounts    map[string]string
+	keystoreAccountsLock locker.Locker
+
 	log *logrus.Entry
 }
 
@@ -135,11 +166,15 @@ func NewBackend(arguments *arguments.Arguments, environment Environment) (*Backe
 		config:      config.NewConfig(arguments.AppConfigFilename(), arguments.AccountsConfigFilename()),
 		events:      make(chan interface{}, 1000),
 
-		devices:   map[string]device.Interface{},
-		keystores: keystore.NewKeystores(),
-		coins:     map[string]coin.Coin{},
-		accounts:  []accounts.Interface{},
-		log:       log,
+		devices:         map[string]device.Interface{},
+		deviceKeystores: map[string]keystore.Keystore{},
+		keystores:       keystore.NewKeystores(),
+		coins:           map[string]coin.Coin{},
+		accounts:        []accounts.Interface{},
+		log:             log,
+
+		keystoreAccounts:  map[string]config.Account{},
+		watchOnlyAccounts: map[string]string{},
 	}
 	notifier, err := NewNotifier(filepath.Join(arguments.MainDirectoryPath(), "notifier.db"))
 	if err != nil {
@@ -191,6 +226,7 @@ func (backend *Backend) CreateAndAddAccount(
 	getSigningConfiguration func() (*signing.Configuration, error),
 	persist bool,
 ) error {
+	var gapLimits *types.GapLimits
 	if persist {
 		configuration, err := getSigningConfiguration()
 		if err != nil {
@@ -198,7 +234,7 @@ func (backend *Backend) CreateAndAddAccount(
 		}
 		accountsConfig := backend.config.AccountsConfig()
 		for _, account := range accountsConfig.Accounts {
-			if account.Configuration.Hash() == configuration.Hash() {
+			if account.Configuration != nil && account.Configuration.Hash() == configuration.Hash() {
 				return errp.WithStack(ErrAccountAlreadyExists)
 			}
 		}
@@ -211,8 +247,27 @@ func (backend *Backend) CreateAndAddAccount(
 		if err := backend.config.SetAccountsConfig(accountsConfig); err != nil {
 			return err
 		}
+	} else {
+		persistedAccount := backend.config.AccountsConfig().LookupForKeystore(
+			code, backend.accountRootFingerprint(code))
+		if persistedAccount != nil {
+			gapLimits = persistedAccount.GapLimits
+		}
 	}
+	backend.loadAccount(coin, code, name, getSigningConfiguration, backend.keystores, gapLimits)
+	return nil
+}
 
+// loadAccount creates an account with the given parameters, which signs with the given keystores,
+// and adds it to the backend.
+func (backend *Backend) loadAccount(
+	coin coin.Coin,
+	code string,
+	name string,
+	getSigningConfiguration func() (*signing.Configuration, error),
+	keystores *keystore.Keystores,
+	gapLimits *types.GapLimits,
+) {
 	var account accounts.Interface
 	onEvent := func(event accounts.Event) {
 		backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event)}
@@ -228,46 +283,359 @@ func (backend *Backend) CreateAndAddAccount(
 	switch specificCoin := coin.(type) {
 	case *btc.Coin:
 		account = btc.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(), code, name,
-			getSigningConfiguration, backend.keystores, getNotifier, onEvent, backend.log)
+			getSigningConfiguration, keystores, gapLimits, getNotifier, onEvent, backend.log)
 		backend.addAccount(account)
 	case *eth.Coin:
-		account = eth.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(), code, name,
-			getSigningConfiguration, backend.keystores, getNotifier, onEvent, backend.log)
+		ethAccount := eth.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(), code, name,
+			getSigningConfiguration, keystores, getNotifier, onEvent, backend.log)
+		account = ethAccount
 		backend.addAccount(account)
+		backend.addERC20TokenAccounts(ethAccount)
 	default:
 		panic("unknown coin type")
 	}
+}
+
+// addERC20TokenAccounts adds a token account for each configured ERC20 token of the coin of the
+// given ethereum account.
+func (backend *Backend) addERC20TokenAccounts(parent *eth.Account) {
+	parentCoin := parent.Coin().(*eth.Coin)
+	for _, tokenConfig := range backend.config.AppConfig().Backend.ETHCoinConfig(parentCoin.Code()).ERC20Tokens {
+		if !common.IsHexAddress(tokenConfig.ContractAddress) || tokenConfig.Symbol == "" {
+			backend.log.WithField("token", tokenConfig).Error("skipping invalid ERC20 token")
+			continue
+		}
+		tokenCoin := eth.NewTokenCoin(parentCoin, erc20.NewToken(
+			common.HexToAddress(tokenConfig.ContractAddress), tokenConfig.Symbol, tokenConfig.Decimals))
+		code := fmt.Sprintf("%s-erc20-%s", parent.Code(), strings.ToLower(tokenConfig.Symbol))
+		name := fmt.Sprintf("%s (%s)", tokenConfig.Symbol, parent.Name())
+
+		var account accounts.Interface
+		onEvent := func(event accounts.Event) {
+			backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event)}
+			if account != nil && event == accounts.EventSyncDone {
+				backend.notifyNewTxs(account)
+			}
+		}
+		getNotifier := func(configuration *signing.Configuration) accounts.Notifier {
+			return backend.notifier.ForAccount(fmt.Sprintf("%s-%s", configuration.Hash(), tokenCoin.Code()))
+		}
+		account = eth.NewTokenAccount(parent, tokenCoin, code, name, getNotifier, onEvent, backend.log)
+		backend.addAccount(account)
+	}
+}
+
+// SetAccountGapLimits changes and persists the gap limits of the btc based account with the given
+// code. The running account rescans its addresses using the new gap limits.
+func (backend *Backend) SetAccountGapLimits(code string, gapLimits *types.GapLimits) error {
+	if err := gapLimits.Validate(); err != nil {
+		return err
+	}
+	var btcAccount *btc.Account
+	for _, account := range backend.Accounts() {
+		if account.Code() != code {
+			continue
+		}
+		specificAccount, ok := account.(*btc.Account)
+		if !ok {
+			return errp.New("Gap limits can only be set for btc based accounts.")
+		}
+		btcAccount = specificAccount
+	}
+	_, err := backend.modifyPersistedAccount(code, func(account *config.Account) {
+		account.GapLimits = gapLimits
+	})
+	if err != nil {
+		return err
+	}
+	if btcAccount != nil {
+		return btcAccount.SetGapLimits(gapLimits)
+	}
 	return nil
 }
 
-func (backend *Backend) createAndAddAccount(
+// keystoreAccountType is a type of keystore accounts of which several accounts can exist, one per
+// BIP44 account index.
+type keystoreAccountType struct {
+	coin          coin.Coin
+	code          string
+	name          string
+	keypathPrefix signing.AbsoluteKeypath
+	// accountIndexHardened is true if the account index is a hardened child of the keypath prefix.
+	// Ethereum accounts are single addresses at `m/44'/60'/0'/0/<account index>`, as in most
+	// other Ethereum wallets.
+	accountIndexHardened bool
+	scriptType           signing.ScriptType
+	// rootFingerprint identifies the keystores the accounts are derived from, see
+	// config.Account.RootFingerprint.
+	rootFingerprint string
+}
+
+// accountKeypath returns the keypath of the account with the given BIP44 account index.
+func (accountType *keystoreAccountType) accountKeypath(accountIndex uint32) signing.AbsoluteKeypath {
+	return accountType.keypathPrefix.Child(accountIndex, accountType.accountIndexHardened)
+}
+
+// lastAccountIndex returns the highest account index of the persisted accounts of this type, or 0
+// if only the first account exists.
+func (accountType *keystoreAccountType) lastAccountIndex(accountsConfig config.AccountsConfig) uint32 {
+	lastAccountIndex := uint32(0)
+	for _, persistedAccount := range accountsConfig.Accounts {
+		accountIndex, ok := accountType.accountIndex(persistedAccount)
+		if ok && accountIndex > lastAccountIndex {
+			lastAccountIndex = accountIndex
+		}
+	}
+	return lastAccountIndex
+}
+
+// accountIndex returns the account index of the persisted account if it is of this type.
+func (accountType *keystoreAccountType) accountIndex(persistedAccount config.Account) (uint32, bool) {
+	if !persistedAccount.Keystore || persistedAccount.CoinCode != accountType.coin.Code() ||
+		persistedAccount.ScriptType != accountType.scriptType ||
+		persistedAccount.RootFingerprint != accountType.rootFingerprint {
+		return 0, false
+	}
+	return bip44AccountIndex(
+		accountType.keypathPrefix, persistedAccount.Keypath, accountType.accountIndexHardened)
+}
+
+// createAndAddKeystoreAccounts adds the first account (BIP44 account index 0) of the given type, as
+// well as all previously discovered or created accounts of the same type, and starts the discovery
+// of further accounts in the background. The keypath prefix is the account keypath without the
+// account index, e.g. `m/84'/0'`, or `m/44'/60'/0'/0` for ethereum.
+func (backend *Backend) createAndAddKeystoreAccounts(
 	coin coin.Coin,
 	code string,
 	name string,
-	keypath string,
+	keypathPrefix string,
 	scriptType signing.ScriptType,
 ) {
-	if !backend.arguments.Multisig() && !backend.config.AppConfig().Backend.AccountActive(code) {
+	if !backend.config.AppConfig().Backend.AccountActive(code) {
 		backend.log.WithField("code", code).WithField("name", name).Info("skipping inactive account")
 		return
 	}
-	backend.log.WithField("code", code).WithField("name", name).Info("init account")
-	absoluteKeypath, err := signing.NewAbsoluteKeypath(keypath)
+	if !backend.mainKeystores().SupportsScriptType(scriptType) {
+		backend.log.WithField("code", code).WithField("name", name).
+			Info("skipping account not supported by the keystore")
+		return
+	}
+	prefix, err := signing.NewAbsoluteKeypath(keypathPrefix)
 	if err != nil {
 		panic(err)
 	}
+	_, isETH := coin.(*eth.Coin)
+	accountType := &keystoreAccountType{
+		coin:                 coin,
+		code:                 code,
+		name:                 name,
+		keypathPrefix:        prefix,
+		accountIndexHardened: !isETH,
+		scriptType:           scriptType,
+		rootFingerprint:      backend.rootFingerprint(),
+	}
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		backend.keystoreAccountTypes = append(backend.keystoreAccountTypes, accountType)
+	}()
+	backend.addKeystoreAccount(coin, code, name, accountType.accountKeypath(0), scriptType)
+
+	accountsConfig := backend.config.AccountsConfig()
+	for _, persistedAccount := range accountsConfig.Accounts {
+		accountIndex, ok := accountType.accountIndex(persistedAccount)
+		if !ok || accountIndex == 0 {
+			continue
+		}
+		backend.addKeystoreAccount(
+			coin, persistedAccount.Code, persistedAccount.Name, persistedAccount.Keypath, scriptType)
+	}
+	go backend.discoverAccounts(
+		backend.mainKeystores(), accountType, accountType.lastAccountIndex(accountsConfig))
+}
+
+// bip44AccountIndex returns the account index of the keypath if it is the keypath prefix followed
+// by an account index, which must be hardened if hardened is true and unhardened otherwise.
+func bip44AccountIndex(
+	prefix signing.AbsoluteKeypath, keypath signing.AbsoluteKeypath, hardened bool) (uint32, bool) {
+	prefixNodes := prefix.ToUInt32()
+	nodes := keypath.ToUInt32()
+	if len(nodes) != len(prefixNodes)+1 {
+		return 0, false
+	}
+	for index, node := range prefixNodes {
+		if nodes[index] != node {
+			return 0, false
+		}
+	}
+	accountNode := nodes[len(nodes)-1]
+	if !hardened {
+		if accountNode >= hdkeychain.HardenedKeyStart {
+			return 0, false
+		}
+		return accountNode, true
+	}
+	if accountNode < hdkeychain.HardenedKeyStart {
+		return 0, false
+	}
+	return accountNode - hdkeychain.HardenedKeyStart, true
+}
+
+// addKeystoreAccount adds an account whose signing configuration is derived from the registered
+// keystores at the given keypath. If the account is persisted, the persisted name is used, and
+// archived accounts are not added.
+func (backend *Backend) addKeystoreAccount(
+	coin coin.Coin,
+	code string,
+	name string,
+	keypath signing.AbsoluteKeypath,
+	scriptType signing.ScriptType,
+) {
+	var rootFingerprints [][4]byte
+	func() {
+		defer backend.keystoreAccountsLock.RLock()()
+		rootFingerprints = backend.rootFingerprints
+	}()
+	keystoreAccount := config.Account{
+		CoinCode:        coin.Code(),
+		Code:            code,
+		Name:            name,
+		Keystore:        true,
+		ScriptType:      scriptType,
+		Keypath:         keypath,
+		RootFingerprint: encodeRootFingerprints(rootFingerprints),
+	}
+	persistedAccount := backend.config.AccountsConfig().LookupForKeystore(
+		code, keystoreAccount.RootFingerprint)
+	if persistedAccount != nil {
+		keystoreAccount = *persistedAccount
+	}
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		backend.keystoreAccounts[code] = keystoreAccount
+	}()
+	log := backend.log.WithField("code", code).WithField("name", keystoreAccount.Name)
+	if keystoreAccount.Archived {
+		log.Info("skipping archived account")
+		return
+	}
+	// A watch-only account of another keystore with the same code makes way for this account.
+	backend.uninitWatchOnlyAccounts(func(watchOnlyCode string, _ string) bool {
+		return watchOnlyCode == code
+	})
+	if backend.account(code) != nil {
+		return
+	}
+	log.Info("init account")
 	getSigningConfiguration := func() (*signing.Configuration, error) {
-		return backend.keystores.Configuration(coin, scriptType, absoluteKeypath, backend.keystores.Count())
+		configuration, err := backend.keystoreAccountConfiguration(
+			coin, scriptType, keypath, rootFingerprints)
+		if err != nil {
+			return nil, err
+		}
+		backend.rememberKeystoreAccount(code, keystoreAccount.RootFingerprint, configuration)
+		return configuration, nil
+	}
+	var gapLimits *types.GapLimits
+	if persistedAccount != nil {
+		gapLimits = persistedAccount.GapLimits
 	}
-	if backend.arguments.Multisig() {
-		name += " Multisig"
+	backend.loadAccount(
+		coin, code, keystoreAccount.Name, getSigningConfiguration, backend.mainKeystores(), gapLimits)
+}
+
+// discoverAccounts performs BIP44 account discovery, starting at the given account index. If the
+// account has a transaction history, the next account is added and scanned, until an account
+// without history is found. Every discovered account is persisted, so it is loaded without
+// scanning the next time the keystore is registered. Discovery stops when the keystores change.
+func (backend *Backend) discoverAccounts(
+	keystores *keystore.Keystores,
+	accountType *keystoreAccountType,
+	startAccountIndex uint32,
+) {
+	log := backend.log.WithField("code", accountType.code)
+	accountType.coin.Initialize()
+	for accountIndex := startAccountIndex; ; accountIndex++ {
+		if !backend.keystoreAccountTypeActive(accountType) {
+			log.Info("keystores changed, stopping account discovery")
+			return
+		}
+		used, err := backend.keystoreAccountHasHistory(keystores, accountType, accountIndex)
+		if err != nil {
+			log.WithError(err).Error("account discovery: could not scan the account")
+			return
+		}
+		if !used {
+			return
+		}
+		nextAccountIndex := accountIndex + 1
+		log.WithField("account-index", nextAccountIndex).Info("discovered account")
+		_, err = backend.persistKeystoreAccount(accountType, nextAccountIndex, "")
+		if err != nil && errp.Cause(err) != ErrAccountAlreadyExists {
+			log.WithError(err).Error("account discovery: could not persist the account")
+			return
+		}
 	}
-	err = backend.CreateAndAddAccount(coin, code, name, getSigningConfiguration, false)
+}
+
+// keystoreAccountHasHistory returns whether the account of the given type and account index has a
+// transaction history.
+func (backend *Backend) keystoreAccountHasHistory(
+	keystores *keystore.Keystores,
+	accountType *keystoreAccountType,
+	accountIndex uint32,
+) (bool, error) {
+	configuration, err := keystores.Configuration(
+		accountType.coin, accountType.scriptType, accountType.accountKeypath(accountIndex), keystores.Count())
 	if err != nil {
-		panic(err)
+		return false, err
+	}
+	switch specificCoin := accountType.coin.(type) {
+	case *btc.Coin:
+		return btc.HasHistory(specificCoin.Blockchain(), specificCoin.Net(), configuration, backend.log)
+	case *eth.Coin:
+		return eth.HasHistory(specificCoin, configuration)
+	default:
+		return false, errp.New("account discovery is not supported for this coin")
 	}
 }
 
+// persistKeystoreAccount persists the account of the given type and account index and adds it to
+// the backend. If the name is empty, a name is derived from the name of the account type. It
+// returns the code of the account, or ErrAccountAlreadyExists if the account was already persisted.
+func (backend *Backend) persistKeystoreAccount(
+	accountType *keystoreAccountType,
+	accountIndex uint32,
+	name string,
+) (string, error) {
+	code := config.KeystoreAccountCode(accountType.code, accountIndex)
+	if name == "" {
+		name = fmt.Sprintf("%s %d", accountType.name, accountIndex+1)
+	}
+	keypath := accountType.accountKeypath(accountIndex)
+	err := backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		if accountsConfig.LookupForKeystore(code, accountType.rootFingerprint) != nil {
+			return errp.WithStack(ErrAccountAlreadyExists)
+		}
+		accountsConfig.Accounts = append(accountsConfig.Accounts, config.Account{
+			CoinCode:        accountType.coin.Code(),
+			Code:            code,
+			Name:            name,
+			Keystore:        true,
+			ScriptType:      accountType.scriptType,
+			Keypath:         keypath,
+			RootFingerprint: accountType.rootFingerprint,
+		})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if backend.keystoreAccountTypeActive(accountType) {
+		backend.addKeystoreAccount(accountType.coin, code, name, keypath, accountType.scriptType)
+	}
+	return code, nil
+}
+
 // Config returns the app config.
 func (backend *Backend) Config() *config.Config {
 	return backend.config
@@ -355,6 +723,26 @@ func (backend *Backend) defaultElectrumXServers(code string) []*rpc.ServerInfo {
 	return backend.defaultProdServers(code)
 }
 
+// makeETHIndexer returns the function creating the configured indexer of the ethereum coin with
+// the given code.
+func (backend *Backend) makeETHIndexer(
+	code string, net *params.ChainConfig, etherScanURL string) eth.MakeIndexer {
+	ethConfig := backend.config.AppConfig().Backend.ETHCoinConfig(code)
+	return func(client eth.NodeClient) eth.Indexer {
+		if ethConfig.Indexer == config.ETHIndexerNode {
+			log := backend.log.WithField("code", code)
+			log.Infof("scanning the blocks of %s from block %d", ethConfig.NodeURL, ethConfig.BirthdayBlock)
+			db, err := nodeindexer.NewDB(filepath.Join(
+				backend.arguments.CacheDirectoryPath(), fmt.Sprintf("nodeindexer-%s.db", code)))
+			if err != nil {
+				log.WithError(err).Error("Could not open the database, the blocks are scanned again on restart")
+			}
+			return nodeindexer.NewNodeIndexer(client, net, ethConfig.BirthdayBlock, db)
+		}
+		return etherscan.NewEtherScan(etherScanURL)
+	}
+}
+
 // Coin returns the coin with the given code or an error if no such coin exists.
 func (backend *Backend) Coin(code string) (coin.Coin, error) {
 	defer backend.coinsLock.Lock()()
@@ -385,13 +773,16 @@ func (backend *Backend) Coin(code string) (coin.Coin, error) {
 			"https://insight.litecore.io/tx/")
 	case coinETH:
 		coin = eth.NewCoin(code, params.MainnetChainConfig,
-			"https://etherscan.io/tx/", backend.config.AppConfig().Backend.ETH.NodeURL)
+			"https://etherscan.io/tx/", backend.config.AppConfig().Backend.ETH.NodeURL,
+			backend.makeETHIndexer(code, params.MainnetChainConfig, "https://api.etherscan.io/api"))
 	case coinRETH:
 		coin = eth.NewCoin(code, params.RinkebyChainConfig,
-			"https://rinkeby.etherscan.io/tx/", backend.config.AppConfig().Backend.RETH.NodeURL)
+			"https://rinkeby.etherscan.io/tx/", backend.config.AppConfig().Backend.RETH.NodeURL,
+			backend.makeETHIndexer(code, params.RinkebyChainConfig, "https://api-rinkeby.etherscan.io/api"))
 	case coinTETH:
 		coin = eth.NewCoin(code, params.TestnetChainConfig,
-			"https://ropsten.etherscan.io/tx/", backend.config.AppConfig().Backend.TETH.NodeURL)
+			"https://ropsten.etherscan.io/tx/", backend.config.AppConfig().Backend.TETH.NodeURL,
+			backend.makeETHIndexer(code, params.TestnetChainConfig, "https://api-ropsten.etherscan.io/api"))
 	default:
 		return nil, errp.Newf("unknown coin code %s", code)
 	}
@@ -400,100 +791,99 @@ func (backend *Backend) Coin(code string) (coin.Coin, error) {
 	return coin, nil
 }
 
+// initPersistedAccounts loads the persisted accounts, including the keystore accounts which are
+// loaded as watch-only accounts because their keystore is not registered.
+// initPersistedAccounts loads the persisted accounts. An account which cannot be loaded, e.g.
+// because of an invalid configuration, is skipped so that the other accounts are still available.
 func (backend *Backend) initPersistedAccounts() {
 	for _, account := range backend.config.AccountsConfig().Accounts {
-		account := account
-		if _, isTestnet := testnetCoins[account.CoinCode]; isTestnet != backend.Testing() {
-			// Don't load testnet accounts when running normally, nor mainnet accounts when running
-			// in testing mode
-			continue
-		}
-		coin, err := backend.Coin(account.CoinCode)
-		if err != nil {
-			backend.log.Errorf("skipping persisted account %s/%s, could not find coin",
+		if err := backend.loadPersistedAccount(account); err != nil {
+			backend.log.WithError(err).Errorf("skipping persisted account %s/%s, could not load it",
 				account.CoinCode, account.Code)
 		}
-		getSigningConfiguration := func() (*signing.Configuration, error) {
-			return account.Configuration, nil
-		}
-		err = backend.CreateAndAddAccount(coin, account.Code, account.Name, getSigningConfiguration, false)
-		if err != nil {
-			panic(err)
-		}
 	}
 }
 
 func (backend *Backend) initAccounts() {
-	// Since initAccounts replaces all previous accounts, we need to properly close them first.
-	backend.uninitAccounts()
+	// Since initAccounts replaces the previous keystore accounts, we need to properly close them
+	// first.
+	backend.uninitKeystoreAccounts()
+	rootFingerprints, err := backend.mainKeystores().RootFingerprints()
+	if err != nil {
+		backend.log.WithError(err).Warning(
+			"could not get the root fingerprints, keystore accounts are not told apart by keystore")
+		rootFingerprints = nil
+	}
+	backend.claimLegacyKeystoreAccounts(encodeRootFingerprints(rootFingerprints))
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		backend.rootFingerprints = rootFingerprints
+	}()
+	// The watch-only accounts of the registered keystore are replaced by its keystore accounts.
+	registeredRootFingerprint := encodeRootFingerprints(rootFingerprints)
+	backend.uninitWatchOnlyAccounts(func(_ string, rootFingerprint string) bool {
+		return rootFingerprint == registeredRootFingerprint
+	})
 
 	if backend.arguments.Testing() {
 		switch {
-		case backend.arguments.Multisig():
-			TBTC, _ := backend.Coin(coinTBTC)
-			backend.createAndAddAccount(TBTC, "tbtc-multisig", "Bitcoin Testnet", "m/48'/1'/0'",
-				signing.ScriptTypeP2PKH)
-			TLTC, _ := backend.Coin(coinTLTC)
-			backend.createAndAddAccount(TLTC, "tltc-multisig", "Litecoin Testnet", "m/48'/1'/0'",
-				signing.ScriptTypeP2PKH)
 		case backend.arguments.Regtest():
 			RBTC, _ := backend.Coin("rbtc")
-			backend.createAndAddAccount(RBTC, "rbtc-p2pkh", "Bitcoin Regtest Legacy", "m/44'/1'/0'",
+			backend.createAndAddKeystoreAccounts(RBTC, "rbtc-p2pkh", "Bitcoin Regtest Legacy", "m/44'/1'",
 				signing.ScriptTypeP2PKH)
-			backend.createAndAddAccount(RBTC, "rbtc-p2wpkh-p2sh", "Bitcoin Regtest Segwit", "m/49'/1'/0'",
+			backend.createAndAddKeystoreAccounts(RBTC, "rbtc-p2wpkh-p2sh", "Bitcoin Regtest Segwit", "m/49'/1'",
 				signing.ScriptTypeP2WPKHP2SH)
+			backend.createAndAddKeystoreAccounts(RBTC, "rbtc-p2tr", "Bitcoin Regtest Taproot", "m/86'/1'",
+				signing.ScriptTypeP2TR)
 		default:
 			TBTC, _ := backend.Coin(coinTBTC)
-			backend.createAndAddAccount(TBTC, "tbtc-p2wpkh-p2sh", "Bitcoin Testnet", "m/49'/1'/0'",
+			backend.createAndAddKeystoreAccounts(TBTC, "tbtc-p2wpkh-p2sh", "Bitcoin Testnet", "m/49'/1'",
 				signing.ScriptTypeP2WPKHP2SH)
-			backend.createAndAddAccount(TBTC, "tbtc-p2wpkh", "Bitcoin Testnet: bech32", "m/84'/1'/0'",
+			backend.createAndAddKeystoreAccounts(TBTC, "tbtc-p2wpkh", "Bitcoin Testnet: bech32", "m/84'/1'",
 				signing.ScriptTypeP2WPKH)
-			backend.createAndAddAccount(TBTC, "tbtc-p2pkh", "Bitcoin Testnet Legacy", "m/44'/1'/0'",
+			backend.createAndAddKeystoreAccounts(TBTC, "tbtc-p2pkh", "Bitcoin Testnet Legacy", "m/44'/1'",
 				signing.ScriptTypeP2PKH)
+			backend.createAndAddKeystoreAccounts(TBTC, "tbtc-p2tr", "Bitcoin Testnet: taproot", "m/86'/1'",
+				signing.ScriptTypeP2TR)
 
 			TLTC, _ := backend.Coin(coinTLTC)
-			backend.createAndAddAccount(TLTC, "tltc-p2wpkh-p2sh", "Litecoin Testnet", "m/49'/1'/0'",
+			backend.createAndAddKeystoreAccounts(TLTC, "tltc-p2wpkh-p2sh", "Litecoin Testnet", "m/49'/1'",
 				signing.ScriptTypeP2WPKHP2SH)
-			backend.createAndAddAccount(TLTC, "tltc-p2wpkh", "Litecoin Testnet: bech32", "m/84'/1'/0'",
+			backend.createAndAddKeystoreAccounts(TLTC, "tltc-p2wpkh", "Litecoin Testnet: bech32", "m/84'/1'",
 				signing.ScriptTypeP2WPKH)
 
 			if backend.arguments.DevMode() {
 				TETH, _ := backend.Coin(coinTETH)
-				backend.createAndAddAccount(TETH, "teth", "Ethereum Ropsten", "m/44'/1'/0'/0/0", signing.ScriptTypeP2WPKH)
+				backend.createAndAddKeystoreAccounts(TETH, "teth", "Ethereum Ropsten", "m/44'/1'/0'/0",
+					signing.ScriptTypeP2WPKH)
 				RETH, _ := backend.Coin(coinRETH)
-				backend.createAndAddAccount(RETH, "reth", "Ethereum Rinkeby", "m/44'/1'/0'/0/0", signing.ScriptTypeP2WPKH)
+				backend.createAndAddKeystoreAccounts(RETH, "reth", "Ethereum Rinkeby", "m/44'/1'/0'/0",
+					signing.ScriptTypeP2WPKH)
 			}
 		}
 	} else {
-		if backend.arguments.Multisig() {
-			BTC, _ := backend.Coin(coinBTC)
-			backend.createAndAddAccount(BTC, "btc-multisig", "Bitcoin", "m/48'/0'/0'",
-				signing.ScriptTypeP2PKH)
-			LTC, _ := backend.Coin(coinLTC)
-			backend.createAndAddAccount(LTC, "ltc-multisig", "Litecoin", "m/48'/2'/0'",
-				signing.ScriptTypeP2PKH)
-		} else {
-			BTC, _ := backend.Coin(coinBTC)
-			backend.createAndAddAccount(BTC, "btc-p2wpkh-p2sh", "Bitcoin", "m/49'/0'/0'",
-				signing.ScriptTypeP2WPKHP2SH)
-			backend.createAndAddAccount(BTC, "btc-p2wpkh", "Bitcoin: bech32", "m/84'/0'/0'",
-				signing.ScriptTypeP2WPKH)
-			backend.createAndAddAccount(BTC, "btc-p2pkh", "Bitcoin Legacy", "m/44'/0'/0'",
-				signing.ScriptTypeP2PKH)
-
-			LTC, _ := backend.Coin(coinLTC)
-			backend.createAndAddAccount(LTC, "ltc-p2wpkh-p2sh", "Litecoin", "m/49'/2'/0'",
-				signing.ScriptTypeP2WPKHP2SH)
-			backend.createAndAddAccount(LTC, "ltc-p2wpkh", "Litecoin: bech32", "m/84'/2'/0'",
+		BTC, _ := backend.Coin(coinBTC)
+		backend.createAndAddKeystoreAccounts(BTC, "btc-p2wpkh-p2sh", "Bitcoin", "m/49'/0'",
+			signing.ScriptTypeP2WPKHP2SH)
+		backend.createAndAddKeystoreAccounts(BTC, "btc-p2wpkh", "Bitcoin: bech32", "m/84'/0'",
+			signing.ScriptTypeP2WPKH)
+		backend.createAndAddKeystoreAccounts(BTC, "btc-p2pkh", "Bitcoin Legacy", "m/44'/0'",
+			signing.ScriptTypeP2PKH)
+		backend.createAndAddKeystoreAccounts(BTC, "btc-p2tr", "Bitcoin: taproot", "m/86'/0'",
+			signing.ScriptTypeP2TR)
+
+		LTC, _ := backend.Coin(coinLTC)
+		backend.createAndAddKeystoreAccounts(LTC, "ltc-p2wpkh-p2sh", "Litecoin", "m/49'/2'",
+			signing.ScriptTypeP2WPKHP2SH)
+		backend.createAndAddKeystoreAccounts(LTC, "ltc-p2wpkh", "Litecoin: bech32", "m/84'/2'",
+			signing.ScriptTypeP2WPKH)
+
+		if backend.arguments.DevMode() {
+			ETH, _ := backend.Coin(coinETH)
+			backend.createAndAddKeystoreAccounts(ETH, "eth", "Ethereum", "m/44'/60'/0'/0",
 				signing.ScriptTypeP2WPKH)
-
-			if backend.arguments.DevMode() {
-				ETH, _ := backend.Coin(coinETH)
-				backend.createAndAddAccount(ETH, "eth", "Ethereum", "m/44'/60'/0'/0/0", signing.ScriptTypeP2WPKH)
-			}
 		}
 	}
-	backend.initPersistedAccounts()
 }
 
 // AccountsStatus returns whether the accounts have been initialized.
@@ -567,15 +957,24 @@ func (backend *Backend) DevicesRegistered() map[string]device.Interface {
 	return backend.devices
 }
 
-func (backend *Backend) uninitAccounts() {
-	defer backend.accountsLock.Lock()()
-	for _, account := range backend.accounts {
-		account := account
-		backend.onAccountUninit(account)
-		account.Close()
+// uninitKeystoreAccounts closes the accounts derived from the registered keystores. The other
+// accounts stay loaded.
+func (backend *Backend) uninitKeystoreAccounts() {
+	codes := []string{}
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		for code := range backend.keystoreAccounts {
+			codes = append(codes, code)
+		}
+		backend.keystoreAccountTypes = nil
+		backend.keystoreAccounts = map[string]config.Account{}
+		backend.rootFingerprints = nil
+	}()
+	for _, code := range codes {
+		if backend.account(code) != nil {
+			backend.removeAccount(code)
+		}
 	}
-	backend.accounts = []accounts.Interface{}
-	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
 }
 
 // Keystores returns the keystores registered at this backend.
@@ -583,28 +982,113 @@ func (backend *Backend) Keystores() *keystore.Keystores {
 	return backend.keystores
 }
 
-// RegisterKeystore registers the given keystore at this backend.
+// mainKeystores returns the main keystore, which is the first registered keystore. The keystore
+// accounts are derived from it. The other registered keystores are cosigners of multisig accounts.
+func (backend *Backend) mainKeystores() *keystore.Keystores {
+	if backend.keystores.Count() == 0 {
+		return keystore.NewKeystores()
+	}
+	return keystore.NewKeystores(backend.keystores.AccessKeystoreByIndex(0))
+}
+
+// RegisterKeystore registers the given keystore at this backend, in addition to the registered
+// keystores. The cosigner index of the keystore must be its position among the registered
+// keystores. The keystore accounts are loaded when the first keystore is registered.
 func (backend *Backend) RegisterKeystore(keystore keystore.Keystore) {
 	backend.log.Info("registering keystore")
+	if keystore.CosignerIndex() != backend.keystores.Count() {
+		backend.log.Panic("The cosigner index of the keystore must be its position.")
+	}
 	if err := backend.keystores.Add(keystore); err != nil {
 		backend.log.Panic("Failed to add a keystore.", err)
 	}
-	if backend.arguments.Multisig() && backend.keystores.Count() != 2 {
-		return
+	if backend.keystores.Count() == 1 {
+		backend.initAccounts()
 	}
-	backend.initAccounts()
 }
 
-// DeregisterKeystore removes the registered keystore.
+// DeregisterKeystore removes the registered keystores and closes the accounts derived from them.
+// The other accounts, e.g. the ones added from an extended public key, stay loaded.
 func (backend *Backend) DeregisterKeystore() {
 	backend.log.Info("deregistering keystore")
-	backend.keystores = keystore.NewKeystores()
-	backend.uninitAccounts()
-	// TODO: classify accounts by keystore, remove only the ones belonging to the deregistered
-	// keystore. For now we just remove all, then re-add the rest.
+	backend.uninitKeystoreAccounts()
+	backend.keystores.Wipe()
+	backend.keystores.RemoveAll()
+	backend.deviceKeystores = map[string]keystore.Keystore{}
+	// The accounts of the keystore are loaded as watch-only accounts if enabled.
 	backend.initPersistedAccounts()
 }
 
+// deregisterDeviceKeystore deregisters the keystore of the device with the given ID, if it is
+// registered. The keystores of other devices or software keystores stay registered.
+func (backend *Backend) deregisterDeviceKeystore(deviceID string) {
+	deviceKeystore, ok := backend.deviceKeystores[deviceID]
+	if !ok {
+		return
+	}
+	delete(backend.deviceKeystores, deviceID)
+	backend.deregisterKeystore(deviceKeystore)
+}
+
+// deregisterKeystore removes the given keystore. As the cosigner index of a keystore is its
+// position, the keystores registered after it are removed as well. The ones of devices are
+// registered again at their new position, software keystores are wiped. If the main keystore is
+// removed, the keystore accounts are loaded from the next keystore.
+func (backend *Backend) deregisterKeystore(removedKeystore keystore.Keystore) {
+	position := -1
+	for index := 0; index < backend.keystores.Count(); index++ {
+		if backend.keystores.AccessKeystoreByIndex(index) == removedKeystore {
+			position = index
+		}
+	}
+	if position == -1 {
+		return
+	}
+	backend.log.WithField("cosigner-index", position).Info("deregistering keystore")
+	if position == 0 {
+		backend.uninitKeystoreAccounts()
+	}
+	followingKeystores := []keystore.Keystore{}
+	for index := position; index < backend.keystores.Count(); index++ {
+		followingKeystores = append(followingKeystores, backend.keystores.AccessKeystoreByIndex(index))
+	}
+	for _, followingKeystore := range followingKeystores {
+		if err := backend.keystores.Remove(followingKeystore); err != nil {
+			backend.log.Panic("Failed to remove a keystore.", err)
+		}
+	}
+	if position == 0 {
+		// The accounts of the keystore are loaded as watch-only accounts if enabled.
+		backend.initPersistedAccounts()
+	}
+	devices := map[keystore.Keystore]device.Interface{}
+	for deviceID, deviceKeystore := range backend.deviceKeystores {
+		if theDevice, ok := backend.devices[deviceID]; ok {
+			devices[deviceKeystore] = theDevice
+		}
+	}
+	for _, followingKeystore := range followingKeystores {
+		theDevice, ok := devices[followingKeystore]
+		if followingKeystore == removedKeystore || !ok {
+			keystore.NewKeystores(followingKeystore).Wipe()
+			continue
+		}
+		backend.registerDeviceKeystore(theDevice)
+	}
+}
+
+// registerDeviceKeystore registers the keystore of the given device, replacing its previous
+// keystore. The cosigner index of the keystore is its position among the registered keystores.
+func (backend *Backend) registerDeviceKeystore(theDevice device.Interface) {
+	backend.deregisterDeviceKeystore(theDevice.Identifier())
+	deviceKeystore := theDevice.KeystoreForConfiguration(nil, backend.keystores.Count())
+	if deviceKeystore == nil {
+		return
+	}
+	backend.deviceKeystores[theDevice.Identifier()] = deviceKeystore
+	backend.RegisterKeystore(deviceKeystore)
+}
+
 // Register registers the given device at this backend.
 func (backend *Backend) Register(theDevice device.Interface) error {
 	if theDevice.ProductName() == "bitbox02" && !backend.arguments.DevMode() {
@@ -615,11 +1099,10 @@ func (backend *Backend) Register(theDevice device.Interface) error {
 	backend.onDeviceInit(theDevice)
 	theDevice.Init(backend.Testing())
 
-	mainKeystore := len(backend.devices) == 1
 	theDevice.SetOnEvent(func(event device.Event, data interface{}) {
 		switch event {
 		case device.EventKeystoreGone:
-			backend.DeregisterKeystore()
+			backend.deregisterDeviceKeystore(theDevice.Identifier())
 		case device.EventKeystoreAvailable:
 			// absoluteKeypath := signing.NewEmptyAbsoluteKeypath().Child(44, signing.Hardened)
 			// extendedPublicKey, err := backend.device.ExtendedPublicKey(absoluteKeypath)
@@ -628,16 +1111,7 @@ func (backend *Backend) Register(theDevice device.Interface) error {
 			// }
 			// configuration := signing.NewConfiguration(absoluteKeypath,
 			// 	[]*hdkeychain.ExtendedKey{extendedPublicKey}, 1)
-			if backend.arguments.Multisig() {
-				backend.RegisterKeystore(
-					theDevice.KeystoreForConfiguration(nil, backend.keystores.Count()))
-			} else if mainKeystore {
-				// HACK: for device based, only one is supported at the moment.
-				backend.keystores = keystore.NewKeystores()
-
-				backend.RegisterKeystore(
-					theDevice.KeystoreForConfiguration(nil, backend.keystores.Count()))
-			}
+			backend.registerDeviceKeystore(theDevice)
 		}
 		backend.events <- deviceEvent{
 			DeviceID: theDevice.Identifier(),
@@ -661,7 +1135,7 @@ func (backend *Backend) Deregister(deviceID string) {
 	if _, ok := backend.devices[deviceID]; ok {
 		backend.onDeviceUninit(deviceID)
 		delete(backend.devices, deviceID)
-		backend.DeregisterKeystore()
+		backend.deregisterDeviceKeystore(deviceID)
 		backend.events <- backendEvent{Type: "devices", Data: "registeredChanged"}
 	}
 }
@@ -719,9 +1193,36 @@ func (backend *Backend) CheckElectrumServer(server string, pemCert string) error
 // RegisterTestKeystore adds a keystore derived deterministically from a PIN, for convenience in
 // devmode.
 func (backend *Backend) RegisterTestKeystore(pin string) {
-	softwareBasedKeystore := software.NewKeystoreFromPIN(
-		backend.keystores.Count(), pin)
+	softwareBasedKeystore := software.NewKeystoreFromPIN(backend.keystores.Count(), pin)
+	backend.RegisterKeystore(softwareBasedKeystore)
+}
+
+// softwareKeystoreNet returns the network for which software keystores are created.
+func (backend *Backend) softwareKeystoreNet() *chaincfg.Params {
+	switch {
+	case backend.arguments.Regtest():
+		return &chaincfg.RegressionNetParams
+	case backend.arguments.Testing():
+		return &chaincfg.TestNet3Params
+	default:
+		return &chaincfg.MainNetParams
+	}
+}
+
+// RegisterMnemonicKeystore adds a software keystore created from a BIP39 mnemonic and an optional
+// passphrase. As the seed is exposed to this computer, it is only available in testing mode or if
+// explicitly enabled. The key material is wiped when the keystore is deregistered.
+func (backend *Backend) RegisterMnemonicKeystore(mnemonic string, passphrase string) error {
+	if !backend.arguments.Testing() && !backend.arguments.SoftwareKeystore() {
+		return errp.New("Software keystore not available")
+	}
+	softwareBasedKeystore, err := software.NewKeystoreFromMnemonic(
+		backend.keystores.Count(), mnemonic, passphrase, backend.softwareKeystoreNet())
+	if err != nil {
+		return err
+	}
 	backend.RegisterKeystore(softwareBasedKeystore)
+	return nil
 }
 
 // NotifyUser creates a desktop notification.
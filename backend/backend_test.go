@@ -0,0 +1,72 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/arguments"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	keystoremocks "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/mocks"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnsupportedScriptType checks that no taproot accounts are added for a keystore which cannot
+// sign for taproot outputs, and that none can be created.
+func TestUnsupportedScriptType(t *testing.T) {
+	backend, err := NewBackend(arguments.NewArguments(
+		test.TstTempDir("bitbox-wallet-unsupported-script-type-"), true, false, false, false), nil)
+	require.NoError(t, err)
+	appConfig := backend.config.AppConfig()
+	appConfig.Backend.BitcoinP2TRActive = true
+	require.NoError(t, backend.config.SetAppConfig(appConfig))
+	mainKeystore := &keystoremocks.Keystore{}
+	mainKeystore.On("SupportsScriptType", signing.ScriptTypeP2TR).Return(false)
+	// The keystore is not registered, as that would load all keystore accounts.
+	backend.keystores = keystore.NewKeystores(mainKeystore)
+
+	tbtc, err := backend.Coin(coinTBTC)
+	require.NoError(t, err)
+	backend.createAndAddKeystoreAccounts(
+		tbtc, "tbtc-p2tr", "Bitcoin Testnet: taproot", "m/86'/1'", signing.ScriptTypeP2TR)
+	require.Empty(t, backend.keystoreAccountTypes)
+	require.Empty(t, backend.Accounts())
+
+	_, err = backend.CreateKeystoreAccount(coinTBTC, signing.ScriptTypeP2TR, "")
+	require.Equal(t, ErrScriptTypeUnsupported, errp.Cause(err))
+	mainKeystore.AssertNumberOfCalls(t, "SupportsScriptType", 2)
+}
+
+// TestInitPersistedAccountsSkipsInvalidAccount checks that a persisted account which cannot be
+// loaded is skipped instead of crashing the app.
+func TestInitPersistedAccountsSkipsInvalidAccount(t *testing.T) {
+	backend, err := NewBackend(arguments.NewArguments(
+		test.TstTempDir("bitbox-wallet-invalid-persisted-account-"), true, false, false, false), nil)
+	require.NoError(t, err)
+	require.NoError(t, backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+		accountsConfig.Accounts = append(accountsConfig.Accounts, config.Account{
+			CoinCode: coinTBTC,
+			Code:     "tbtc-invalid",
+			Name:     "Invalid account",
+		})
+		return nil
+	}))
+	require.NotPanics(t, backend.initPersistedAccounts)
+	require.Empty(t, backend.Accounts())
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"path"
 	"sort"
+	"strings"
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -31,6 +32,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/headers"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/synchronizer"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/ltc"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
@@ -60,6 +62,8 @@ type Account struct {
 	getNotifier             func(*signing.Configuration) accounts.Notifier
 	notifier                accounts.Notifier
 	blockchain              blockchain.Interface
+	// gapLimits are the user configured gap limits. If nil, the defaults are used.
+	gapLimits *types.GapLimits
 
 	receiveAddresses AddressChain
 	changeAddresses  AddressChain
@@ -106,6 +110,7 @@ func NewAccount(
 	name string,
 	getSigningConfiguration func() (*signing.Configuration, error),
 	keystores *keystore.Keystores,
+	gapLimits *types.GapLimits,
 	getNotifier func(*signing.Configuration) accounts.Notifier,
 	onEvent func(accounts.Event),
 	log *logrus.Entry,
@@ -122,6 +127,7 @@ func NewAccount(
 		getSigningConfiguration: getSigningConfiguration,
 		signingConfiguration:    nil,
 		keystores:               keystores,
+		gapLimits:               gapLimits,
 		getNotifier:             getNotifier,
 
 		// feeTargets must be sorted by ascending priority.
@@ -236,17 +242,15 @@ func (account *Account) Initialize() error {
 		account.coin.Net(), account.db, theHeaders, account.synchronizer,
 		account.blockchain, account.notifier, account.log)
 
-	fixGapLimit := gapLimit
-	fixChangeGapLimit := changeGapLimit
-	if account.signingConfiguration.Singlesig() &&
-		account.signingConfiguration.ScriptType() == signing.ScriptTypeP2PKH {
-		// usually 6, but BWS uses 20, so for legacy accounts, we have to do that too.
-		fixChangeGapLimit = 20
-
-		// usually 20, but BWS used to not have any limit. We put it fairly high to cover most
-		// outliers.
-		fixGapLimit = 60
-		account.log.Warning("increased change gap limit to 20 and gap limit to 60 for BWS compatibility")
+	fixGapLimit, fixChangeGapLimit := effectiveGapLimits(account.signingConfiguration, account.gapLimits)
+	gapLimitsLog := account.log.WithFields(
+		logrus.Fields{"gap-limit": fixGapLimit, "change-gap-limit": fixChangeGapLimit})
+	switch {
+	case account.gapLimits != nil:
+		gapLimitsLog.Info("using configured gap limits")
+	case account.signingConfiguration.Singlesig() &&
+		account.signingConfiguration.ScriptType() == signing.ScriptTypeP2PKH:
+		gapLimitsLog.Info("using increased gap limits for BWS compatibility")
 	}
 
 	if account.signingConfiguration.IsAddressBased() {
@@ -312,15 +316,39 @@ func (account *Account) Info() *accounts.Info {
 		)
 		xpubs = append(xpubs, xpubCopy)
 	}
-	return &accounts.Info{
-		SigningConfiguration: signing.NewConfiguration(
-			account.signingConfiguration.ScriptType(),
-			account.signingConfiguration.AbsoluteKeypath(),
-			xpubs,
-			account.signingConfiguration.Address(),
-			account.signingConfiguration.SigningThreshold(),
-		),
+	signingConfiguration, err := signing.NewConfiguration(
+		account.signingConfiguration.ScriptType(),
+		account.signingConfiguration.AbsoluteKeypath(),
+		xpubs,
+		account.signingConfiguration.Address(),
+		account.signingConfiguration.SigningThreshold(),
+	).WithRootFingerprints(account.signingConfiguration.RootFingerprints())
+	if err != nil {
+		panic(err)
+	}
+	info := &accounts.Info{SigningConfiguration: signingConfiguration}
+	if signingConfiguration.Multisig() {
+		info.PolicyHash, err = signingConfiguration.PolicyHash()
+		if err != nil {
+			panic(err)
+		}
+	}
+	// Descriptors always use the xpub/tpub version bytes.
+	descriptorNet := &chaincfg.Params{
+		HDPublicKeyID: XPubVersionForScriptType(account.coin, signing.ScriptTypeP2PKH),
+	}
+	info.Descriptor, err = account.signingConfiguration.Descriptor(descriptorNet, false)
+	if err != nil {
+		account.log.WithError(err).Error("Could not create the descriptor")
+		return info
 	}
+	if !account.signingConfiguration.IsAddressBased() {
+		info.ChangeDescriptor, err = account.signingConfiguration.Descriptor(descriptorNet, true)
+		if err != nil {
+			account.log.WithError(err).Error("Could not create the change descriptor")
+		}
+	}
+	return info
 }
 
 func (account *Account) onNewHeader(header *blockchain.Header) error {
@@ -557,10 +585,13 @@ func (account *Account) Transactions() ([]accounts.Transaction, error) {
 	if account.fatalError {
 		return nil, errp.New("can't call Transactions() after a fatal error")
 	}
-	transactions := account.transactions.Transactions(
+	transactions, err := account.transactions.Transactions(
 		func(scriptHashHex blockchain.ScriptHashHex) bool {
 			return account.changeAddresses.LookupByScriptHashHex(scriptHashHex) != nil
 		})
+	if err != nil {
+		return nil, err
+	}
 	cast := make([]accounts.Transaction, len(transactions))
 	for index, transaction := range transactions {
 		cast[index] = transaction
@@ -579,14 +610,222 @@ func (account *Account) GetUnusedReceiveAddresses() []accounts.Address {
 		return addresses
 	}
 	// Limit to `gapLimit` receive addresses, even if the actual limit is higher when scanning.
-	for _, address := range account.receiveAddresses.GetUnused()[:gapLimit] {
+	unusedAddresses := account.receiveAddresses.GetUnused()
+	if len(unusedAddresses) > gapLimit {
+		unusedAddresses = unusedAddresses[:gapLimit]
+	}
+	for _, address := range unusedAddresses {
 		addresses = append(addresses, address)
 	}
 	return addresses
 }
 
+// effectiveGapLimits returns the receive and change gap limits of an account with the given signing
+// configuration. Configured gap limits can raise the defaults of the script type, but never lower
+// them, so that no funds are hidden which are discovered with the defaults.
+func effectiveGapLimits(
+	signingConfiguration *signing.Configuration, configured *types.GapLimits) (int, int) {
+	receive, change := gapLimit, changeGapLimit
+	if signingConfiguration.Singlesig() && signingConfiguration.ScriptType() == signing.ScriptTypeP2PKH {
+		// usually 6, but BWS uses 20, so for legacy accounts, we have to do that too.
+		change = 20
+
+		// usually 20, but BWS used to not have any limit. We put it fairly high to cover most
+		// outliers.
+		receive = 60
+	}
+	if configured != nil {
+		if int(configured.Receive) > receive {
+			receive = int(configured.Receive)
+		}
+		if int(configured.Change) > change {
+			change = int(configured.Change)
+		}
+	}
+	return receive, change
+}
+
+// SetGapLimits changes the gap limits of the account. If the account is initialized, the address
+// chains are extended to satisfy the new limits, and the new addresses are scanned.
+func (account *Account) SetGapLimits(gapLimits *types.GapLimits) error {
+	if err := gapLimits.Validate(); err != nil {
+		return err
+	}
+	// Keep readers of the address chains waiting until the chains are extended.
+	defer account.synchronizer.IncRequestsCounter()()
+	initialized := func() bool {
+		defer account.Lock()()
+		account.gapLimits = gapLimits
+		if account.signingConfiguration == nil || account.receiveAddresses == nil {
+			// Applied in Initialize().
+			return false
+		}
+		receiveAddresses, ok := account.receiveAddresses.(*addresses.AddressChain)
+		if !ok {
+			// Address based accounts have no gap limit.
+			return false
+		}
+		receive, change := effectiveGapLimits(account.signingConfiguration, gapLimits)
+		receiveAddresses.SetGapLimit(receive)
+		account.changeAddresses.(*addresses.AddressChain).SetGapLimit(change)
+		return true
+	}()
+	if initialized {
+		account.ensureAddresses()
+	}
+	return nil
+}
+
+// GapLimits returns the gap limits configured for the account, or nil if the defaults are used,
+// and the gap limits in effect. The latter are zero for address based accounts, which have no gap
+// limits.
+func (account *Account) GapLimits() (*types.GapLimits, types.GapLimits) {
+	defer account.RLock()()
+	effective := types.GapLimits{}
+	if receiveAddresses, ok := account.receiveAddresses.(*addresses.AddressChain); ok {
+		effective.Receive = uint16(receiveAddresses.GapLimit())
+		effective.Change = uint16(account.changeAddresses.(*addresses.AddressChain).GapLimit())
+	}
+	return account.gapLimits, effective
+}
+
+// AddressInfo describes a derived address of the account.
+type AddressInfo struct {
+	*addresses.AccountAddress
+	Change bool
+	transactions.AddressSummary
+}
+
+// AddressesInfo returns all derived receive addresses, followed by all derived change addresses,
+// together with their labels, balances and number of transactions.
+func (account *Account) AddressesInfo() ([]*AddressInfo, error) {
+	account.synchronizer.WaitSynchronized()
+	result := []*AddressInfo{}
+	scriptHashHexes := []blockchain.ScriptHashHex{}
+	func() {
+		defer account.RLock()()
+		for _, change := range []bool{false, true} {
+			for _, address := range account.addresses(change).Addresses() {
+				result = append(result, &AddressInfo{AccountAddress: address, Change: change})
+				scriptHashHexes = append(scriptHashHexes, address.PubkeyScriptHashHex())
+			}
+		}
+	}()
+	// The summaries are retrieved without holding the account lock, as the transactions wait
+	// until the account is synchronized.
+	summaries, err := account.transactions.AddressSummaries(scriptHashHexes)
+	if err != nil {
+		return nil, err
+	}
+	for index, addressInfo := range result {
+		addressInfo.AddressSummary = summaries[index]
+	}
+	return result, nil
+}
+
+// SetAddressLabel labels the receive or change address with the given ID. An empty label removes
+// the label.
+func (account *Account) SetAddressLabel(addressID string, label string) error {
+	account.synchronizer.WaitSynchronized()
+	defer account.RLock()()
+	scriptHashHex := blockchain.ScriptHashHex(addressID)
+	if account.receiveAddresses.LookupByScriptHashHex(scriptHashHex) == nil &&
+		account.changeAddresses.LookupByScriptHashHex(scriptHashHex) == nil {
+		return errp.New("unknown address not found")
+	}
+	return account.transactions.SetAddressLabel(scriptHashHex, strings.TrimSpace(label))
+}
+
+// SetTxNote implements accounts.Interface.
+func (account *Account) SetTxNote(txID string, note string) error {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return account.transactions.SetTxNote(*txHash, strings.TrimSpace(note))
+}
+
+// addressesByScriptHashHex returns all derived receive and change addresses.
+func (account *Account) addressesByScriptHashHex() map[blockchain.ScriptHashHex]*addresses.AccountAddress {
+	account.synchronizer.WaitSynchronized()
+	defer account.RLock()()
+	result := map[blockchain.ScriptHashHex]*addresses.AccountAddress{}
+	for _, change := range []bool{false, true} {
+		for _, address := range account.addresses(change).Addresses() {
+			result[address.PubkeyScriptHashHex()] = address
+		}
+	}
+	return result
+}
+
+// ExportLabels implements accounts.Interface.
+func (account *Account) ExportLabels() ([]accounts.Label, error) {
+	txNotes, addressLabels, err := account.transactions.Labels()
+	if err != nil {
+		return nil, err
+	}
+	labels := []accounts.Label{}
+	for txHash, note := range txNotes {
+		labels = append(labels, accounts.Label{
+			Type:  accounts.LabelTypeTx,
+			Ref:   txHash.String(),
+			Label: note,
+		})
+	}
+	ourAddresses := account.addressesByScriptHashHex()
+	for scriptHashHex, label := range addressLabels {
+		address, ok := ourAddresses[scriptHashHex]
+		if !ok {
+			continue
+		}
+		labels = append(labels, accounts.Label{
+			Type:  accounts.LabelTypeAddress,
+			Ref:   address.EncodeAddress(),
+			Label: label,
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Type != labels[j].Type {
+			return labels[i].Type > labels[j].Type
+		}
+		return labels[i].Ref < labels[j].Ref
+	})
+	return labels, nil
+}
+
+// ImportLabels implements accounts.Interface.
+func (account *Account) ImportLabels(labels []accounts.Label) (int, error) {
+	scriptHashHexes := map[string]blockchain.ScriptHashHex{}
+	for scriptHashHex, address := range account.addressesByScriptHashHex() {
+		scriptHashHexes[address.EncodeAddress()] = scriptHashHex
+	}
+	txNotes := map[chainhash.Hash]string{}
+	addressLabels := map[blockchain.ScriptHashHex]string{}
+	for _, label := range labels {
+		switch label.Type {
+		case accounts.LabelTypeTx:
+			txHash, err := chainhash.NewHashFromStr(label.Ref)
+			if err != nil {
+				continue
+			}
+			txNotes[*txHash] = strings.TrimSpace(label.Label)
+		case accounts.LabelTypeAddress:
+			scriptHashHex, ok := scriptHashHexes[label.Ref]
+			if !ok {
+				continue
+			}
+			addressLabels[scriptHashHex] = strings.TrimSpace(label.Label)
+		}
+	}
+	if err := account.transactions.PutLabels(txNotes, addressLabels); err != nil {
+		return 0, err
+	}
+	return len(txNotes) + len(addressLabels), nil
+}
+
 // VerifyAddress verifies a receive address on a keystore. Returns false, nil if no secure output
-// exists.
+// exists. Multisig addresses cannot be displayed by any keystore yet, so an error is returned for
+// them instead.
 func (account *Account) VerifyAddress(addressID string) (bool, error) {
 	account.synchronizer.WaitSynchronized()
 	defer account.RLock()()
@@ -602,6 +841,10 @@ func (account *Account) VerifyAddress(addressID string) (bool, error) {
 	if canVerifyAddress {
 		return true, account.Keystores().VerifyAddress(address.Configuration, account.Coin())
 	}
+	if address.Configuration.Multisig() {
+		return false, errp.New("Multisig addresses cannot be verified on the keystores yet. " +
+			"Compare the policy hash with the cosigners instead.")
+	}
 	return false, nil
 }
 
@@ -666,7 +909,20 @@ func (account *Account) CanVerifyExtendedPublicKey() []int {
 
 // VerifyExtendedPublicKey verifies an account's public key. Returns false, nil if no secure output exists.
 // index is the position of an xpub in the []*hdkeychain which corresponds to the particular keystore in []Keystore
+// The keystores are registered in the order of the cosigners, so the index is the cosigner index.
+// Returns keystore.ErrNoKeystore if the keystore of the cosigner is not registered.
 func (account *Account) VerifyExtendedPublicKey(index int) (bool, error) {
+	if index < 0 || index >= account.signingConfiguration.NumberOfSigners() {
+		return false, errp.Newf("There is no cosigner with the index %d.", index)
+	}
+	if index >= account.Keystores().Count() {
+		return false, errp.WithStack(keystore.ErrNoKeystore)
+	}
+	// Make sure that the registered keystores are the cosigners of this account, so that the xpub
+	// shown on the screen is the one of the account.
+	if err := account.checkKeystores(); err != nil {
+		return false, err
+	}
 	keystore := account.Keystores().AccessKeystoreByIndex(index)
 	if keystore.CanVerifyExtendedPublicKey() {
 		return true, keystore.VerifyExtendedPublicKey(account.Coin(), account.signingConfiguration.AbsoluteKeypath(), account.signingConfiguration)
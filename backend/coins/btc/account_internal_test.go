@@ -0,0 +1,55 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/types"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveGapLimits(t *testing.T) {
+	xprv, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/44'/1'/0'")
+	require.NoError(t, err)
+	p2wpkh := signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, xpub)
+	p2pkh := signing.NewSinglesigConfiguration(signing.ScriptTypeP2PKH, keypath, xpub)
+
+	for _, test := range []struct {
+		configuration   *signing.Configuration
+		configured      *types.GapLimits
+		expectedReceive int
+		expectedChange  int
+	}{
+		{p2wpkh, nil, 20, 6},
+		{p2wpkh, &types.GapLimits{Receive: 20, Change: 6}, 20, 6},
+		{p2wpkh, &types.GapLimits{Receive: 100, Change: 30}, 100, 30},
+		// The BWS compatible defaults of legacy accounts are not lowered by the minimum limits.
+		{p2pkh, nil, 60, 20},
+		{p2pkh, &types.GapLimits{Receive: 20, Change: 6}, 60, 20},
+		{p2pkh, &types.GapLimits{Receive: 100, Change: 10}, 100, 20},
+	} {
+		receive, change := effectiveGapLimits(test.configuration, test.configured)
+		require.Equal(t, test.expectedReceive, receive)
+		require.Equal(t, test.expectedChange, change)
+	}
+}
@@ -24,4 +24,5 @@ type AddressChain interface {
 	GetUnused() []*addresses.AccountAddress
 	EnsureAddresses() []*addresses.AccountAddress
 	LookupByScriptHashHex(blockchain.ScriptHashHex) *addresses.AccountAddress
+	Addresses() []*addresses.AccountAddress
 }
@@ -15,6 +15,7 @@
 package addresses
 
 import (
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -24,6 +25,7 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/sirupsen/logrus"
 )
@@ -47,6 +49,8 @@ type AccountAddress struct {
 
 	// redeemScript stores the redeem script of a BIP16 P2SH output or nil if address type is P2PKH.
 	redeemScript []byte
+	// witnessScript stores the multisig script of a P2WSH output, or nil for other address types.
+	witnessScript []byte
 
 	log *logrus.Entry
 }
@@ -60,7 +64,7 @@ func NewAccountAddress(
 ) *AccountAddress {
 
 	var address btcutil.Address
-	var redeemScript []byte
+	var redeemScript, witnessScript []byte
 	configuration, err := accountConfiguration.Derive(keyPath)
 	if err != nil {
 		log.WithError(err).Panic("Failed to derive the configuration.")
@@ -86,13 +90,35 @@ func NewAccountAddress(
 				log.WithError(err).Panic("Failed to get a P2PK address from a public key.")
 			}
 		}
-		redeemScript, err = txscript.MultiSigScript(addresses, configuration.SigningThreshold())
+		multisigScript, err := txscript.MultiSigScript(addresses, configuration.SigningThreshold())
 		if err != nil {
 			log.WithError(err).Panic("Failed to get the redeem script for multisig.")
 		}
-		address, err = btcutil.NewAddressScriptHash(redeemScript, net)
-		if err != nil {
-			log.WithError(err).Panic("Failed to get a P2SH address for multisig.")
+		switch configuration.ScriptType() {
+		case signing.ScriptTypeP2WSH, signing.ScriptTypeP2WSHP2SH:
+			witnessScript = multisigScript
+			witnessScriptHash := sha256.Sum256(witnessScript)
+			segwitAddress, err := btcutil.NewAddressWitnessScriptHash(witnessScriptHash[:], net)
+			if err != nil {
+				log.WithError(err).Panic("Failed to get a P2WSH address for multisig.")
+			}
+			address = segwitAddress
+			if configuration.ScriptType() == signing.ScriptTypeP2WSHP2SH {
+				redeemScript, err = txscript.PayToAddrScript(segwitAddress)
+				if err != nil {
+					log.WithError(err).Panic("Failed to get redeem script for segwit multisig.")
+				}
+				address, err = btcutil.NewAddressScriptHash(redeemScript, net)
+				if err != nil {
+					log.WithError(err).Panic("Failed to get a P2SH address for segwit multisig.")
+				}
+			}
+		default:
+			redeemScript = multisigScript
+			address, err = btcutil.NewAddressScriptHash(redeemScript, net)
+			if err != nil {
+				log.WithError(err).Panic("Failed to get a P2SH address for multisig.")
+			}
 		}
 	default:
 		publicKeyHash := btcutil.Hash160(configuration.PublicKeys()[0].SerializeCompressed())
@@ -121,6 +147,15 @@ func NewAccountAddress(
 			if err != nil {
 				log.WithError(err).Panic("Failed to get p2wpkh addr. from publ. key hash.")
 			}
+		case signing.ScriptTypeP2TR:
+			outputKey, err := taproot.OutputKey(configuration.PublicKeys()[0])
+			if err != nil {
+				log.WithError(err).Panic("Failed to tweak the public key.")
+			}
+			address, err = taproot.NewAddress(taproot.SerializePublicKey(outputKey), net)
+			if err != nil {
+				log.WithError(err).Panic("Failed to get p2tr addr. from output key.")
+			}
 		default:
 			log.Panic(fmt.Sprintf("Unrecognized script type: %s", configuration.ScriptType()))
 		}
@@ -132,10 +167,21 @@ func NewAccountAddress(
 		Configuration:        configuration,
 		HistoryStatus:        "",
 		redeemScript:         redeemScript,
+		witnessScript:        witnessScript,
 		log:                  log,
 	}
 }
 
+// RedeemScript returns the redeem script of a P2SH output, or nil for other address types.
+func (address *AccountAddress) RedeemScript() []byte {
+	return address.redeemScript
+}
+
+// WitnessScript returns the witness script of a P2WSH output, or nil for other address types.
+func (address *AccountAddress) WitnessScript() []byte {
+	return address.witnessScript
+}
+
 // ID implements coin.Address.
 func (address *AccountAddress) ID() string {
 	return string(address.PubkeyScriptHashHex())
@@ -146,12 +192,16 @@ func (address *AccountAddress) EncodeForHumans() string {
 	return address.EncodeAddress()
 }
 
-func (address *AccountAddress) isUsed() bool {
+// IsUsed returns whether the address has a transaction history.
+func (address *AccountAddress) IsUsed() bool {
 	return address.HistoryStatus != ""
 }
 
 // PubkeyScript returns the pubkey script of this address. Use this in a tx output to receive funds.
 func (address *AccountAddress) PubkeyScript() []byte {
+	if taprootAddress, ok := address.Address.(*taproot.Address); ok {
+		return taprootAddress.PkScript()
+	}
 	script, err := txscript.PayToAddrScript(address.Address)
 	if err != nil {
 		address.log.WithError(err).Panic("Failed to get the pubkey script for an address.")
@@ -170,6 +220,9 @@ func (address *AccountAddress) PubkeyScriptHashHex() blockchain.ScriptHashHex {
 // from this address.
 func (address *AccountAddress) ScriptForHashToSign() (bool, []byte) {
 	if address.Configuration.Multisig() {
+		if address.witnessScript != nil {
+			return true, address.witnessScript
+		}
 		return false, address.redeemScript
 	}
 	switch address.Configuration.ScriptType() {
@@ -179,6 +232,10 @@ func (address *AccountAddress) ScriptForHashToSign() (bool, []byte) {
 		return true, address.redeemScript
 	case signing.ScriptTypeP2WPKH:
 		return true, address.PubkeyScript()
+	case signing.ScriptTypeP2TR:
+		// The BIP341 signature hash commits to the pubkey scripts of all spent outputs, see
+		// taproot.SigHash().
+		return true, address.PubkeyScript()
 	default:
 		address.log.Panic("Unrecognized address type.")
 	}
@@ -210,6 +267,27 @@ func (address *AccountAddress) SignatureScript(
 		for i := 0; i < length; i++ {
 			sortedSignatures[index(publicKeys[i], sortedPublicKeys)] = signatures[i]
 		}
+		if address.witnessScript != nil {
+			// The empty item is consumed by the off-by-one bug of OP_CHECKMULTISIG.
+			txWitness := wire.TxWitness{[]byte{}}
+			for _, signature := range sortedSignatures {
+				if signature != nil {
+					txWitness = append(txWitness,
+						append(signature.Serialize(), byte(txscript.SigHashAll)))
+				}
+			}
+			txWitness = append(txWitness, address.witnessScript)
+			if address.redeemScript == nil {
+				return []byte{}, txWitness
+			}
+			signatureScript, err := txscript.NewScriptBuilder().
+				AddData(address.redeemScript).
+				Script()
+			if err != nil {
+				address.log.WithError(err).Panic("Failed to build segwit multisig signature script.")
+			}
+			return signatureScript, txWitness
+		}
 		scriptBuilder := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
 		for _, signature := range sortedSignatures {
 			if signature != nil {
@@ -255,6 +333,9 @@ func (address *AccountAddress) SignatureScript(
 			publicKey.SerializeCompressed(),
 		}
 		return []byte{}, txWitness
+	case signing.ScriptTypeP2TR:
+		// A Schnorr signature with SIGHASH_DEFAULT, which is not appended.
+		return []byte{}, wire.TxWitness{taproot.SerializeSignature(signature)}
 	default:
 		address.log.Panic("Unrecognized address type.")
 	}
@@ -15,12 +15,15 @@
 package addresses_test
 
 import (
+	"crypto/sha256"
 	"testing"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses/test"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -61,3 +64,50 @@ func (s *addressTestSuite) TestScriptHashHex() {
 		blockchain.ScriptHashHex("0466d0029406f583feadaccb91c7b5b855eb5d6782316cafa4f390b7c784436b"),
 		s.address.PubkeyScriptHashHex())
 }
+
+func TestTaprootAddress(t *testing.T) {
+	address := test.GetAddress(signing.ScriptTypeP2TR)
+	outputKey, err := taproot.OutputKey(address.Configuration.PublicKeys()[0])
+	require.NoError(t, err)
+	require.Equal(t,
+		append([]byte{txscript.OP_1, txscript.OP_DATA_32}, taproot.SerializePublicKey(outputKey)...),
+		address.PubkeyScript())
+	require.True(t, address.IsForNet(net))
+
+	decoded, err := taproot.DecodeAddress(address.EncodeAddress(), net)
+	require.NoError(t, err)
+	require.Equal(t, address.PubkeyScript(), decoded.PkScript())
+
+	isSegwit, script := address.ScriptForHashToSign()
+	require.True(t, isSegwit)
+	require.Equal(t, address.PubkeyScript(), script)
+}
+
+func TestMultisigAddress(t *testing.T) {
+	for _, scriptType := range []signing.ScriptType{
+		signing.ScriptTypeP2WSH, signing.ScriptTypeP2WSHP2SH,
+	} {
+		address := test.GetMultisigAddress(scriptType, 2, 3)
+		witnessScript := address.WitnessScript()
+		class, pubKeys, signingThreshold, err := txscript.ExtractPkScriptAddrs(witnessScript, net)
+		require.NoError(t, err)
+		require.Equal(t, txscript.MultiSigTy, class)
+		require.Len(t, pubKeys, 3)
+		require.Equal(t, 2, signingThreshold)
+
+		isSegwit, script := address.ScriptForHashToSign()
+		require.True(t, isSegwit)
+		require.Equal(t, witnessScript, script)
+
+		witnessProgram := sha256.Sum256(witnessScript)
+		switch scriptType {
+		case signing.ScriptTypeP2WSH:
+			require.Nil(t, address.RedeemScript())
+			require.Equal(t, txscript.WitnessV0ScriptHashTy, txscript.GetScriptClass(address.PubkeyScript()))
+			require.Equal(t, witnessProgram[:], address.PubkeyScript()[2:])
+		case signing.ScriptTypeP2WSHP2SH:
+			require.Equal(t, append([]byte{txscript.OP_0, 0x20}, witnessProgram[:]...), address.RedeemScript())
+			require.Equal(t, txscript.ScriptHashTy, txscript.GetScriptClass(address.PubkeyScript()))
+		}
+	}
+}
@@ -78,7 +78,7 @@ func (addresses *AddressChain) addAddress() *AccountAddress {
 func (addresses *AddressChain) unusedTailCount() int {
 	count := 0
 	for i := len(addresses.addresses) - 1; i >= 0; i-- {
-		if addresses.addresses[i].isUsed() {
+		if addresses.addresses[i].IsUsed() {
 			break
 		}
 		count++
@@ -99,6 +99,25 @@ func (addresses *AddressChain) LookupByScriptHashHex(hashHex blockchain.ScriptHa
 	return nil
 }
 
+// GapLimit returns the number of unused addresses kept at the end of the chain.
+func (addresses *AddressChain) GapLimit() int {
+	return addresses.gapLimit
+}
+
+// SetGapLimit changes the gap limit. EnsureAddresses() must be called afterwards to derive the
+// addresses needed to satisfy the new limit.
+func (addresses *AddressChain) SetGapLimit(gapLimit int) {
+	addresses.log.WithField("new-gap-limit", gapLimit).Info("Changing the gap limit")
+	addresses.gapLimit = gapLimit
+}
+
+// Addresses returns all addresses derived so far, in the order of derivation.
+func (addresses *AddressChain) Addresses() []*AccountAddress {
+	result := make([]*AccountAddress, len(addresses.addresses))
+	copy(result, addresses.addresses)
+	return result
+}
+
 // EnsureAddresses appends addresses to the address chain until there are `gapLimit` unused unused
 // ones, and returns the new addresses.
 func (addresses *AddressChain) EnsureAddresses() []*AccountAddress {
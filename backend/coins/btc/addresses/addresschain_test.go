@@ -127,3 +127,22 @@ func (s *addressChainTestSuite) TestEnsureAddresses() {
 	newAddresses[s.gapLimit-1].HistoryStatus = "used"
 	require.Len(s.T(), s.addresses.EnsureAddresses(), s.gapLimit)
 }
+
+func (s *addressChainTestSuite) TestSetGapLimit() {
+	newAddresses := s.addresses.EnsureAddresses()
+	require.Len(s.T(), newAddresses, s.gapLimit)
+	require.Equal(s.T(), s.gapLimit, s.addresses.GapLimit())
+
+	s.addresses.SetGapLimit(s.gapLimit + 4)
+	require.Equal(s.T(), s.gapLimit+4, s.addresses.GapLimit())
+	// Raising the gap limit derives the missing addresses at the end of the chain.
+	require.Len(s.T(), s.addresses.EnsureAddresses(), 4)
+	require.Len(s.T(), s.addresses.Addresses(), s.gapLimit+4)
+	require.Equal(s.T(), newAddresses, s.addresses.Addresses()[:s.gapLimit])
+	require.Len(s.T(), s.addresses.GetUnused(), s.gapLimit+4)
+
+	// Lowering the gap limit keeps the addresses derived so far.
+	s.addresses.SetGapLimit(s.gapLimit)
+	require.Empty(s.T(), s.addresses.EnsureAddresses())
+	require.Len(s.T(), s.addresses.Addresses(), s.gapLimit+4)
+}
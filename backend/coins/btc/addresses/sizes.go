@@ -14,18 +14,38 @@
 
 package addresses
 
-import "github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+)
 
-// SigScriptWitnessSize returns the maximum possible sigscript size for a given address type.
+// multisigScriptSize returns the size of the multisig script, which is the redeem script of P2SH
+// multisig outputs and the witness script of P2WSH multisig outputs.
+func multisigScriptSize(configuration *signing.Configuration) int {
+	// OP_N (1 byte, signingThreshold)
+	// numberOfSigners*(
+	// OP_DATA_33
+	// 33 bytes of compressed pubkey
+	// )
+	// OP_N (1 byte, numberOfSigners) OP_CHECKMULTISIG (1 byte)
+	return 1 + configuration.NumberOfSigners()*(1+33) + 1 + 1
+}
+
+// SigScriptWitnessSize returns the maximum possible sigscript size for a given address type, and
+// whether spending from the address type needs a witness.
 func SigScriptWitnessSize(configuration *signing.Configuration) (int, bool) {
 	if configuration.Multisig() {
-		// OP_N (1 byte, signingThreshold)
-		// numberOfSigners*(
-		// OP_DATA_33
-		// 33 bytes of compressed pubkey
-		// )
-		// OP_N (1 byte, numberOfSigners) OP_CHECKMULTISIG (1 byte)
-		redeemScriptSize := 1 + configuration.NumberOfSigners()*(1+33) + 1 + 1
+		switch configuration.ScriptType() {
+		case signing.ScriptTypeP2WSH:
+			return 0, true
+		case signing.ScriptTypeP2WSHP2SH:
+			// OP_0 (1 byte) OP_32 (1 byte) witnessScriptHash (32 bytes)
+			const redeemScriptSize = 1 + 1 + 32
+			// OP_DATA_34 (1 Byte) redeemScript (34 bytes)
+			return 1 + redeemScriptSize, true
+		}
+		redeemScriptSize := multisigScriptSize(configuration)
 		// OP_0 (1 byte)
 		// numSigs*(
 		// OP_DATA_72
@@ -59,7 +79,42 @@ func SigScriptWitnessSize(configuration *signing.Configuration) (int, bool) {
 		return 1 + redeemScriptSize, true
 	case signing.ScriptTypeP2WPKH:
 		return 0, true // hooray
+	case signing.ScriptTypeP2TR:
+		return 0, true
 	default:
 		panic("unknown address type")
 	}
 }
+
+// WitnessSize returns the maximum possible size of the witness serialization for a given address
+// type, or 0 if the address type does not need a witness.
+func WitnessSize(configuration *signing.Configuration) int {
+	if _, hasWitness := SigScriptWitnessSize(configuration); !hasWitness {
+		return 0
+	}
+	if configuration.Multisig() {
+		// The witness is:
+		// <empty item> signingThreshold*<serialized sig> <witness script>
+		// A signature is at most 72 bytes (including SIGHASH op), as high S values are not
+		// standard.
+		const signatureSize = 72
+		witnessScriptSize := multisigScriptSize(configuration)
+		return wire.VarIntSerializeSize(uint64(configuration.SigningThreshold()+2)) +
+			wire.VarIntSerializeSize(0) +
+			configuration.SigningThreshold()*(wire.VarIntSerializeSize(signatureSize)+signatureSize) +
+			wire.VarIntSerializeSize(uint64(witnessScriptSize)) + witnessScriptSize
+	}
+	if configuration.ScriptType() == signing.ScriptTypeP2TR {
+		// <64 byte Schnorr signature>, the SIGHASH op is omitted for SIGHASH_DEFAULT.
+		return wire.VarIntSerializeSize(1) +
+			wire.VarIntSerializeSize(taproot.SignatureSize) + taproot.SignatureSize
+	}
+	// <serialized sig> <serialized compressed pubkey>
+	const (
+		signatureSize = 73 // including SIGHASH op
+		pubkeySize    = 33
+	)
+	return wire.VarIntSerializeSize(2) +
+		wire.VarIntSerializeSize(signatureSize) + signatureSize +
+		wire.VarIntSerializeSize(pubkeySize) + pubkeySize
+}
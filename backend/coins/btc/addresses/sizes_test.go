@@ -29,6 +29,7 @@ var scriptTypes = []signing.ScriptType{
 	signing.ScriptTypeP2PKH,
 	signing.ScriptTypeP2WPKHP2SH,
 	signing.ScriptTypeP2WPKH,
+	signing.ScriptTypeP2TR,
 }
 
 func TestSigScriptWitnessSize(t *testing.T) {
@@ -48,27 +49,39 @@ func TestSigScriptWitnessSize(t *testing.T) {
 			sigScript, witness := address.SignatureScript([]*btcec.Signature{sig})
 			require.Equal(t, len(sigScript), sigScriptSize)
 			require.Equal(t, witness != nil, hasWitness)
+			if scriptType == signing.ScriptTypeP2TR {
+				require.Equal(t, witness.SerializeSize(), addresses.WitnessSize(address.Configuration))
+			}
 		})
 	}
 
 	// Test all multisig configurations.
-	for numberOfSigners := 2; numberOfSigners <= 15; numberOfSigners++ {
-		numberOfSigners := numberOfSigners // avoids referencing the same variable across loop iterations
-		for signingThreshold := 1; signingThreshold <= numberOfSigners; signingThreshold++ {
-			signingThreshold := signingThreshold // avoids referencing the same variable across loop iterations
-			address := test.GetMultisigAddress(signingThreshold, numberOfSigners)
-			t.Run(address.Configuration.String(), func(t *testing.T) {
-				// create a slice of `n` sigs, `m` of which contain a signature, the rest being
-				// nil. This is how SignatureScript() expects it.
-				sigs := make([]*btcec.Signature, numberOfSigners)
-				for numSigs := 0; numSigs < signingThreshold; numSigs++ {
-					sigs[numSigs] = sig
-				}
-				sigScriptSize, hasWitness := addresses.SigScriptWitnessSize(address.Configuration)
-				sigScript, _ := address.SignatureScript(sigs)
-				require.Equal(t, len(sigScript), sigScriptSize)
-				require.False(t, hasWitness)
-			})
+	for _, scriptType := range []signing.ScriptType{
+		signing.ScriptTypeP2PKH, signing.ScriptTypeP2WSHP2SH, signing.ScriptTypeP2WSH,
+	} {
+		for numberOfSigners := 2; numberOfSigners <= 15; numberOfSigners++ {
+			numberOfSigners := numberOfSigners // avoids referencing the same variable across loop iterations
+			for signingThreshold := 1; signingThreshold <= numberOfSigners; signingThreshold++ {
+				signingThreshold := signingThreshold // avoids referencing the same variable across loop iterations
+				address := test.GetMultisigAddress(scriptType, signingThreshold, numberOfSigners)
+				t.Run(address.Configuration.String(), func(t *testing.T) {
+					// create a slice of `n` sigs, `m` of which contain a signature, the rest being
+					// nil. This is how SignatureScript() expects it.
+					sigs := make([]*btcec.Signature, numberOfSigners)
+					for numSigs := 0; numSigs < signingThreshold; numSigs++ {
+						sigs[numSigs] = sig
+					}
+					sigScriptSize, hasWitness := addresses.SigScriptWitnessSize(address.Configuration)
+					sigScript, witness := address.SignatureScript(sigs)
+					require.Equal(t, len(sigScript), sigScriptSize)
+					require.Equal(t, witness != nil, hasWitness)
+					if hasWitness {
+						require.Equal(t, witness.SerializeSize(), addresses.WitnessSize(address.Configuration))
+					} else {
+						require.Equal(t, 0, addresses.WitnessSize(address.Configuration))
+					}
+				})
+			}
 		}
 	}
 }
@@ -65,8 +65,9 @@ func GetAddress(scriptType signing.ScriptType) *addresses.AccountAddress {
 	)
 }
 
-// GetMultisigAddress returns a dummy multisig address.
-func GetMultisigAddress(signingThreshold, numberOfSigners int) *addresses.AccountAddress {
+// GetMultisigAddress returns a dummy multisig address for a given script type.
+func GetMultisigAddress(
+	scriptType signing.ScriptType, signingThreshold, numberOfSigners int) *addresses.AccountAddress {
 	xpubs := make([]*hdkeychain.ExtendedKey, numberOfSigners)
 	for i := range xpubs {
 		seed, err := hdkeychain.GenerateSeed(32)
@@ -83,7 +84,7 @@ func GetMultisigAddress(signingThreshold, numberOfSigners int) *addresses.Accoun
 		}
 		xpubs[i] = xpub
 	}
-	configuration := signing.NewConfiguration(signing.ScriptTypeP2PKH, absoluteKeypath, xpubs, "", signingThreshold)
+	configuration := signing.NewConfiguration(scriptType, absoluteKeypath, xpubs, "", signingThreshold)
 	return addresses.NewAccountAddress(
 		configuration,
 		signing.NewEmptyRelativeKeypath(),
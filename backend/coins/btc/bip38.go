@@ -0,0 +1,152 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	bip38FlagCompressed    = 0x20
+	bip38FlagLotSequence   = 0x04
+	bip38FlagNonECMultiply = 0xc0
+)
+
+// bip38AddressHash returns the first four bytes of the double sha256 of the P2PKH address of the
+// public key, which is used as a salt and checksum in BIP38.
+func bip38AddressHash(privateKey *btcec.PrivateKey, compressed bool, net *chaincfg.Params) ([]byte, error) {
+	publicKey := privateKey.PubKey().SerializeUncompressed()
+	if compressed {
+		publicKey = privateKey.PubKey().SerializeCompressed()
+	}
+	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(publicKey), net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return chainhash.DoubleHashB([]byte(address.EncodeAddress()))[:4], nil
+}
+
+// bip38DecryptBlocks decrypts the 16 byte blocks with AES-256 in ECB mode using derivedHalf2 as
+// the key, and xors the result with derivedHalf1.
+func bip38DecryptBlocks(encrypted []byte, derivedHalf1, derivedHalf2 []byte) ([]byte, error) {
+	block, err := aes.NewCipher(derivedHalf2)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	decrypted := make([]byte, len(encrypted))
+	for offset := 0; offset < len(encrypted); offset += aes.BlockSize {
+		block.Decrypt(decrypted[offset:offset+aes.BlockSize], encrypted[offset:offset+aes.BlockSize])
+	}
+	for i := range decrypted {
+		decrypted[i] ^= derivedHalf1[i]
+	}
+	return decrypted, nil
+}
+
+// DecryptBIP38 decrypts a BIP38 encrypted private key, as found on paper wallets, with the given
+// passphrase. Both the non-EC-multiply and the EC-multiply modes are supported.
+func DecryptBIP38(encrypted string, passphrase string, net *chaincfg.Params) (*btcutil.WIF, error) {
+	decoded, version, err := base58.CheckDecode(encrypted)
+	if err != nil || len(decoded) != 38 {
+		return nil, errp.New("Invalid BIP38 encrypted key.")
+	}
+	// CheckDecode splits off the first byte as the version.
+	decoded = append([]byte{version}, decoded...)
+	flag := decoded[2]
+	compressed := flag&bip38FlagCompressed != 0
+	addressHash := decoded[3:7]
+	passphraseBytes := norm.NFC.Bytes([]byte(passphrase))
+
+	var privateKeyBytes []byte
+	switch {
+	case decoded[0] == 0x01 && decoded[1] == 0x42:
+		if flag&bip38FlagNonECMultiply != bip38FlagNonECMultiply {
+			return nil, errp.New("Invalid BIP38 encrypted key.")
+		}
+		derived, err := scrypt.Key(passphraseBytes, addressHash, 16384, 8, 8, 64)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		privateKeyBytes, err = bip38DecryptBlocks(decoded[7:39], derived[:32], derived[32:])
+		if err != nil {
+			return nil, err
+		}
+	case decoded[0] == 0x01 && decoded[1] == 0x43:
+		ownerEntropy := decoded[7:15]
+		ownerSalt := ownerEntropy
+		if flag&bip38FlagLotSequence != 0 {
+			ownerSalt = ownerEntropy[:4]
+		}
+		passFactor, err := scrypt.Key(passphraseBytes, ownerSalt, 16384, 8, 8, 32)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if flag&bip38FlagLotSequence != 0 {
+			passFactor = chainhash.DoubleHashB(append(passFactor, ownerEntropy...))
+		}
+		_, passPoint := btcec.PrivKeyFromBytes(btcec.S256(), passFactor)
+		derived, err := scrypt.Key(passPoint.SerializeCompressed(),
+			append(append([]byte{}, addressHash...), ownerEntropy...), 1024, 1, 1, 64)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		encryptedPart1 := decoded[15:23]
+		// The second half of the first encrypted part is recovered from the second encrypted part.
+		decryptedPart2, err := bip38DecryptBlocks(decoded[23:39], derived[16:32], derived[32:])
+		if err != nil {
+			return nil, err
+		}
+		decryptedPart1, err := bip38DecryptBlocks(
+			append(append([]byte{}, encryptedPart1...), decryptedPart2[:8]...),
+			derived[:16], derived[32:])
+		if err != nil {
+			return nil, err
+		}
+		seedB := append(decryptedPart1, decryptedPart2[8:]...)
+		factorB := new(big.Int).SetBytes(chainhash.DoubleHashB(seedB))
+		privateKey := new(big.Int).Mul(new(big.Int).SetBytes(passFactor), factorB)
+		privateKey.Mod(privateKey, btcec.S256().N)
+		privateKeyBytes = make([]byte, 32)
+		privateKeyBigEndian := privateKey.Bytes()
+		copy(privateKeyBytes[32-len(privateKeyBigEndian):], privateKeyBigEndian)
+	default:
+		return nil, errp.New("Invalid BIP38 encrypted key.")
+	}
+
+	privateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privateKeyBytes)
+	expectedAddressHash, err := bip38AddressHash(privateKey, compressed, net)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(addressHash, expectedAddressHash) {
+		return nil, errp.New("The passphrase is wrong.")
+	}
+	wif, err := btcutil.NewWIF(privateKey, net, compressed)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return wif, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecryptBIP38(t *testing.T) {
+	// Test vectors of BIP38.
+	for encrypted, expected := range map[string]string{
+		// No EC multiply, no compression.
+		"6PRVWUbkzzsbcVac2qwfssoUJAN1Xhrg6bNk8J7Nzm5H7kxEbn2Nh2ZoGg": "5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+		// No EC multiply, compression.
+		"6PYNKZ1EAgYgmQfmNVamxyXVWHzK5s6DGhwP4J5o44cvXdoY7sRzhtpUeo": "L44B5gGEpqEDRS9vVPz7QT35jcBG2r3CZwSwQ4fCewXAhAhqGVpP",
+		// EC multiply, no compression, no lot/sequence numbers.
+		"6PfQu77ygVyJLZjfvMLyhLMQbYnu5uguoJJ4kMCLqWwPEdfpwANVS76gTX": "5K4caxezwjGCGfnoPTZ8tMcJBLB7Jvyjv4xxeacadhq8nLisLR2",
+	} {
+		wif, err := btc.DecryptBIP38(encrypted, "TestingOneTwoThree", &chaincfg.MainNetParams)
+		require.NoError(t, err, encrypted)
+		require.Equal(t, expected, wif.String())
+
+		_, err = btc.DecryptBIP38(encrypted, "wrong passphrase", &chaincfg.MainNetParams)
+		require.Error(t, err)
+	}
+	_, err := btc.DecryptBIP38("5KN7MzqK5wt2TP1fQCYyHBtDrXdJuXbUzm4A9rKAteGu3Qi5CVR",
+		"TestingOneTwoThree", &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
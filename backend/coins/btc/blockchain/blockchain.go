@@ -77,6 +77,14 @@ func (history TxHistory) Status() string {
 	return hex.EncodeToString(chainhash.HashB(status.Bytes()))
 }
 
+// UTXO is an unspent output returned by ScriptHashListUnspent.
+type UTXO struct {
+	TXPos  int    `json:"tx_pos"`
+	Value  int64  `json:"value"`
+	TXHash TXHash `json:"tx_hash"`
+	Height int    `json:"height"`
+}
+
 // ScriptHashHex is the hash of a pkScript in reverse hex format.
 type ScriptHashHex string
 
@@ -103,6 +111,7 @@ type Interface interface {
 	TransactionGet(chainhash.Hash, func(*wire.MsgTx) error, func(error))
 	ScriptHashSubscribe(func() func(error), ScriptHashHex, func(string) error)
 	HeadersSubscribe(func() func(error), func(*Header) error)
+	ScriptHashListUnspent(ScriptHashHex) ([]*UTXO, error)
 	TransactionBroadcast(*wire.MsgTx) error
 	RelayFee(func(btcutil.Amount) error, func(error))
 	EstimateFee(int, func(*btcutil.Amount) error, func(error))
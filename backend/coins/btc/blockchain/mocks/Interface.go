@@ -67,6 +67,29 @@ func (_m *Interface) ScriptHashGetHistory(_a0 blockchain.ScriptHashHex, _a1 func
 	_m.Called(_a0, _a1, _a2)
 }
 
+// ScriptHashListUnspent provides a mock function with given fields: _a0
+func (_m *Interface) ScriptHashListUnspent(_a0 blockchain.ScriptHashHex) ([]*blockchain.UTXO, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*blockchain.UTXO
+	if rf, ok := ret.Get(0).(func(blockchain.ScriptHashHex) []*blockchain.UTXO); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*blockchain.UTXO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(blockchain.ScriptHashHex) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ScriptHashSubscribe provides a mock function with given fields: _a0, _a1, _a2
 func (_m *Interface) ScriptHashSubscribe(_a0 func() func(error), _a1 blockchain.ScriptHashHex, _a2 func(string) error) {
 	_m.Called(_a0, _a1, _a2)
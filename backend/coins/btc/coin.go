@@ -28,6 +28,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/db/headersdb"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/electrum"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/headers"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
@@ -173,7 +174,12 @@ func (coin *Coin) SmallestUnit() string {
 func (coin *Coin) DecodeAddress(address string) (btcutil.Address, error) {
 	btcAddress, err := btcutil.DecodeAddress(address, coin.Net())
 	if err != nil {
-		return nil, errp.WithStack(errors.ErrInvalidAddress)
+		// btcutil does not know about bech32m encoded taproot addresses.
+		taprootAddress, taprootErr := taproot.DecodeAddress(address, coin.Net())
+		if taprootErr != nil {
+			return nil, errp.WithStack(errors.ErrInvalidAddress)
+		}
+		return taprootAddress, nil
 	}
 	if !btcAddress.IsForNet(coin.Net()) {
 		return nil, errp.WithStack(errors.ErrInvalidAddress)
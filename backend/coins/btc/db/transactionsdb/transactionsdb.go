@@ -33,6 +33,8 @@ const (
 	bucketInputs                 = "inputs"
 	bucketOutputs                = "outputs"
 	bucketAddressHistories       = "addressHistories"
+	bucketAddressLabels          = "addressLabels"
+	bucketTxNotes                = "txNotes"
 )
 
 // DB is a bbolt key/value database.
@@ -75,6 +77,14 @@ func (db *DB) Begin() (transactions.DBTxInterface, error) {
 	if err != nil {
 		return nil, err
 	}
+	bucketAddressLabels, err := tx.CreateBucketIfNotExists([]byte(bucketAddressLabels))
+	if err != nil {
+		return nil, err
+	}
+	bucketTxNotes, err := tx.CreateBucketIfNotExists([]byte(bucketTxNotes))
+	if err != nil {
+		return nil, err
+	}
 	return &Tx{
 		tx:                           tx,
 		bucketTransactions:           bucketTransactions,
@@ -82,6 +92,8 @@ func (db *DB) Begin() (transactions.DBTxInterface, error) {
 		bucketInputs:                 bucketInputs,
 		bucketOutputs:                bucketOutputs,
 		bucketAddressHistories:       bucketAddressHistories,
+		bucketAddressLabels:          bucketAddressLabels,
+		bucketTxNotes:                bucketTxNotes,
 	}, nil
 }
 
@@ -99,6 +111,8 @@ type Tx struct {
 	bucketInputs                 *bbolt.Bucket
 	bucketOutputs                *bbolt.Bucket
 	bucketAddressHistories       *bbolt.Bucket
+	bucketAddressLabels          *bbolt.Bucket
+	bucketTxNotes                *bbolt.Bucket
 }
 
 // Rollback implements transactions.DBTxInterface.
@@ -318,3 +332,43 @@ func (tx *Tx) AddressHistory(scriptHashHex blockchain.ScriptHashHex) (blockchain
 	_, err := readJSON(tx.bucketAddressHistories, []byte(string(scriptHashHex)), &history)
 	return history, err
 }
+
+// PutAddressLabel implements transactions.DBTxInterface.
+func (tx *Tx) PutAddressLabel(scriptHashHex blockchain.ScriptHashHex, label string) error {
+	if label == "" {
+		return tx.bucketAddressLabels.Delete([]byte(string(scriptHashHex)))
+	}
+	return tx.bucketAddressLabels.Put([]byte(string(scriptHashHex)), []byte(label))
+}
+
+// AddressLabels implements transactions.DBTxInterface.
+func (tx *Tx) AddressLabels() (map[blockchain.ScriptHashHex]string, error) {
+	labels := map[blockchain.ScriptHashHex]string{}
+	cursor := tx.bucketAddressLabels.Cursor()
+	for scriptHashHex, label := cursor.First(); scriptHashHex != nil; scriptHashHex, label = cursor.Next() {
+		labels[blockchain.ScriptHashHex(scriptHashHex)] = string(label)
+	}
+	return labels, nil
+}
+
+// PutTxNote implements transactions.DBTxInterface.
+func (tx *Tx) PutTxNote(txHash chainhash.Hash, note string) error {
+	if note == "" {
+		return tx.bucketTxNotes.Delete(txHash[:])
+	}
+	return tx.bucketTxNotes.Put(txHash[:], []byte(note))
+}
+
+// TxNotes implements transactions.DBTxInterface.
+func (tx *Tx) TxNotes() (map[chainhash.Hash]string, error) {
+	notes := map[chainhash.Hash]string{}
+	cursor := tx.bucketTxNotes.Cursor()
+	for txHashBytes, note := cursor.First(); txHashBytes != nil; txHashBytes, note = cursor.Next() {
+		var txHash chainhash.Hash
+		if err := txHash.SetBytes(txHashBytes); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		notes[txHash] = string(note)
+	}
+	return notes, nil
+}
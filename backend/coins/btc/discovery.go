@@ -0,0 +1,76 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/sirupsen/logrus"
+)
+
+// HasHistory returns whether any of the first receive or change addresses of the account with the
+// given signing configuration has a transaction history. The first `gapLimit` receive and
+// `changeGapLimit` change addresses are scanned, as in BIP44 account discovery.
+func HasHistory(
+	theBlockchain blockchain.Interface,
+	net *chaincfg.Params,
+	configuration *signing.Configuration,
+	log *logrus.Entry,
+) (bool, error) {
+	log = log.WithField("keypath", configuration.AbsoluteKeypath().Encode())
+	log.Debug("Scanning account for history")
+	scanAddresses := append(
+		addresses.NewAddressChain(configuration, net, gapLimit, 0, log).EnsureAddresses(),
+		addresses.NewAddressChain(configuration, net, changeGapLimit, 1, log).EnsureAddresses()...)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	used := false
+	var firstErr error
+	wg.Add(len(scanAddresses))
+	for _, address := range scanAddresses {
+		theBlockchain.ScriptHashGetHistory(
+			address.PubkeyScriptHashHex(),
+			func(history blockchain.TxHistory) error {
+				if len(history) > 0 {
+					lock.Lock()
+					used = true
+					lock.Unlock()
+				}
+				return nil
+			},
+			func(err error) {
+				defer wg.Done()
+				if err != nil {
+					lock.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					lock.Unlock()
+				}
+			},
+		)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return false, firstErr
+	}
+	log.WithField("used", used).Debug("Scanned account for history")
+	return used, nil
+}
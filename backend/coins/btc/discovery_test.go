@@ -0,0 +1,109 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain/mocks"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var net = &chaincfg.TestNet3Params
+
+func testConfiguration(t *testing.T) *signing.Configuration {
+	xprv, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), net)
+	require.NoError(t, err)
+	xpub, err := xprv.Neuter()
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'")
+	require.NoError(t, err)
+	return signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, xpub)
+}
+
+// mockHistory makes the blockchain return a non-empty history for the given script hashes, and an
+// empty history for all other script hashes.
+func mockHistory(usedScriptHashes map[blockchain.ScriptHashHex]struct{}, err error) *mocks.Interface {
+	theBlockchain := &mocks.Interface{}
+	theBlockchain.On("ScriptHashGetHistory", mock.Anything, mock.Anything, mock.Anything).Run(
+		func(args mock.Arguments) {
+			scriptHashHex := args.Get(0).(blockchain.ScriptHashHex)
+			success := args.Get(1).(func(blockchain.TxHistory) error)
+			cleanup := args.Get(2).(func(error))
+			if err != nil {
+				cleanup(err)
+				return
+			}
+			history := blockchain.TxHistory{}
+			if _, ok := usedScriptHashes[scriptHashHex]; ok {
+				history = append(history, &blockchain.TxInfo{
+					Height: 10,
+					TXHash: blockchain.TXHash(chainhash.HashH([]byte("tx"))),
+				})
+			}
+			cleanup(success(history))
+		})
+	return theBlockchain
+}
+
+func TestHasHistory(t *testing.T) {
+	log := logging.Get().WithGroup("btc_test")
+	configuration := testConfiguration(t)
+
+	theBlockchain := mockHistory(map[blockchain.ScriptHashHex]struct{}{}, nil)
+	used, err := btc.HasHistory(theBlockchain, net, configuration, log)
+	require.NoError(t, err)
+	require.False(t, used)
+	// 20 receive and 6 change addresses are scanned.
+	theBlockchain.AssertNumberOfCalls(t, "ScriptHashGetHistory", 26)
+
+	changeKeypath, err := signing.NewRelativeKeypath("1/5")
+	require.NoError(t, err)
+	changeAddress := addresses.NewAccountAddress(configuration, changeKeypath, net, log)
+	used, err = btc.HasHistory(
+		mockHistory(map[blockchain.ScriptHashHex]struct{}{
+			changeAddress.PubkeyScriptHashHex(): {},
+		}, nil),
+		net, configuration, log)
+	require.NoError(t, err)
+	require.True(t, used)
+
+	// Receive addresses beyond the gap limit are not scanned.
+	receiveKeypath, err := signing.NewRelativeKeypath("0/20")
+	require.NoError(t, err)
+	receiveAddress := addresses.NewAccountAddress(configuration, receiveKeypath, net, log)
+	used, err = btc.HasHistory(
+		mockHistory(map[blockchain.ScriptHashHex]struct{}{
+			receiveAddress.PubkeyScriptHashHex(): {},
+		}, nil),
+		net, configuration, log)
+	require.NoError(t, err)
+	require.False(t, used)
+
+	_, err = btc.HasHistory(
+		mockHistory(map[blockchain.ScriptHashHex]struct{}{}, errp.New("server error")),
+		net, configuration, log)
+	require.Error(t, err)
+}
@@ -353,19 +353,13 @@ func (txHash *TXHash) UnmarshalJSON(jsonBytes []byte) error {
 	return nil
 }
 
-// UTXO is the data returned by the listunspent RPC call.
-type UTXO struct {
-	TXPos  int    `json:"tx_pos"`
-	Value  int64  `json:"value"`
-	TXHash string `json:"tx_hash"`
-	Height int    `json:"height"`
-}
-
-// ScriptHashListUnspent does the blockchain.address.listunspent() RPC call.
+// ScriptHashListUnspent does the blockchain.scripthash.listunspent() RPC call.
 // https://github.com/kyuupichan/electrumx/blob/159db3f8e70b2b2cbb8e8cd01d1e9df3fe83828f/docs/PROTOCOL.rst#blockchainscripthashlistunspent
-func (client *ElectrumClient) ScriptHashListUnspent(scriptHashHex string) ([]*UTXO, error) {
-	response := []*UTXO{}
-	if err := client.rpc.MethodSync(&response, "blockchain.scripthash.listunspent", scriptHashHex); err != nil {
+func (client *ElectrumClient) ScriptHashListUnspent(
+	scriptHashHex blockchain.ScriptHashHex) ([]*blockchain.UTXO, error) {
+	response := []*blockchain.UTXO{}
+	if err := client.rpc.MethodSync(
+		&response, "blockchain.scripthash.listunspent", string(scriptHashHex)); err != nil {
 		return nil, errp.WithStack(err)
 	}
 	return response, nil
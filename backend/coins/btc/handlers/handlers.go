@@ -39,6 +39,8 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/config"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
@@ -58,13 +60,28 @@ func NewHandlers(
 	handleFunc("/status", handlers.getAccountStatus).Methods("GET")
 	handleFunc("/transactions", handlers.ensureAccountInitialized(handlers.getAccountTransactions)).Methods("GET")
 	handleFunc("/export", handlers.ensureAccountInitialized(handlers.postExportTransactions)).Methods("POST")
+	handleFunc("/tx-note", handlers.ensureAccountInitialized(handlers.postTxNote)).Methods("POST")
+	handleFunc("/export-labels", handlers.ensureAccountInitialized(handlers.postExportLabels)).Methods("POST")
+	handleFunc("/import-labels", handlers.ensureAccountInitialized(handlers.postImportLabels)).Methods("POST")
 	handleFunc("/info", handlers.ensureAccountInitialized(handlers.getAccountInfo)).Methods("GET")
 	handleFunc("/utxos", handlers.ensureAccountInitialized(handlers.getUTXOs)).Methods("GET")
 	handleFunc("/balance", handlers.ensureAccountInitialized(handlers.getAccountBalance)).Methods("GET")
 	handleFunc("/sendtx", handlers.ensureAccountInitialized(handlers.postAccountSendTx)).Methods("POST")
+	handleFunc("/psbt-create", handlers.ensureAccountInitialized(handlers.postCreatePSBT)).Methods("POST")
+	handleFunc("/psbt-combine", handlers.ensureAccountInitialized(handlers.postCombinePSBTs)).Methods("POST")
+	handleFunc("/psbt-broadcast", handlers.ensureAccountInitialized(handlers.postBroadcastPSBT)).Methods("POST")
+	handleFunc("/sweep-proposal", handlers.ensureAccountInitialized(handlers.postSweepProposal)).Methods("POST")
+	handleFunc("/sweep", handlers.ensureAccountInitialized(handlers.postSweep)).Methods("POST")
 	handleFunc("/fee-targets", handlers.ensureAccountInitialized(handlers.getAccountFeeTargets)).Methods("GET")
 	handleFunc("/tx-proposal", handlers.ensureAccountInitialized(handlers.getAccountTxProposal)).Methods("POST")
+	handleFunc("/speed-up-tx", handlers.ensureAccountInitialized(handlers.postSpeedUpTx)).Methods("POST")
+	handleFunc("/cancel-tx", handlers.ensureAccountInitialized(handlers.postCancelTx)).Methods("POST")
+	handleFunc("/sign-message", handlers.ensureAccountInitialized(handlers.postSignMessage)).Methods("POST")
+	handleFunc("/contract-call-proposal", handlers.ensureAccountInitialized(handlers.postContractCallProposal)).Methods("POST")
+	handleFunc("/gap-limits", handlers.ensureAccountInitialized(handlers.getGapLimits)).Methods("GET")
 	handleFunc("/receive-addresses", handlers.ensureAccountInitialized(handlers.getReceiveAddresses)).Methods("GET")
+	handleFunc("/addresses", handlers.ensureAccountInitialized(handlers.getAddresses)).Methods("GET")
+	handleFunc("/address-label", handlers.ensureAccountInitialized(handlers.postAddressLabel)).Methods("POST")
 	handleFunc("/verify-address", handlers.ensureAccountInitialized(handlers.postVerifyAddress)).Methods("POST")
 	handleFunc("/can-verify-extended-public-key", handlers.ensureAccountInitialized(handlers.getCanVerifyExtendedPublicKey)).Methods("GET")
 	handleFunc("/verify-extended-public-key", handlers.ensureAccountInitialized(handlers.postVerifyExtendedPublicKey)).Methods("POST")
@@ -128,6 +145,19 @@ func (handlers *Handlers) formatAmountAsJSON(amount coin.Amount) FormattedAmount
 	}
 }
 
+// formatFeeAsJSON formats a fee. The fees of token accounts are paid in the parent coin.
+func (handlers *Handlers) formatFeeAsJSON(amount coin.Amount) FormattedAmount {
+	tokenCoin, ok := handlers.account.Coin().(*eth.TokenCoin)
+	if !ok {
+		return handlers.formatAmountAsJSON(amount)
+	}
+	return FormattedAmount{
+		Amount:      tokenCoin.Parent().FormatAmount(amount),
+		Unit:        tokenCoin.Parent().Unit(),
+		Conversions: Conversions(amount, tokenCoin.Parent()),
+	}
+}
+
 func (handlers *Handlers) formatBTCAmountAsJSON(amount btcutil.Amount) FormattedAmount {
 	return handlers.formatAmountAsJSON(coin.NewAmountFromInt64(int64(amount)))
 }
@@ -141,6 +171,9 @@ type Transaction struct {
 	Fee              FormattedAmount `json:"fee"`
 	Time             *string         `json:"time"`
 	Addresses        []string        `json:"addresses"`
+	// AddressLabels maps our labeled addresses among Addresses to their labels.
+	AddressLabels map[string]string `json:"addressLabels"`
+	Note          string            `json:"note"`
 
 	// BTC specific fields.
 	VSize        int64           `json:"vsize"`
@@ -149,7 +182,9 @@ type Transaction struct {
 	FeeRatePerKb FormattedAmount `json:"feeRatePerKb"`
 
 	// ETH specific fields
-	Gas uint64 `json:"gas"`
+	Gas          uint64              `json:"gas"`
+	ReplacedTxID string              `json:"replacedTxID"`
+	ContractCall *types.ContractCall `json:"contractCall"`
 }
 
 func (handlers *Handlers) ensureAccountInitialized(h func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
@@ -171,7 +206,7 @@ func (handlers *Handlers) getAccountTransactions(_ *http.Request) (interface{},
 		var feeString FormattedAmount
 		fee := txInfo.Fee()
 		if fee != nil {
-			feeString = handlers.formatAmountAsJSON(*fee)
+			feeString = handlers.formatFeeAsJSON(*fee)
 		}
 		var formattedTime *string
 		timestamp := txInfo.Timestamp()
@@ -180,8 +215,12 @@ func (handlers *Handlers) getAccountTransactions(_ *http.Request) (interface{},
 			formattedTime = &t
 		}
 		addresses := []string{}
+		addressLabels := map[string]string{}
 		for _, addressAndAmount := range txInfo.Addresses() {
 			addresses = append(addresses, addressAndAmount.Address)
+			if addressAndAmount.Label != "" {
+				addressLabels[addressAndAmount.Address] = addressAndAmount.Label
+			}
 		}
 		txInfoJSON := Transaction{
 			ID:               txInfo.ID(),
@@ -191,10 +230,12 @@ func (handlers *Handlers) getAccountTransactions(_ *http.Request) (interface{},
 				accounts.TxTypeSend:     "send",
 				accounts.TxTypeSendSelf: "send_to_self",
 			}[txInfo.Type()],
-			Amount:    handlers.formatAmountAsJSON(txInfo.Amount()),
-			Fee:       feeString,
-			Time:      formattedTime,
-			Addresses: addresses,
+			Amount:        handlers.formatAmountAsJSON(txInfo.Amount()),
+			Fee:           feeString,
+			Time:          formattedTime,
+			Addresses:     addresses,
+			AddressLabels: addressLabels,
+			Note:          txInfo.Note(),
 		}
 		switch specificInfo := txInfo.(type) {
 		case *transactions.TxInfo:
@@ -207,6 +248,8 @@ func (handlers *Handlers) getAccountTransactions(_ *http.Request) (interface{},
 			}
 		case types.EthereumTransaction:
 			txInfoJSON.Gas = specificInfo.Gas()
+			txInfoJSON.ReplacedTxID = specificInfo.ReplacedTxID()
+			txInfoJSON.ContractCall = specificInfo.ContractCall()
 		}
 		result = append(result, txInfoJSON)
 	}
@@ -243,7 +286,9 @@ func (handlers *Handlers) postExportTransactions(_ *http.Request) (interface{},
 		"Unit",
 		"Fee",
 		"Address",
+		"Address label",
 		"Transaction ID",
+		"Note",
 	})
 	if err != nil {
 		return nil, errp.WithStack(err)
@@ -280,7 +325,9 @@ func (handlers *Handlers) postExportTransactions(_ *http.Request) (interface{},
 				unit,
 				feeString,
 				addressAndAmount.Address,
+				addressAndAmount.Label,
 				transaction.ID(),
+				transaction.Note(),
 			})
 			if err != nil {
 				return nil, errp.WithStack(err)
@@ -294,6 +341,70 @@ func (handlers *Handlers) postExportTransactions(_ *http.Request) (interface{},
 	return path, nil
 }
 
+func (handlers *Handlers) postTxNote(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		TxID string `json:"txID"`
+		Note string `json:"note"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.account.SetTxNote(jsonBody.TxID, jsonBody.Note); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// postExportLabels exports the transaction notes and address labels in the BIP329 format to the
+// downloads folder and returns the path of the file.
+func (handlers *Handlers) postExportLabels(_ *http.Request) (interface{}, error) {
+	labels, err := handlers.account.ExportLabels()
+	if err != nil {
+		return nil, err
+	}
+	name := time.Now().Format("2006-01-02-at-15-04-05-") + handlers.account.Code() + "-labels.jsonl"
+	downloadsDir, err := config.DownloadsDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(downloadsDir, name)
+	handlers.log.Infof("Export labels to %s.", path)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			handlers.log.WithError(err).Error("Could not close the exported labels file.")
+		}
+	}()
+	if err := accounts.EncodeLabels(file, labels); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// postImportLabels imports transaction notes and address labels given in the BIP329 format.
+func (handlers *Handlers) postImportLabels(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		Labels string `json:"labels"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	labels, err := accounts.DecodeLabels(strings.NewReader(jsonBody.Labels))
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorCode": "invalidLabels"}, nil
+	}
+	imported, err := handlers.account.ImportLabels(labels)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "imported": imported}, nil
+}
+
 func (handlers *Handlers) getAccountInfo(_ *http.Request) (interface{}, error) {
 	return handlers.account.Info(), nil
 }
@@ -392,12 +503,305 @@ func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error
 	if errp.Cause(err) == keystore.ErrSigningAborted {
 		return map[string]interface{}{"success": false, "aborted": true}, nil
 	}
+	if errp.Cause(err) == keystore.ErrNoKeystore {
+		return map[string]interface{}{"success": false, "errorCode": "noKeystore"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// postCreatePSBT creates a transaction of a multisig account as a PSBT, signed by the connected
+// cosigners, to be passed on to the other cosigners.
+func (handlers *Handlers) postCreatePSBT(r *http.Request) (interface{}, error) {
+	var input sendTxInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("PSBTs are only supported for bitcoin based accounts")
+	}
+	encodedPSBT, err := btcAccount.CreatePSBT(
+		input.address,
+		input.sendAmount,
+		input.feeTargetCode,
+		input.selectedUTXOs,
+	)
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false, "aborted": true}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "psbt": encodedPSBT}, nil
+}
+
+// postCombinePSBTs combines PSBTs signed by different cosigners and adds the signatures of the
+// connected cosigners.
+func (handlers *Handlers) postCombinePSBTs(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		PSBTs []string `json:"psbts"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("PSBTs are only supported for bitcoin based accounts")
+	}
+	encodedPSBT, complete, err := btcAccount.CombinePSBTs(jsonBody.PSBTs)
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false, "aborted": true}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{
+		"success":  true,
+		"psbt":     encodedPSBT,
+		"complete": complete,
+	}, nil
+}
+
+func (handlers *Handlers) postBroadcastPSBT(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		PSBT string `json:"psbt"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("PSBTs are only supported for bitcoin based accounts")
+	}
+	if err := btcAccount.BroadcastPSBT(jsonBody.PSBT); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// sweepInput is the input of the sweep endpoints. PrivateKey is a WIF private key, or a BIP38
+// encrypted private key if Passphrase is not empty.
+type sweepInput struct {
+	PrivateKey string `json:"privateKey"`
+	Passphrase string `json:"passphrase"`
+	FeeTarget  string `json:"feeTarget"`
+}
+
+func (handlers *Handlers) decodeSweepInput(r *http.Request) (
+	*btc.Account, *sweepInput, accounts.FeeTargetCode, error) {
+	var input sweepInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, nil, "", errp.WithStack(err)
+	}
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, nil, "", errp.New("Sweeping private keys is only supported for bitcoin based accounts")
+	}
+	feeTargetCode, err := accounts.NewFeeTargetCode(input.FeeTarget)
+	if err != nil {
+		return nil, nil, "", errp.WithMessage(err, "Failed to decode fee target code")
+	}
+	return btcAccount, &input, feeTargetCode, nil
+}
+
+func (handlers *Handlers) postSweepProposal(r *http.Request) (interface{}, error) {
+	btcAccount, input, feeTargetCode, err := handlers.decodeSweepInput(r)
+	if err != nil {
+		return nil, err
+	}
+	amount, fee, err := btcAccount.SweepProposal(input.PrivateKey, input.Passphrase, feeTargetCode)
+	if err != nil {
+		if _, ok := errp.Cause(err).(errors.TxValidationError); ok {
+			return txProposalError(err)
+		}
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{
+		"success": true,
+		"amount":  handlers.formatAmountAsJSON(amount),
+		"fee":     handlers.formatFeeAsJSON(fee),
+	}, nil
+}
+
+func (handlers *Handlers) postSweep(r *http.Request) (interface{}, error) {
+	btcAccount, input, feeTargetCode, err := handlers.decodeSweepInput(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := btcAccount.Sweep(input.PrivateKey, input.Passphrase, feeTargetCode); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// postReplaceTx speeds up or cancels a pending outgoing ETH transaction, depending on cancel.
+func (handlers *Handlers) postReplaceTx(r *http.Request, cancel bool) (interface{}, error) {
+	jsonBody := struct {
+		TxID      string `json:"txID"`
+		FeeTarget string `json:"feeTarget"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	feeTargetCode, err := accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return nil, err
+	}
+	var ethAccount *eth.Account
+	switch specificAccount := handlers.account.(type) {
+	case *eth.Account:
+		ethAccount = specificAccount
+	case *eth.TokenAccount:
+		ethAccount = specificAccount.Parent()
+	default:
+		return nil, errp.New("Only pending ETH transactions can be replaced")
+	}
+	if cancel {
+		err = ethAccount.CancelTx(jsonBody.TxID, feeTargetCode)
+	} else {
+		err = ethAccount.SpeedUpTx(jsonBody.TxID, feeTargetCode)
+	}
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false, "aborted": true}, nil
+	}
 	if err != nil {
 		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
 	}
 	return map[string]interface{}{"success": true}, nil
 }
 
+func (handlers *Handlers) postSpeedUpTx(r *http.Request) (interface{}, error) {
+	return handlers.postReplaceTx(r, false)
+}
+
+// postContractCallProposal encodes the calldata of a contract method call using the contract ABI
+// and returns it together with a preview of the decoded call and the amounts of the transaction,
+// for which the gas is estimated. The ABI is stored to decode calls to the contract in the
+// transaction history. The transaction is sent via /sendtx with the returned data.
+func (handlers *Handlers) postContractCallProposal(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		ContractAddress string   `json:"contractAddress"`
+		ABI             string   `json:"abi"`
+		Method          string   `json:"method"`
+		Arguments       []string `json:"arguments"`
+		Amount          string   `json:"amount"`
+		FeeTarget       string   `json:"feeTarget"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	ethAccount, ok := handlers.account.(*eth.Account)
+	if !ok {
+		return nil, errp.New("Only ETH accounts can call contracts")
+	}
+	if !ethcommon.IsHexAddress(jsonBody.ContractAddress) {
+		return txProposalError(errp.WithStack(errors.ErrInvalidAddress))
+	}
+	contractAddress := ethcommon.HexToAddress(jsonBody.ContractAddress)
+	feeTargetCode, err := accounts.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return nil, err
+	}
+	data, contractCall, err := eth.EncodeContractCall(
+		jsonBody.ABI, jsonBody.Method, jsonBody.Arguments)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	amount := jsonBody.Amount
+	if amount == "" {
+		amount = "0"
+	}
+	outputAmount, fee, total, err := ethAccount.TxProposal(
+		contractAddress.Hex(), coin.NewSendAmount(amount), feeTargetCode, nil, data)
+	if err != nil {
+		return txProposalError(err)
+	}
+	if err := ethAccount.StoreContractABI(contractAddress, jsonBody.ABI); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"success":      true,
+		"data":         hexutil.Encode(data),
+		"contractCall": contractCall,
+		"amount":       handlers.formatAmountAsJSON(outputAmount),
+		"fee":          handlers.formatFeeAsJSON(fee),
+		"total":        handlers.formatAmountAsJSON(total),
+	}, nil
+}
+
+// postSignMessage signs a message with the key of a receive address of a BTC account in the given
+// format, or with personal_sign or, if typedData is provided, EIP-712 typed data with the key of an
+// ETH account. A message to be signed by an ETH account starting with 0x is signed as hex encoded
+// bytes.
+func (handlers *Handlers) postSignMessage(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		Message   string          `json:"message"`
+		TypedData json.RawMessage `json:"typedData"`
+		AddressID string          `json:"addressID"`
+		Format    string          `json:"format"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	var ethAccount *eth.Account
+	switch specificAccount := handlers.account.(type) {
+	case *btc.Account:
+		address, signature, err := specificAccount.SignMessage(
+			jsonBody.AddressID, []byte(jsonBody.Message), btc.MessageFormat(jsonBody.Format))
+		if errp.Cause(err) == keystore.ErrSigningAborted {
+			return map[string]interface{}{"success": false, "aborted": true}, nil
+		}
+		if err != nil {
+			return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+		}
+		return map[string]interface{}{
+			"success":   true,
+			"signature": signature,
+			"address":   address,
+		}, nil
+	case *eth.Account:
+		ethAccount = specificAccount
+	case *eth.TokenAccount:
+		ethAccount = specificAccount.Parent()
+	default:
+		return nil, errp.New("This account cannot sign messages")
+	}
+	var signature []byte
+	var address ethcommon.Address
+	var err error
+	if len(jsonBody.TypedData) != 0 && string(jsonBody.TypedData) != "null" {
+		typedData, parseErr := eth.ParseTypedData(jsonBody.TypedData)
+		if parseErr != nil {
+			return map[string]interface{}{"success": false, "errorMessage": parseErr.Error()}, nil
+		}
+		signature, address, err = ethAccount.SignTypedData(typedData)
+	} else {
+		message := []byte(jsonBody.Message)
+		if decoded, decodeErr := hexutil.Decode(jsonBody.Message); decodeErr == nil {
+			message = decoded
+		}
+		signature, address, err = ethAccount.SignMessage(message)
+	}
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false, "aborted": true}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{
+		"success":   true,
+		"signature": hexutil.Encode(signature),
+		"address":   address.Hex(),
+	}, nil
+}
+
+func (handlers *Handlers) postCancelTx(r *http.Request) (interface{}, error) {
+	return handlers.postReplaceTx(r, true)
+}
+
 func txProposalError(err error) (interface{}, error) {
 	if validationErr, ok := errp.Cause(err).(errors.TxValidationError); ok {
 		return map[string]interface{}{
@@ -413,6 +817,24 @@ func (handlers *Handlers) getAccountTxProposal(r *http.Request) (interface{}, er
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		return txProposalError(errp.WithStack(err))
 	}
+	// ENS names are resolved only here. The frontend sends the transaction to the returned address,
+	// which is shown to the user and confirmed on the device.
+	var recipient string
+	var ethAccount *eth.Account
+	switch specificAccount := handlers.account.(type) {
+	case *eth.Account:
+		ethAccount = specificAccount
+	case *eth.TokenAccount:
+		ethAccount = specificAccount.Parent()
+	}
+	if ethAccount != nil {
+		address, err := ethAccount.ResolveRecipient(input.address)
+		if err != nil {
+			return txProposalError(err)
+		}
+		recipient = address.Hex()
+		input.address = recipient
+	}
 	outputAmount, fee, total, err := handlers.account.TxProposal(
 		input.address,
 		input.sendAmount,
@@ -423,12 +845,16 @@ func (handlers *Handlers) getAccountTxProposal(r *http.Request) (interface{}, er
 	if err != nil {
 		return txProposalError(err)
 	}
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"success": true,
 		"amount":  handlers.formatAmountAsJSON(outputAmount),
-		"fee":     handlers.formatAmountAsJSON(fee),
+		"fee":     handlers.formatFeeAsJSON(fee),
 		"total":   handlers.formatAmountAsJSON(total),
-	}, nil
+	}
+	if recipient != "" {
+		result["recipient"] = recipient
+	}
+	return result, nil
 }
 
 func (handlers *Handlers) getAccountFeeTargets(_ *http.Request) (interface{}, error) {
@@ -487,6 +913,61 @@ func (handlers *Handlers) getReceiveAddresses(_ *http.Request) (interface{}, err
 	return addresses, nil
 }
 
+func (handlers *Handlers) getAddresses(_ *http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	addressesInfo, err := btcAccount.AddressesInfo()
+	if err != nil {
+		return nil, err
+	}
+	addresses := []interface{}{}
+	for _, address := range addressesInfo {
+		addresses = append(addresses, map[string]interface{}{
+			"address":         address.EncodeForHumans(),
+			"addressID":       address.ID(),
+			"keypath":         address.Configuration.AbsoluteKeypath().Encode(),
+			"change":          address.Change,
+			"used":            address.IsUsed(),
+			"label":           address.Label,
+			"balance":         handlers.formatBTCAmountAsJSON(address.Balance),
+			"numTransactions": address.NumTransactions,
+		})
+	}
+	return addresses, nil
+}
+
+func (handlers *Handlers) postAddressLabel(r *http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("Interface must be of type btc.Account")
+	}
+	jsonBody := struct {
+		AddressID string `json:"addressID"`
+		Label     string `json:"label"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := btcAccount.SetAddressLabel(jsonBody.AddressID, jsonBody.Label); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getGapLimits(_ *http.Request) (interface{}, error) {
+	btcAccount, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("An account must be BTC based to have gap limits")
+	}
+	configured, effective := btcAccount.GapLimits()
+	return map[string]interface{}{
+		"configured": configured,
+		"effective":  effective,
+	}, nil
+}
+
 func (handlers *Handlers) postVerifyAddress(r *http.Request) (interface{}, error) {
 	var addressID string
 	if err := json.NewDecoder(r.Body).Decode(&addressID); err != nil {
@@ -499,7 +980,7 @@ func (handlers *Handlers) getCanVerifyExtendedPublicKey(_ *http.Request) (interf
 	switch specificAccount := handlers.account.(type) {
 	case *btc.Account:
 		return specificAccount.CanVerifyExtendedPublicKey(), nil
-	case *eth.Account:
+	case *eth.Account, *eth.TokenAccount:
 		// No xpub verification for ethereum accounts
 		return []int{}, nil
 	default:
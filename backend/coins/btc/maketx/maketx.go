@@ -129,6 +129,63 @@ func NewTxSpendAll(
 	}, nil
 }
 
+// SweepInput is an output which is spent by a sweep transaction. The output does not belong to an
+// account, so the sizes needed to spend it are provided explicitly.
+type SweepInput struct {
+	OutPoint wire.OutPoint
+	TxOut    *wire.TxOut
+	// SigScriptSize is the maximum size of the signature script spending the output.
+	SigScriptSize int
+	// WitnessSize is the maximum size of the witness serialization spending the output, or 0 if
+	// the output is not a segwit output.
+	WitnessSize int
+}
+
+// NewTxSweep creates a transaction which spends all the given inputs to a single output to the
+// given address. The fee is deducted from the swept amount. ErrInsufficientFunds is returned if the
+// remaining amount is dust. The returned proposal has no account configuration, as the inputs do
+// not belong to an account.
+func NewTxSweep(
+	coin coin.Coin,
+	inputs []*SweepInput,
+	outputAddress *addresses.AccountAddress,
+	feePerKb btcutil.Amount,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(inputs) == 0 {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	txIns := make([]*wire.TxIn, len(inputs))
+	inputsSum := btcutil.Amount(0)
+	for index, input := range inputs {
+		outPoint := input.OutPoint
+		txIns[index] = wire.NewTxIn(&outPoint, nil, nil)
+		inputsSum += btcutil.Amount(input.TxOut.Value)
+	}
+	outputPkScript := outputAddress.PubkeyScript()
+	txSize := estimateSweepTxSize(inputs, len(outputPkScript))
+	maxRequiredFee := feeForSerializeSize(feePerKb, txSize, log)
+	if inputsSum <= maxRequiredFee || isDustAmount(
+		inputsSum-maxRequiredFee, len(outputPkScript), outputAddress.Configuration, feePerKb) {
+		return nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	output := wire.NewTxOut(int64(inputsSum-maxRequiredFee), outputPkScript)
+	unsignedTransaction := &wire.MsgTx{
+		Version:  wire.TxVersion,
+		TxIn:     txIns,
+		TxOut:    []*wire.TxOut{output},
+		LockTime: 0,
+	}
+	txsort.InPlaceSort(unsignedTransaction)
+	log.WithField("fee", maxRequiredFee).Debug("Preparing sweep transaction")
+	return &TxProposal{
+		Coin:        coin,
+		Amount:      btcutil.Amount(output.Value),
+		Fee:         maxRequiredFee,
+		Transaction: unsignedTransaction,
+	}, nil
+}
+
 // NewTx creates a transaction from a set of unspent outputs, targeting an output value. A subset of
 // the unspent outputs is selected to cover the needed amount. A change output is added if needed.
 func NewTx(
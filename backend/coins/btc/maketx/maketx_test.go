@@ -291,3 +291,57 @@ func (s *newTxSuite) TestNewTxCoinSelection() {
 	// coins: .5, .3, .1, .1, .9, .8, .6. select .5+.3+.1+.1 to get 1BTC, take .9 to cover the fees.
 	s.check(amount, feePerKb, s.buildUTXO(500*mBTC, 300*mBTC, 100*mBTC, 100*mBTC, 90*mBTC, 80*mBTC, 70*mBTC), s.change(90*mBTC-txSizeFiveInputs), noDust, s.selectCoins(0, 1, 2, 3, 4))
 }
+
+func (s *newTxSuite) TestNewTxSweep() {
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	outputAddress := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	outputPkScript := outputAddress.PubkeyScript()
+	utxo := s.buildUTXO(1000, 2000)
+	inputs := []*maketx.SweepInput{}
+	for outPoint, txOut := range utxo {
+		inputs = append(inputs, &maketx.SweepInput{OutPoint: outPoint, TxOut: txOut})
+	}
+	// A P2PKH input with a compressed public key and a P2WPKH input.
+	inputs[0].SigScriptSize = 1 + 73 + 1 + 33
+	inputs[1].WitnessSize = 1 + 1 + 73 + 1 + 33
+	// Non-witness: 4 version + 4 locktime + 1 input count + 1 output count + 31 output
+	// + 149 P2PKH input + 41 P2WPKH input = 231 bytes, weight 924.
+	// Witness: 1 empty witness of the P2PKH input + 109 + 2 marker and flag = 112.
+	// vsize: ceil(1036 / 4) = 259.
+	const expectedFee = 259
+	txProposal, err := maketx.NewTxSweep(tbtc, inputs, outputAddress, feePerKb, s.log)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(expectedFee), txProposal.Fee)
+	require.Equal(s.T(), btcutil.Amount(3000-expectedFee), txProposal.Amount)
+	require.Len(s.T(), txProposal.Transaction.TxIn, 2)
+	require.Len(s.T(), txProposal.Transaction.TxOut, 1)
+	require.Equal(s.T(), outputPkScript, txProposal.Transaction.TxOut[0].PkScript)
+
+	_, err = maketx.NewTxSweep(tbtc, inputs, outputAddress, 20*feePerKb, s.log)
+	require.Equal(s.T(), errors.ErrInsufficientFunds, errp.Cause(err))
+	_, err = maketx.NewTxSweep(tbtc, nil, outputAddress, feePerKb, s.log)
+	require.Equal(s.T(), errors.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxSweepDust() {
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	outputAddress := addressesTest.GetAddress(signing.ScriptTypeP2WPKH)
+	sweep := func(values ...int64) (*maketx.TxProposal, error) {
+		inputs := []*maketx.SweepInput{}
+		for outPoint, txOut := range s.buildUTXO(values...) {
+			inputs = append(inputs, &maketx.SweepInput{OutPoint: outPoint, TxOut: txOut})
+		}
+		// A P2PKH input with a compressed public key and a P2WPKH input, see TestNewTxSweep.
+		inputs[0].SigScriptSize = 1 + 73 + 1 + 33
+		inputs[1].WitnessSize = 1 + 1 + 73 + 1 + 33
+		return maketx.NewTxSweep(tbtc, inputs, outputAddress, feePerKb, s.log)
+	}
+	const fee = 259
+	// A P2WPKH output costs 31 bytes plus 41 bytes to spend it. It is dust if its value is below
+	// three times the fee of these 72 bytes: 216 satoshi.
+	_, err := sweep(200, fee+15)
+	require.Equal(s.T(), errors.ErrInsufficientFunds, errp.Cause(err))
+	txProposal, err := sweep(200, fee+16)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(216), txProposal.Amount)
+}
@@ -59,16 +59,44 @@ func estimateTxSize(
 		outputSize(outputPkScriptSize) +
 		outputSize(changePkScriptSize))
 	if hasWitness {
-		// For now, every input has a witness serialization of this format:
-		// <serialized sig> <serialized compressed pubkey>
-		const (
-			signatureSize = 73 // including SIGHASH op
-			pubkeySize    = 33
-		)
-		witnessSize := wire.VarIntSerializeSize(2) +
-			wire.VarIntSerializeSize(signatureSize) + signatureSize +
-			wire.VarIntSerializeSize(pubkeySize) + pubkeySize
-		txWeight += inputCount * witnessSize
+		txWeight += inputCount * addresses.WitnessSize(inputConfiguration)
+		txWeight += 2 // segwit marker + segwit flag
+	}
+	// return txWeight/4 rounded up.
+	if txWeight%4 == 0 {
+		return txWeight / 4
+	}
+	return txWeight/4 + 1
+}
+
+// estimateSweepTxSize gives the worst case virtual size of a sweep transaction, which spends inputs
+// of possibly different structures to a single output without change.
+func estimateSweepTxSize(inputs []*SweepInput, outputPkScriptSize int) int {
+	const (
+		outputCount  = 1
+		versionSize  = 4
+		lockTimeSize = 4
+		nonWitness   = 4 // factor for non-witness fields
+	)
+	txWeight := nonWitness * (versionSize + lockTimeSize + wire.VarIntSerializeSize(uint64(len(inputs))) +
+		wire.VarIntSerializeSize(outputCount) +
+		outputSize(outputPkScriptSize))
+	hasWitness := false
+	for _, input := range inputs {
+		txWeight += nonWitness * calcInputSize(input.SigScriptSize)
+		if input.WitnessSize != 0 {
+			hasWitness = true
+		}
+	}
+	if hasWitness {
+		for _, input := range inputs {
+			witnessSize := input.WitnessSize
+			if witnessSize == 0 {
+				// Inputs without a witness have an empty witness in a segwit transaction.
+				witnessSize = wire.VarIntSerializeSize(0)
+			}
+			txWeight += witnessSize
+		}
 		txWeight += 2 // segwit marker + segwit flag
 	}
 	// return txWeight/4 rounded up.
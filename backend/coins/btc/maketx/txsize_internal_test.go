@@ -40,7 +40,8 @@ func TestEstimateTxSize(t *testing.T) {
 	scriptTypeP2PKH := signing.ScriptTypeP2PKH
 	scriptTypeP2WPKHP2SH := signing.ScriptTypeP2WPKHP2SH
 	scriptTypeP2WPKH := signing.ScriptTypeP2WPKH
-	scriptTypes := []signing.ScriptType{scriptTypeP2PKH, scriptTypeP2WPKHP2SH, scriptTypeP2WPKH}
+	scriptTypeP2TR := signing.ScriptTypeP2TR
+	scriptTypes := []signing.ScriptType{scriptTypeP2PKH, scriptTypeP2WPKHP2SH, scriptTypeP2WPKH, scriptTypeP2TR}
 
 	test := func(inputScriptType, outputScriptType signing.ScriptType, changeScriptType *signing.ScriptType) {
 		changeStr := "noChange"
@@ -0,0 +1,310 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// MessageFormat is the format of a signed message.
+type MessageFormat string
+
+const (
+	// MessageFormatBIP137 is the legacy format of signed messages (BIP137): a compact signature
+	// from which the public key can be recovered.
+	MessageFormatBIP137 MessageFormat = "bip137"
+
+	// MessageFormatBIP322 is the simple format of BIP322 for native segwit addresses: the witness
+	// of a virtual transaction spending from the address.
+	MessageFormatBIP322 MessageFormat = "bip322"
+)
+
+// maxWitnessItemSize limits the size of the items when parsing a BIP322 signature.
+const maxWitnessItemSize = 10000
+
+// messageMagic returns the prefix of messages in the BIP137 format.
+func (coin *Coin) messageMagic() string {
+	switch coin.code {
+	case "ltc", "tltc":
+		return "Litecoin Signed Message:\n"
+	default:
+		return "Bitcoin Signed Message:\n"
+	}
+}
+
+// bip137Hash returns the hash of a message which is signed in the BIP137 format.
+func (coin *Coin) bip137Hash(message []byte) []byte {
+	var buffer bytes.Buffer
+	_ = wire.WriteVarString(&buffer, 0, coin.messageMagic())
+	_ = wire.WriteVarBytes(&buffer, 0, message)
+	return chainhash.DoubleHashB(buffer.Bytes())
+}
+
+// bip322Transactions returns the virtual to_spend and to_sign transactions of BIP322. The witness
+// of the to_sign transaction needs to be filled in to sign the message.
+func bip322Transactions(pkScript []byte, message []byte) (*wire.MsgTx, *wire.MsgTx, error) {
+	tag := sha256.Sum256([]byte("BIP0322-signed-message"))
+	messageHash := sha256.Sum256(append(append(tag[:], tag[:]...), message...))
+	scriptSig, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).AddData(messageHash[:]).Script()
+	if err != nil {
+		return nil, nil, errp.WithStack(err)
+	}
+	toSpend := wire.NewMsgTx(0)
+	toSpendIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0xFFFFFFFF), scriptSig, nil)
+	toSpendIn.Sequence = 0
+	toSpend.AddTxIn(toSpendIn)
+	toSpend.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	toSpendHash := toSpend.TxHash()
+	toSign := wire.NewMsgTx(0)
+	toSignIn := wire.NewTxIn(wire.NewOutPoint(&toSpendHash, 0), nil, nil)
+	toSignIn.Sequence = 0
+	toSign.AddTxIn(toSignIn)
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	return toSpend, toSign, nil
+}
+
+// verifyBIP322 checks that the to_sign transaction with its witness spends the to_spend output.
+func verifyBIP322(toSpend *wire.MsgTx, toSign *wire.MsgTx) error {
+	engine, err := txscript.NewEngine(toSpend.TxOut[0].PkScript, toSign, 0,
+		txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(toSign), 0)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(engine.Execute())
+}
+
+func serializeWitness(witness wire.TxWitness) []byte {
+	var buffer bytes.Buffer
+	_ = wire.WriteVarInt(&buffer, 0, uint64(len(witness)))
+	for _, item := range witness {
+		_ = wire.WriteVarBytes(&buffer, 0, item)
+	}
+	return buffer.Bytes()
+}
+
+func parseWitness(serialized []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(serialized)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil || count > uint64(len(serialized)) {
+		return nil, errp.New("Invalid witness.")
+	}
+	witness := make(wire.TxWitness, count)
+	for index := range witness {
+		witness[index], err = wire.ReadVarBytes(reader, 0, maxWitnessItemSize, "witness item")
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	if reader.Len() != 0 {
+		return nil, errp.New("Invalid witness.")
+	}
+	return witness, nil
+}
+
+// SignMessage signs the message with the key of the receive address with the given ID in the
+// given format. It returns the address and the base64 encoded signature. Returns
+// keystore.ErrSigningAborted on user abort.
+func (account *Account) SignMessage(
+	addressID string, message []byte, format MessageFormat) (string, string, error) {
+	account.synchronizer.WaitSynchronized()
+	unlock := account.RLock()
+	address := account.receiveAddresses.LookupByScriptHashHex(blockchain.ScriptHashHex(addressID))
+	unlock()
+	if address == nil {
+		return "", "", errp.New("unknown address not found")
+	}
+	if !address.Configuration.Singlesig() {
+		return "", "", errp.New("Messages can only be signed with singlesig addresses.")
+	}
+	publicKey := address.Configuration.PublicKeys()[0]
+	scriptType := address.Configuration.ScriptType()
+
+	messageProposal := &signing.MessageProposal{
+		Message: message,
+		Keypath: address.Configuration.AbsoluteKeypath(),
+	}
+	var toSpend, toSign *wire.MsgTx
+	switch format {
+	case MessageFormatBIP137:
+		switch scriptType {
+		case signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH:
+		default:
+			return "", "", errp.Newf("BIP137 is not supported for %s addresses.", scriptType)
+		}
+		messageProposal.Hash = account.coin.bip137Hash(message)
+	case MessageFormatBIP322:
+		if scriptType != signing.ScriptTypeP2WPKH {
+			return "", "", errp.New("BIP322 is only supported for native segwit addresses.")
+		}
+		var err error
+		toSpend, toSign, err = bip322Transactions(address.PubkeyScript(), message)
+		if err != nil {
+			return "", "", err
+		}
+		_, scriptCode := address.ScriptForHashToSign()
+		messageProposal.Hash, err = txscript.CalcWitnessSigHash(scriptCode,
+			txscript.NewTxSigHashes(toSign), txscript.SigHashAll, toSign, 0, 0)
+		if err != nil {
+			return "", "", errp.WithStack(err)
+		}
+	default:
+		return "", "", errp.Newf("Unknown message format %s.", format)
+	}
+
+	account.log.Info("Signing message")
+	if err := account.keystores.SignMessage(messageProposal); err != nil {
+		return "", "", err
+	}
+	if len(messageProposal.Signature) != 65 {
+		return "", "", errp.New("unexpected signature")
+	}
+	// Convert [R, S, recID] to the compact format [27 + 4 (compressed) + recID, R, S].
+	signature := append(
+		[]byte{27 + 4 + messageProposal.Signature[64]}, messageProposal.Signature[:64]...)
+	recoveredPublicKey, compressed, err := btcec.RecoverCompact(
+		btcec.S256(), signature, messageProposal.Hash)
+	if err != nil {
+		return "", "", errp.WithStack(err)
+	}
+	if !compressed || !recoveredPublicKey.IsEqual(publicKey) {
+		return "", "", errp.New("The signature does not match the address.")
+	}
+
+	var encodedSignature []byte
+	switch format {
+	case MessageFormatBIP137:
+		headerOffset := map[signing.ScriptType]byte{
+			signing.ScriptTypeP2PKH:      31,
+			signing.ScriptTypeP2WPKHP2SH: 35,
+			signing.ScriptTypeP2WPKH:     39,
+		}[scriptType]
+		recoveryID := (signature[0] - 27) & 3
+		encodedSignature = append([]byte{headerOffset + recoveryID}, signature[1:]...)
+	case MessageFormatBIP322:
+		derSignature := (&btcec.Signature{
+			R: new(big.Int).SetBytes(signature[1:33]),
+			S: new(big.Int).SetBytes(signature[33:]),
+		}).Serialize()
+		toSign.TxIn[0].Witness = wire.TxWitness{
+			append(derSignature, byte(txscript.SigHashAll)),
+			publicKey.SerializeCompressed(),
+		}
+		if err := verifyBIP322(toSpend, toSign); err != nil {
+			return "", "", errp.WithMessage(err, "The signature is invalid")
+		}
+		encodedSignature = serializeWitness(toSign.TxIn[0].Witness)
+	}
+	return address.EncodeAddress(), base64.StdEncoding.EncodeToString(encodedSignature), nil
+}
+
+// VerifyMessage checks that the base64 encoded signature of the message was made with the key of
+// the given address. BIP137 signatures and BIP322 simple signatures are supported. As many wallets
+// sign messages of segwit addresses with the BIP137 header of P2PKH addresses, the address type
+// encoded in the header of BIP137 signatures is not enforced. It returns an error if the
+// signature or address is malformed and false if the signature does not match.
+func (coin *Coin) VerifyMessage(address string, message []byte, signature string) (bool, error) {
+	decodedAddress, err := coin.DecodeAddress(address)
+	if err != nil {
+		return false, err
+	}
+	decodedSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, errp.New("The signature is not base64 encoded.")
+	}
+
+	if len(decodedSignature) == 65 {
+		header := decodedSignature[0]
+		if header < 27 || header > 42 {
+			return false, errp.New("Invalid signature header.")
+		}
+		// Normalize the header to the P2PKH range of 27-34 expected by RecoverCompact.
+		compressed := header >= 31
+		normalized := append([]byte{}, decodedSignature...)
+		normalized[0] = 27 + (header-27)%4
+		if compressed {
+			normalized[0] += 4
+		}
+		publicKey, _, err := btcec.RecoverCompact(
+			btcec.S256(), normalized, coin.bip137Hash(message))
+		if err != nil {
+			return false, nil
+		}
+		if !compressed {
+			candidate, err := btcutil.NewAddressPubKeyHash(
+				btcutil.Hash160(publicKey.SerializeUncompressed()), coin.Net())
+			if err != nil {
+				return false, errp.WithStack(err)
+			}
+			return candidate.EncodeAddress() == decodedAddress.EncodeAddress(), nil
+		}
+		publicKeyHash := btcutil.Hash160(publicKey.SerializeCompressed())
+		p2pkh, err := btcutil.NewAddressPubKeyHash(publicKeyHash, coin.Net())
+		if err != nil {
+			return false, errp.WithStack(err)
+		}
+		p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(publicKeyHash, coin.Net())
+		if err != nil {
+			return false, errp.WithStack(err)
+		}
+		redeemScript, err := txscript.PayToAddrScript(p2wpkh)
+		if err != nil {
+			return false, errp.WithStack(err)
+		}
+		p2wpkhP2SH, err := btcutil.NewAddressScriptHash(redeemScript, coin.Net())
+		if err != nil {
+			return false, errp.WithStack(err)
+		}
+		for _, candidate := range []btcutil.Address{p2pkh, p2wpkh, p2wpkhP2SH} {
+			if candidate.EncodeAddress() == decodedAddress.EncodeAddress() {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	switch decodedAddress.(type) {
+	case *btcutil.AddressWitnessPubKeyHash, *btcutil.AddressWitnessScriptHash:
+	default:
+		return false, errp.New("BIP322 signatures are only supported for native segwit addresses.")
+	}
+	witness, err := parseWitness(decodedSignature)
+	if err != nil {
+		return false, err
+	}
+	pkScript, err := txscript.PayToAddrScript(decodedAddress)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	toSpend, toSign, err := bip322Transactions(pkScript, message)
+	if err != nil {
+		return false, err
+	}
+	toSign.TxIn[0].Witness = witness
+	return verifyBIP322(toSpend, toSign) == nil, nil
+}
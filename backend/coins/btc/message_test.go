@@ -0,0 +1,104 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyMessageBIP137(t *testing.T) {
+	coin := btc.NewCoin("tbtc", "TBTC", net, "", nil, "")
+	// Test vector of Bitcoin Core (test/functional/rpc_signmessage.py).
+	address := "mpLQjfK79b7CCV4VMJWEWAj5Mpx8Up5zxB"
+	message := []byte("This is just a test message")
+	signature := "INbVnW4e6PeRmsv2Qgu8NuopvrVjkcxob+sX8OcZG0SALhWybUjzMLPdAsXI46YZGb0KQTRii+wWIQzRpG/U+S0="
+	valid, err := coin.VerifyMessage(address, message, signature)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	valid, err = coin.VerifyMessage(address, []byte("other message"), signature)
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	_, err = coin.VerifyMessage(address, message, "not base64!")
+	require.Error(t, err)
+	_, err = coin.VerifyMessage("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", message, signature)
+	require.Error(t, err, "mainnet address")
+}
+
+// TestVerifyMessageBIP137Segwit checks that the signatures of segwit addresses are accepted with
+// the headers of BIP137 as well as with the P2PKH headers used by some wallets.
+func TestVerifyMessageBIP137Segwit(t *testing.T) {
+	coin := btc.NewCoin("tbtc", "TBTC", net, "", nil, "")
+	privateKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	message := []byte("message")
+	var buffer bytes.Buffer
+	require.NoError(t, wire.WriteVarString(&buffer, 0, "Bitcoin Signed Message:\n"))
+	require.NoError(t, wire.WriteVarBytes(&buffer, 0, message))
+	signature, err := btcec.SignCompact(
+		btcec.S256(), privateKey, chainhash.DoubleHashB(buffer.Bytes()), true)
+	require.NoError(t, err)
+
+	publicKeyHash := btcutil.Hash160(privateKey.PubKey().SerializeCompressed())
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(publicKeyHash, net)
+	require.NoError(t, err)
+	otherP2WPKH, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), net)
+	require.NoError(t, err)
+	recoveryID := signature[0] - 31
+	for _, header := range []byte{31 + recoveryID, 39 + recoveryID} {
+		signature[0] = header
+		encoded := base64.StdEncoding.EncodeToString(signature)
+		valid, err := coin.VerifyMessage(p2wpkh.EncodeAddress(), message, encoded)
+		require.NoError(t, err)
+		require.True(t, valid)
+		valid, err = coin.VerifyMessage(otherP2WPKH.EncodeAddress(), message, encoded)
+		require.NoError(t, err)
+		require.False(t, valid)
+	}
+}
+
+func TestVerifyMessageBIP322(t *testing.T) {
+	coin := btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, "", nil, "")
+	// Test vectors of BIP322.
+	address := "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l"
+	for message, signature := range map[string]string{
+		"":            "AkcwRAIgM2gBAQqvZX15ZiysmKmQpDrG83avLIT492QBzLnQIxYCIBaTpOaD20qRlEylyxFSeEA2ba9YOixpX8z46TSDtS40ASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+		"Hello World": "AkcwRAIgZRfIY3p7/DoVTty6YZbWS71bc5Vct9p9Fia83eRmw2QCICK/ENGfwLtptFluMGs2KsqoNSk89pO7F29zJLUx9a/sASECx/EgAxlkQpQ9hYjgGu6EBCPMVPwVIVJqO4XCsMvViHI=",
+	} {
+		valid, err := coin.VerifyMessage(address, []byte(message), signature)
+		require.NoError(t, err)
+		require.True(t, valid, message)
+		valid, err = coin.VerifyMessage(address, []byte(message+"!"), signature)
+		require.NoError(t, err)
+		require.False(t, valid, message)
+	}
+
+	_, err := coin.VerifyMessage(address, nil, base64.StdEncoding.EncodeToString([]byte{2, 1}))
+	require.Error(t, err, "truncated witness")
+	_, err = coin.VerifyMessage("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", nil,
+		base64.StdEncoding.EncodeToString([]byte{0}))
+	require.Error(t, err, "BIP322 simple for a legacy address")
+}
@@ -0,0 +1,409 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// scriptHashHex returns the hash of the pkScript in the format used to look up addresses.
+func scriptHashHex(pkScript []byte) blockchain.ScriptHashHex {
+	return blockchain.ScriptHashHex(chainhash.HashH(pkScript).String())
+}
+
+// lookupAddress returns the receive or change address with the given script hash, or nil if the
+// address does not belong to the account.
+func (account *Account) lookupAddress(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+	if address := account.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
+		return address
+	}
+	return account.changeAddresses.LookupByScriptHashHex(scriptHashHex)
+}
+
+// supportsPSBT returns an error if the account cannot collect signatures of its cosigners in
+// PSBTs. Only segwit multisig accounts are supported, as the inputs of other accounts would need
+// the whole previous transactions.
+func (account *Account) supportsPSBT() error {
+	configuration := account.signingConfiguration
+	if !configuration.Multisig() ||
+		(configuration.ScriptType() != signing.ScriptTypeP2WSH &&
+			configuration.ScriptType() != signing.ScriptTypeP2WSHP2SH) {
+		return errp.New("PSBTs are only supported by segwit multisig accounts.")
+	}
+	return nil
+}
+
+// checkKeystores returns an error if a registered keystore is not the cosigner of this account at
+// its cosigner index, e.g. if a different device is connected.
+func (account *Account) checkKeystores() error {
+	configuration := account.signingConfiguration
+	for index := 0; index < account.keystores.Count(); index++ {
+		keystore := account.keystores.AccessKeystoreByIndex(index)
+		cosignerIndex := keystore.CosignerIndex()
+		if cosignerIndex >= configuration.NumberOfSigners() {
+			return errp.New("The registered keystore is not a cosigner of this account.")
+		}
+		extendedPublicKey, err := keystore.ExtendedPublicKey(
+			account.coin, configuration.AbsoluteKeypath())
+		if err != nil {
+			return err
+		}
+		if extendedPublicKey.String() != configuration.ExtendedPublicKeys()[cosignerIndex].String() {
+			return errp.New("The registered keystore is not a cosigner of this account.")
+		}
+	}
+	return nil
+}
+
+// bip32Derivations returns the keypaths of the public keys of the address. As the root
+// fingerprints of the cosigners are unknown, the keypaths start at the account xpubs: the
+// fingerprint is the one of the account xpub, followed by the relative keypath of the address.
+func (account *Account) bip32Derivations(address *addresses.AccountAddress) []*psbt.Bip32Derivation {
+	accountXPubs := account.signingConfiguration.ExtendedPublicKeys()
+	derivations := make([]*psbt.Bip32Derivation, len(accountXPubs))
+	for index, publicKey := range address.Configuration.PublicKeys() {
+		accountPublicKey, err := accountXPubs[index].ECPubKey()
+		if err != nil {
+			account.log.WithError(err).Panic("Failed to get the public key of an xpub.")
+		}
+		derivation := &psbt.Bip32Derivation{
+			PubKey: publicKey.SerializeCompressed(),
+			Path:   address.RelativeKeypath.ToUInt32(),
+		}
+		copy(derivation.Fingerprint[:], btcutil.Hash160(accountPublicKey.SerializeCompressed()))
+		derivations[index] = derivation
+	}
+	return derivations
+}
+
+// CreatePSBT creates a transaction which sends `amount` to the recipient, signs it with the
+// registered keystores which are cosigners of this account and returns it as a base64 encoded PSBT,
+// so the other cosigners can add their signatures.
+func (account *Account) CreatePSBT(
+	recipientAddress string,
+	amount coin.SendAmount,
+	feeTargetCode accounts.FeeTargetCode,
+	selectedUTXOs map[wire.OutPoint]struct{},
+) (string, error) {
+	if err := account.supportsPSBT(); err != nil {
+		return "", err
+	}
+	account.log.Info("Creating PSBT")
+	utxo, txProposal, err := account.newTx(recipientAddress, amount, feeTargetCode, selectedUTXOs)
+	if err != nil {
+		return "", errp.WithMessage(err, "Failed to create transaction")
+	}
+	packet, err := psbt.NewFromUnsignedTx(txProposal.Transaction)
+	if err != nil {
+		return "", err
+	}
+	for index, txIn := range txProposal.Transaction.TxIn {
+		spentOutput := utxo[txIn.PreviousOutPoint]
+		address := account.lookupAddress(spentOutput.ScriptHashHex())
+		if address == nil {
+			return "", errp.New("Unknown address of a spent output.")
+		}
+		input := packet.Inputs[index]
+		input.WitnessUtxo = spentOutput.TxOut
+		input.SighashType = txscript.SigHashAll
+		input.RedeemScript = address.RedeemScript()
+		input.WitnessScript = address.WitnessScript()
+		input.Bip32Derivation = account.bip32Derivations(address)
+	}
+	if changeAddress := txProposal.ChangeAddress; changeAddress != nil {
+		for index, txOut := range txProposal.Transaction.TxOut {
+			if bytes.Equal(txOut.PkScript, changeAddress.PubkeyScript()) {
+				output := packet.Outputs[index]
+				output.RedeemScript = changeAddress.RedeemScript()
+				output.WitnessScript = changeAddress.WitnessScript()
+				output.Bip32Derivation = account.bip32Derivations(changeAddress)
+			}
+		}
+	}
+	if err := account.signPSBT(packet); err != nil {
+		return "", err
+	}
+	return packet.B64Encode()
+}
+
+// psbtPreviousOutputs checks that all inputs of the PSBT spend unspent outputs of this account and
+// returns the spent outputs. The outputs are looked up in the transaction history of the account,
+// as the amounts in the PSBT could be manipulated to trick the signers into paying a higher fee.
+func (account *Account) psbtPreviousOutputs(
+	packet *psbt.Packet) (map[wire.OutPoint]*transactions.SpendableOutput, error) {
+	spendableOutputs := account.transactions.SpendableOutputs()
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for index, txIn := range packet.UnsignedTx.TxIn {
+		spentOutput, ok := spendableOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.New("The PSBT spends coins which are not available in this account.")
+		}
+		witnessUtxo := packet.Inputs[index].WitnessUtxo
+		if witnessUtxo == nil || witnessUtxo.Value != spentOutput.Value ||
+			!bytes.Equal(witnessUtxo.PkScript, spentOutput.PkScript) {
+			return nil, errp.New("The PSBT contains invalid information about the spent coins.")
+		}
+		if account.lookupAddress(spentOutput.ScriptHashHex()) == nil {
+			return nil, errp.New("The PSBT spends coins which do not belong to this account.")
+		}
+		previousOutputs[txIn.PreviousOutPoint] = spentOutput
+	}
+	return previousOutputs, nil
+}
+
+// psbtSignatures returns the valid signatures of the PSBT, ordered like the cosigners of the
+// account (signatures[transactionInput][cosignerIndex]). An error is returned if the PSBT contains
+// a signature which is invalid or of an unknown key.
+func (account *Account) psbtSignatures(
+	packet *psbt.Packet,
+	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+	sigHashes *txscript.TxSigHashes,
+) ([][]*btcec.Signature, error) {
+	signatures := make([][]*btcec.Signature, len(packet.Inputs))
+	for index, input := range packet.Inputs {
+		spentOutput := previousOutputs[packet.UnsignedTx.TxIn[index].PreviousOutPoint]
+		address := account.lookupAddress(spentOutput.ScriptHashHex())
+		_, script := address.ScriptForHashToSign()
+		signatureHash, err := txscript.CalcWitnessSigHash(
+			script, sigHashes, txscript.SigHashAll, packet.UnsignedTx, index, spentOutput.Value)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		publicKeys := address.Configuration.PublicKeys()
+		signatures[index] = make([]*btcec.Signature, len(publicKeys))
+		for _, partialSig := range input.PartialSigs {
+			cosignerIndex := -1
+			for publicKeyIndex, publicKey := range publicKeys {
+				if bytes.Equal(publicKey.SerializeCompressed(), partialSig.PubKey) {
+					cosignerIndex = publicKeyIndex
+				}
+			}
+			if cosignerIndex == -1 {
+				return nil, errp.New("The PSBT contains a signature of an unknown key.")
+			}
+			length := len(partialSig.Signature)
+			if length == 0 || txscript.SigHashType(partialSig.Signature[length-1]) != txscript.SigHashAll {
+				return nil, errp.New("The PSBT contains a signature with an unsupported sighash type.")
+			}
+			signature, err := btcec.ParseDERSignature(partialSig.Signature[:length-1], btcec.S256())
+			if err != nil || !signature.Verify(signatureHash, publicKeys[cosignerIndex]) {
+				return nil, errp.New("The PSBT contains an invalid signature.")
+			}
+			signatures[index][cosignerIndex] = signature
+		}
+	}
+	return signatures, nil
+}
+
+// signPSBT adds the signatures of the registered keystores to the PSBT, unless they are present
+// already.
+func (account *Account) signPSBT(packet *psbt.Packet) error {
+	if account.keystores.Count() == 0 {
+		account.log.Info("No keystore registered, the PSBT is not signed")
+		return nil
+	}
+	if err := account.checkKeystores(); err != nil {
+		return err
+	}
+	previousOutputs, err := account.psbtPreviousOutputs(packet)
+	if err != nil {
+		return err
+	}
+	sigHashes := txscript.NewTxSigHashes(packet.UnsignedTx)
+	signatures, err := account.psbtSignatures(packet, previousOutputs, sigHashes)
+	if err != nil {
+		return err
+	}
+	signed := true
+	for _, inputSignatures := range signatures {
+		for cosignerIndex := 0; cosignerIndex < account.keystores.Count(); cosignerIndex++ {
+			if inputSignatures[cosignerIndex] == nil {
+				signed = false
+			}
+		}
+	}
+	if signed {
+		return nil
+	}
+
+	// The keystores expect a proposal of a transaction of this account, as created by newTx.
+	txProposal := &maketx.TxProposal{
+		Coin:                 account.coin,
+		AccountConfiguration: account.signingConfiguration,
+		Transaction:          packet.UnsignedTx.Copy(),
+	}
+	for _, spentOutput := range previousOutputs {
+		txProposal.Fee += btcutil.Amount(spentOutput.Value)
+	}
+	for _, txOut := range txProposal.Transaction.TxOut {
+		txProposal.Fee -= btcutil.Amount(txOut.Value)
+		address := account.changeAddresses.LookupByScriptHashHex(scriptHashHex(txOut.PkScript))
+		if address != nil && txProposal.ChangeAddress == nil {
+			txProposal.ChangeAddress = address
+			continue
+		}
+		txProposal.Amount += btcutil.Amount(txOut.Value)
+	}
+	proposedTransaction := &ProposedTransaction{
+		TXProposal:      txProposal,
+		PreviousOutputs: previousOutputs,
+		GetAddress:      account.lookupAddress,
+		Signatures:      make([][]*btcec.Signature, len(packet.Inputs)),
+		SigHashes:       sigHashes,
+	}
+	for index := range proposedTransaction.Signatures {
+		proposedTransaction.Signatures[index] = make(
+			[]*btcec.Signature, account.signingConfiguration.NumberOfSigners())
+	}
+	if err := account.keystores.SignTransaction(proposedTransaction); err != nil {
+		return err
+	}
+	for index, input := range packet.Inputs {
+		spentOutput := previousOutputs[packet.UnsignedTx.TxIn[index].PreviousOutPoint]
+		publicKeys := account.lookupAddress(spentOutput.ScriptHashHex()).Configuration.PublicKeys()
+		for cosignerIndex, signature := range proposedTransaction.Signatures[index] {
+			if signature == nil {
+				continue
+			}
+			input.AddPartialSig(
+				publicKeys[cosignerIndex].SerializeCompressed(),
+				append(signature.Serialize(), byte(txscript.SigHashAll)))
+		}
+	}
+	// Sanity check: the new signatures must be valid.
+	_, err = account.psbtSignatures(packet, previousOutputs, sigHashes)
+	return err
+}
+
+// psbtComplete returns whether every input has enough signatures to be spent.
+func (account *Account) psbtComplete(signatures [][]*btcec.Signature) bool {
+	for _, inputSignatures := range signatures {
+		count := 0
+		for _, signature := range inputSignatures {
+			if signature != nil {
+				count++
+			}
+		}
+		if count < account.signingConfiguration.SigningThreshold() {
+			return false
+		}
+	}
+	return true
+}
+
+// CombinePSBTs combines the given base64 encoded PSBTs of the same transaction, e.g. signed by
+// different cosigners, and adds the signatures of the registered keystores. It returns the
+// combined PSBT and whether it has enough signatures to be broadcast.
+func (account *Account) CombinePSBTs(encodedPSBTs []string) (string, bool, error) {
+	if err := account.supportsPSBT(); err != nil {
+		return "", false, err
+	}
+	if len(encodedPSBTs) == 0 {
+		return "", false, errp.New("No PSBT given.")
+	}
+	var packet *psbt.Packet
+	for _, encodedPSBT := range encodedPSBTs {
+		other, err := psbt.NewFromBase64(encodedPSBT)
+		if err != nil {
+			return "", false, err
+		}
+		if packet == nil {
+			packet = other
+			continue
+		}
+		if err := packet.Combine(other); err != nil {
+			return "", false, err
+		}
+	}
+	if err := account.signPSBT(packet); err != nil {
+		return "", false, err
+	}
+	previousOutputs, err := account.psbtPreviousOutputs(packet)
+	if err != nil {
+		return "", false, err
+	}
+	signatures, err := account.psbtSignatures(
+		packet, previousOutputs, txscript.NewTxSigHashes(packet.UnsignedTx))
+	if err != nil {
+		return "", false, err
+	}
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return "", false, err
+	}
+	return encoded, account.psbtComplete(signatures), nil
+}
+
+// BroadcastPSBT finalizes the given base64 encoded PSBT, which needs to contain enough signatures,
+// and broadcasts the transaction.
+func (account *Account) BroadcastPSBT(encodedPSBT string) error {
+	if err := account.supportsPSBT(); err != nil {
+		return err
+	}
+	packet, err := psbt.NewFromBase64(encodedPSBT)
+	if err != nil {
+		return err
+	}
+	previousOutputs, err := account.psbtPreviousOutputs(packet)
+	if err != nil {
+		return err
+	}
+	transaction := packet.UnsignedTx.Copy()
+	sigHashes := txscript.NewTxSigHashes(transaction)
+	signatures, err := account.psbtSignatures(packet, previousOutputs, sigHashes)
+	if err != nil {
+		return err
+	}
+	if !account.psbtComplete(signatures) {
+		return errp.New("The PSBT does not have enough signatures.")
+	}
+	for index, txIn := range transaction.TxIn {
+		// OP_CHECKMULTISIG fails if there are more signatures than the threshold.
+		inputSignatures := signatures[index]
+		count := 0
+		for cosignerIndex, signature := range inputSignatures {
+			if signature == nil {
+				continue
+			}
+			if count == account.signingConfiguration.SigningThreshold() {
+				inputSignatures[cosignerIndex] = nil
+				continue
+			}
+			count++
+		}
+		address := account.lookupAddress(previousOutputs[txIn.PreviousOutPoint].ScriptHashHex())
+		txIn.SignatureScript, txIn.Witness = address.SignatureScript(inputSignatures)
+	}
+	if err := txValidityCheck(transaction, previousOutputs, sigHashes); err != nil {
+		return errp.WithMessage(err, "The finalized transaction is invalid")
+	}
+	account.log.Info("Broadcasting the transaction of a PSBT")
+	return account.blockchain.TransactionBroadcast(transaction)
+}
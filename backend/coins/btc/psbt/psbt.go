@@ -0,0 +1,609 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package psbt implements partially signed bitcoin transactions, see
+// https://github.com/bitcoin/bips/blob/master/bip-0174.mediawiki.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// magic prefixes every serialized PSBT.
+var magic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// maxValueSize limits the size of keys and values to protect against malicious input.
+const maxValueSize = 4000000
+
+// Key types of the global map.
+const (
+	globalUnsignedTx = 0x00
+)
+
+// Key types of the input maps.
+const (
+	inputNonWitnessUtxo     = 0x00
+	inputWitnessUtxo        = 0x01
+	inputPartialSig         = 0x02
+	inputSighashType        = 0x03
+	inputRedeemScript       = 0x04
+	inputWitnessScript      = 0x05
+	inputBip32Derivation    = 0x06
+	inputFinalScriptSig     = 0x07
+	inputFinalScriptWitness = 0x08
+)
+
+// Key types of the output maps.
+const (
+	outputRedeemScript    = 0x00
+	outputWitnessScript   = 0x01
+	outputBip32Derivation = 0x02
+)
+
+// Unknown is a key-value pair of an unknown type, which is kept as is.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// PartialSig is a signature of one of the keys needed to spend an input.
+type PartialSig struct {
+	// PubKey is the compressed public key.
+	PubKey []byte
+	// Signature is the DER encoded signature followed by the sighash type.
+	Signature []byte
+}
+
+// Bip32Derivation is the keypath of a public key.
+type Bip32Derivation struct {
+	// PubKey is the compressed public key.
+	PubKey []byte
+	// Fingerprint is the fingerprint of the extended key from which the path starts.
+	Fingerprint [4]byte
+	Path        []uint32
+}
+
+// Input holds the information needed to sign and spend an input of the transaction.
+type Input struct {
+	NonWitnessUtxo     *wire.MsgTx
+	WitnessUtxo        *wire.TxOut
+	PartialSigs        []*PartialSig
+	SighashType        txscript.SigHashType
+	RedeemScript       []byte
+	WitnessScript      []byte
+	Bip32Derivation    []*Bip32Derivation
+	FinalScriptSig     []byte
+	FinalScriptWitness wire.TxWitness
+	Unknowns           []*Unknown
+}
+
+// Output holds information about an output of the transaction, e.g. to identify change.
+type Output struct {
+	RedeemScript    []byte
+	WitnessScript   []byte
+	Bip32Derivation []*Bip32Derivation
+	Unknowns        []*Unknown
+}
+
+// Packet is a partially signed bitcoin transaction.
+type Packet struct {
+	// UnsignedTx is the transaction without signature scripts and witnesses.
+	UnsignedTx *wire.MsgTx
+	Inputs     []*Input
+	Outputs    []*Output
+	Unknowns   []*Unknown
+}
+
+// NewFromUnsignedTx creates a packet of the given transaction. The signature scripts and
+// witnesses of the transaction must be empty.
+func NewFromUnsignedTx(tx *wire.MsgTx) (*Packet, error) {
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) != 0 || len(txIn.Witness) != 0 {
+			return nil, errp.New("The transaction must be unsigned.")
+		}
+	}
+	packet := &Packet{
+		UnsignedTx: tx,
+		Inputs:     make([]*Input, len(tx.TxIn)),
+		Outputs:    make([]*Output, len(tx.TxOut)),
+	}
+	for index := range packet.Inputs {
+		packet.Inputs[index] = &Input{}
+	}
+	for index := range packet.Outputs {
+		packet.Outputs[index] = &Output{}
+	}
+	return packet, nil
+}
+
+// NewFromBase64 parses the base64 encoding of a serialized packet.
+func NewFromBase64(encoded string) (*Packet, error) {
+	serialized, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewFromRawBytes(bytes.NewReader(serialized))
+}
+
+// B64Encode returns the base64 encoding of the serialized packet.
+func (packet *Packet) B64Encode() (string, error) {
+	var buffer bytes.Buffer
+	if err := packet.Serialize(&buffer); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// keyValue is a raw entry of a map. The key type is the first byte of the key.
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	length, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if length > maxValueSize {
+		return nil, errp.New("PSBT value too large")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return data, nil
+}
+
+// readMap reads the entries of a map until the separator. Duplicate keys are rejected.
+func readMap(r io.Reader) ([]keyValue, error) {
+	entries := []keyValue{}
+	keys := map[string]struct{}{}
+	for {
+		key, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) == 0 {
+			return entries, nil
+		}
+		if _, ok := keys[string(key)]; ok {
+			return nil, errp.New("duplicate key in PSBT")
+		}
+		keys[string(key)] = struct{}{}
+		value, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, keyValue{key: key, value: value})
+	}
+}
+
+func writeBytes(w io.Writer, data []byte) error {
+	if err := wire.WriteVarInt(w, 0, uint64(len(data))); err != nil {
+		return errp.WithStack(err)
+	}
+	_, err := w.Write(data)
+	return errp.WithStack(err)
+}
+
+func writeEntry(w io.Writer, keyType byte, keyData []byte, value []byte) error {
+	if err := writeBytes(w, append([]byte{keyType}, keyData...)); err != nil {
+		return err
+	}
+	return writeBytes(w, value)
+}
+
+func parseBip32Derivation(pubKey []byte, value []byte) (*Bip32Derivation, error) {
+	if len(pubKey) != 33 || len(value) < 4 || len(value)%4 != 0 {
+		return nil, errp.New("invalid BIP32 derivation in PSBT")
+	}
+	derivation := &Bip32Derivation{PubKey: pubKey}
+	copy(derivation.Fingerprint[:], value[:4])
+	for offset := 4; offset < len(value); offset += 4 {
+		derivation.Path = append(derivation.Path, binary.LittleEndian.Uint32(value[offset:]))
+	}
+	return derivation, nil
+}
+
+func (derivation *Bip32Derivation) serializeValue() []byte {
+	value := append([]byte{}, derivation.Fingerprint[:]...)
+	for _, node := range derivation.Path {
+		var nodeBytes [4]byte
+		binary.LittleEndian.PutUint32(nodeBytes[:], node)
+		value = append(value, nodeBytes[:]...)
+	}
+	return value
+}
+
+func parseTxOut(value []byte) (*wire.TxOut, error) {
+	r := bytes.NewReader(value)
+	var amount int64
+	if err := binary.Read(r, binary.LittleEndian, &amount); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	pkScript, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewTxOut(amount, pkScript), nil
+}
+
+func serializeTxOut(txOut *wire.TxOut) []byte {
+	var buffer bytes.Buffer
+	_ = binary.Write(&buffer, binary.LittleEndian, txOut.Value)
+	_ = writeBytes(&buffer, txOut.PkScript)
+	return buffer.Bytes()
+}
+
+func parseWitness(value []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(value)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if count > uint64(len(value)) {
+		return nil, errp.New("invalid witness in PSBT")
+	}
+	witness := make(wire.TxWitness, count)
+	for index := range witness {
+		witness[index], err = readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return witness, nil
+}
+
+func serializeWitness(witness wire.TxWitness) []byte {
+	var buffer bytes.Buffer
+	_ = wire.WriteVarInt(&buffer, 0, uint64(len(witness)))
+	for _, item := range witness {
+		_ = writeBytes(&buffer, item)
+	}
+	return buffer.Bytes()
+}
+
+// NewFromRawBytes parses a serialized packet.
+func NewFromRawBytes(r io.Reader) (*Packet, error) {
+	prefix := make([]byte, len(magic))
+	if _, err := io.ReadFull(r, prefix); err != nil || !bytes.Equal(prefix, magic) {
+		return nil, errp.New("not a PSBT")
+	}
+	globals, err := readMap(r)
+	if err != nil {
+		return nil, err
+	}
+	packet := &Packet{}
+	for _, entry := range globals {
+		if entry.key[0] == globalUnsignedTx && len(entry.key) == 1 {
+			tx := &wire.MsgTx{}
+			if err := tx.DeserializeNoWitness(bytes.NewReader(entry.value)); err != nil {
+				return nil, errp.WithStack(err)
+			}
+			packet.UnsignedTx = tx
+			continue
+		}
+		packet.Unknowns = append(packet.Unknowns, &Unknown{Key: entry.key, Value: entry.value})
+	}
+	if packet.UnsignedTx == nil {
+		return nil, errp.New("PSBT is missing the unsigned transaction")
+	}
+	unsigned, err := NewFromUnsignedTx(packet.UnsignedTx)
+	if err != nil {
+		return nil, err
+	}
+	packet.Inputs = unsigned.Inputs
+	packet.Outputs = unsigned.Outputs
+	for _, input := range packet.Inputs {
+		entries, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := input.parse(entries); err != nil {
+			return nil, err
+		}
+	}
+	for _, output := range packet.Outputs {
+		entries, err := readMap(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := output.parse(entries); err != nil {
+			return nil, err
+		}
+	}
+	return packet, nil
+}
+
+func (input *Input) parse(entries []keyValue) error {
+	for _, entry := range entries {
+		keyType, keyData := entry.key[0], entry.key[1:]
+		if keyType != inputPartialSig && keyType != inputBip32Derivation && len(keyData) != 0 {
+			input.Unknowns = append(input.Unknowns, &Unknown{Key: entry.key, Value: entry.value})
+			continue
+		}
+		var err error
+		switch keyType {
+		case inputNonWitnessUtxo:
+			input.NonWitnessUtxo = &wire.MsgTx{}
+			err = input.NonWitnessUtxo.Deserialize(bytes.NewReader(entry.value))
+		case inputWitnessUtxo:
+			input.WitnessUtxo, err = parseTxOut(entry.value)
+		case inputPartialSig:
+			if len(keyData) != 33 {
+				return errp.New("invalid public key of a partial signature in PSBT")
+			}
+			input.PartialSigs = append(input.PartialSigs,
+				&PartialSig{PubKey: keyData, Signature: entry.value})
+		case inputSighashType:
+			if len(entry.value) != 4 {
+				return errp.New("invalid sighash type in PSBT")
+			}
+			input.SighashType = txscript.SigHashType(binary.LittleEndian.Uint32(entry.value))
+		case inputRedeemScript:
+			input.RedeemScript = entry.value
+		case inputWitnessScript:
+			input.WitnessScript = entry.value
+		case inputBip32Derivation:
+			var derivation *Bip32Derivation
+			derivation, err = parseBip32Derivation(keyData, entry.value)
+			if err == nil {
+				input.Bip32Derivation = append(input.Bip32Derivation, derivation)
+			}
+		case inputFinalScriptSig:
+			input.FinalScriptSig = entry.value
+		case inputFinalScriptWitness:
+			input.FinalScriptWitness, err = parseWitness(entry.value)
+		default:
+			input.Unknowns = append(input.Unknowns, &Unknown{Key: entry.key, Value: entry.value})
+		}
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (output *Output) parse(entries []keyValue) error {
+	for _, entry := range entries {
+		keyType, keyData := entry.key[0], entry.key[1:]
+		switch {
+		case keyType == outputRedeemScript && len(keyData) == 0:
+			output.RedeemScript = entry.value
+		case keyType == outputWitnessScript && len(keyData) == 0:
+			output.WitnessScript = entry.value
+		case keyType == outputBip32Derivation:
+			derivation, err := parseBip32Derivation(keyData, entry.value)
+			if err != nil {
+				return err
+			}
+			output.Bip32Derivation = append(output.Bip32Derivation, derivation)
+		default:
+			output.Unknowns = append(output.Unknowns, &Unknown{Key: entry.key, Value: entry.value})
+		}
+	}
+	return nil
+}
+
+func writeUnknowns(w io.Writer, unknowns []*Unknown) error {
+	for _, unknown := range unknowns {
+		if err := writeBytes(w, unknown.Key); err != nil {
+			return err
+		}
+		if err := writeBytes(w, unknown.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeBip32Derivations(w io.Writer, keyType byte, derivations []*Bip32Derivation) error {
+	for _, derivation := range derivations {
+		if err := writeEntry(w, keyType, derivation.PubKey, derivation.serializeValue()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Serialize writes the serialized packet.
+func (packet *Packet) Serialize(w io.Writer) error {
+	if _, err := w.Write(magic); err != nil {
+		return errp.WithStack(err)
+	}
+	var tx bytes.Buffer
+	if err := packet.UnsignedTx.SerializeNoWitness(&tx); err != nil {
+		return errp.WithStack(err)
+	}
+	if err := writeEntry(w, globalUnsignedTx, nil, tx.Bytes()); err != nil {
+		return err
+	}
+	if err := writeUnknowns(w, packet.Unknowns); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte{0}); err != nil {
+		return errp.WithStack(err)
+	}
+	for _, input := range packet.Inputs {
+		if err := input.serialize(w); err != nil {
+			return err
+		}
+	}
+	for _, output := range packet.Outputs {
+		if err := output.serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (input *Input) serialize(w io.Writer) error {
+	if input.NonWitnessUtxo != nil {
+		var tx bytes.Buffer
+		if err := input.NonWitnessUtxo.Serialize(&tx); err != nil {
+			return errp.WithStack(err)
+		}
+		if err := writeEntry(w, inputNonWitnessUtxo, nil, tx.Bytes()); err != nil {
+			return err
+		}
+	}
+	if input.WitnessUtxo != nil {
+		if err := writeEntry(w, inputWitnessUtxo, nil, serializeTxOut(input.WitnessUtxo)); err != nil {
+			return err
+		}
+	}
+	for _, partialSig := range input.PartialSigs {
+		if err := writeEntry(w, inputPartialSig, partialSig.PubKey, partialSig.Signature); err != nil {
+			return err
+		}
+	}
+	if input.SighashType != 0 {
+		var sighashType [4]byte
+		binary.LittleEndian.PutUint32(sighashType[:], uint32(input.SighashType))
+		if err := writeEntry(w, inputSighashType, nil, sighashType[:]); err != nil {
+			return err
+		}
+	}
+	if input.RedeemScript != nil {
+		if err := writeEntry(w, inputRedeemScript, nil, input.RedeemScript); err != nil {
+			return err
+		}
+	}
+	if input.WitnessScript != nil {
+		if err := writeEntry(w, inputWitnessScript, nil, input.WitnessScript); err != nil {
+			return err
+		}
+	}
+	if err := writeBip32Derivations(w, inputBip32Derivation, input.Bip32Derivation); err != nil {
+		return err
+	}
+	if input.FinalScriptSig != nil {
+		if err := writeEntry(w, inputFinalScriptSig, nil, input.FinalScriptSig); err != nil {
+			return err
+		}
+	}
+	if input.FinalScriptWitness != nil {
+		if err := writeEntry(
+			w, inputFinalScriptWitness, nil, serializeWitness(input.FinalScriptWitness)); err != nil {
+			return err
+		}
+	}
+	if err := writeUnknowns(w, input.Unknowns); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{0})
+	return errp.WithStack(err)
+}
+
+func (output *Output) serialize(w io.Writer) error {
+	if output.RedeemScript != nil {
+		if err := writeEntry(w, outputRedeemScript, nil, output.RedeemScript); err != nil {
+			return err
+		}
+	}
+	if output.WitnessScript != nil {
+		if err := writeEntry(w, outputWitnessScript, nil, output.WitnessScript); err != nil {
+			return err
+		}
+	}
+	if err := writeBip32Derivations(w, outputBip32Derivation, output.Bip32Derivation); err != nil {
+		return err
+	}
+	if err := writeUnknowns(w, output.Unknowns); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{0})
+	return errp.WithStack(err)
+}
+
+// PartialSig returns the signature of the given public key, or nil if there is none.
+func (input *Input) PartialSig(pubKey []byte) *PartialSig {
+	for _, partialSig := range input.PartialSigs {
+		if bytes.Equal(partialSig.PubKey, pubKey) {
+			return partialSig
+		}
+	}
+	return nil
+}
+
+// AddPartialSig adds the signature of the given public key, unless it is already present.
+func (input *Input) AddPartialSig(pubKey []byte, signature []byte) {
+	if input.PartialSig(pubKey) != nil {
+		return
+	}
+	input.PartialSigs = append(input.PartialSigs, &PartialSig{PubKey: pubKey, Signature: signature})
+	sort.Slice(input.PartialSigs, func(i, j int) bool {
+		return bytes.Compare(input.PartialSigs[i].PubKey, input.PartialSigs[j].PubKey) < 0
+	})
+}
+
+// Combine adds the signatures and the missing information of the other packet, which must be a
+// packet of the same transaction.
+func (packet *Packet) Combine(other *Packet) error {
+	if packet.UnsignedTx.TxHash() != other.UnsignedTx.TxHash() {
+		return errp.New("The PSBTs are of different transactions.")
+	}
+	for index, input := range packet.Inputs {
+		otherInput := other.Inputs[index]
+		if input.NonWitnessUtxo == nil {
+			input.NonWitnessUtxo = otherInput.NonWitnessUtxo
+		}
+		if input.WitnessUtxo == nil {
+			input.WitnessUtxo = otherInput.WitnessUtxo
+		}
+		for _, partialSig := range otherInput.PartialSigs {
+			input.AddPartialSig(partialSig.PubKey, partialSig.Signature)
+		}
+		if input.SighashType == 0 {
+			input.SighashType = otherInput.SighashType
+		}
+		if input.RedeemScript == nil {
+			input.RedeemScript = otherInput.RedeemScript
+		}
+		if input.WitnessScript == nil {
+			input.WitnessScript = otherInput.WitnessScript
+		}
+		if len(input.Bip32Derivation) == 0 {
+			input.Bip32Derivation = otherInput.Bip32Derivation
+		}
+		if input.FinalScriptSig == nil {
+			input.FinalScriptSig = otherInput.FinalScriptSig
+		}
+		if input.FinalScriptWitness == nil {
+			input.FinalScriptWitness = otherInput.FinalScriptWitness
+		}
+	}
+	for index, output := range packet.Outputs {
+		otherOutput := other.Outputs[index]
+		if output.RedeemScript == nil {
+			output.RedeemScript = otherOutput.RedeemScript
+		}
+		if output.WitnessScript == nil {
+			output.WitnessScript = otherOutput.WitnessScript
+		}
+		if len(output.Bip32Derivation) == 0 {
+			output.Bip32Derivation = otherOutput.Bip32Derivation
+		}
+	}
+	return nil
+}
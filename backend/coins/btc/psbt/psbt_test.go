@@ -0,0 +1,138 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func pubKey(b byte) []byte {
+	key := bytes.Repeat([]byte{b}, 33)
+	key[0] = 0x02
+	return key
+}
+
+func testTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 1), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(100000, []byte{txscript.OP_0, 0x14}))
+	return tx
+}
+
+func TestRoundtrip(t *testing.T) {
+	packet, err := NewFromUnsignedTx(testTx())
+	require.NoError(t, err)
+	input := packet.Inputs[0]
+	input.WitnessUtxo = wire.NewTxOut(200000, []byte{txscript.OP_0, 0x20})
+	input.SighashType = txscript.SigHashAll
+	input.WitnessScript = []byte{txscript.OP_1}
+	input.RedeemScript = []byte{txscript.OP_2}
+	input.Bip32Derivation = []*Bip32Derivation{
+		{PubKey: pubKey(1), Fingerprint: [4]byte{1, 2, 3, 4}, Path: []uint32{0, 5}},
+	}
+	input.AddPartialSig(pubKey(2), []byte{0x30, 0x01})
+	input.AddPartialSig(pubKey(1), []byte{0x30, 0x02})
+	packet.Outputs[0].WitnessScript = []byte{txscript.OP_3}
+	packet.Unknowns = []*Unknown{{Key: []byte{0x70, 1}, Value: []byte{2}}}
+
+	// The signatures are sorted by public key.
+	require.Equal(t, pubKey(1), input.PartialSigs[0].PubKey)
+	// A second signature of the same key is ignored.
+	input.AddPartialSig(pubKey(1), []byte{0x30, 0x03})
+	require.Len(t, input.PartialSigs, 2)
+	require.Equal(t, []byte{0x30, 0x02}, input.PartialSig(pubKey(1)).Signature)
+
+	encoded, err := packet.B64Encode()
+	require.NoError(t, err)
+	decoded, err := NewFromBase64(encoded)
+	require.NoError(t, err)
+	require.Equal(t, packet.UnsignedTx.TxHash(), decoded.UnsignedTx.TxHash())
+	require.Equal(t, packet.Inputs, decoded.Inputs)
+	require.Equal(t, packet.Outputs, decoded.Outputs)
+	require.Equal(t, packet.Unknowns, decoded.Unknowns)
+	reencoded, err := decoded.B64Encode()
+	require.NoError(t, err)
+	require.Equal(t, encoded, reencoded)
+}
+
+func TestNewFromUnsignedTxSigned(t *testing.T) {
+	tx := testTx()
+	tx.TxIn[0].Witness = wire.TxWitness{{1}}
+	_, err := NewFromUnsignedTx(tx)
+	require.Error(t, err)
+}
+
+func TestNewFromRawBytesInvalid(t *testing.T) {
+	_, err := NewFromBase64("not base64!")
+	require.Error(t, err)
+	_, err = NewFromRawBytes(bytes.NewReader([]byte("psbt")))
+	require.Error(t, err)
+
+	packet, err := NewFromUnsignedTx(testTx())
+	require.NoError(t, err)
+	var buffer bytes.Buffer
+	require.NoError(t, packet.Serialize(&buffer))
+	serialized := buffer.Bytes()
+
+	// Truncated.
+	_, err = NewFromRawBytes(bytes.NewReader(serialized[:len(serialized)-1]))
+	require.Error(t, err)
+
+	// Duplicate key in the global map.
+	var txBuffer bytes.Buffer
+	require.NoError(t, testTx().SerializeNoWitness(&txBuffer))
+	var duplicate bytes.Buffer
+	duplicate.Write(magic)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, writeEntry(&duplicate, globalUnsignedTx, nil, txBuffer.Bytes()))
+	}
+	duplicate.WriteByte(0)
+	_, err = NewFromRawBytes(bytes.NewReader(duplicate.Bytes()))
+	require.Error(t, err)
+
+	// Missing unsigned transaction.
+	_, err = NewFromBase64(base64.StdEncoding.EncodeToString(append(magic, 0)))
+	require.Error(t, err)
+}
+
+func TestCombine(t *testing.T) {
+	first, err := NewFromUnsignedTx(testTx())
+	require.NoError(t, err)
+	first.Inputs[0].AddPartialSig(pubKey(1), []byte{0x30, 0x01})
+	second, err := NewFromUnsignedTx(testTx())
+	require.NoError(t, err)
+	second.Inputs[0].AddPartialSig(pubKey(2), []byte{0x30, 0x02})
+	second.Inputs[0].WitnessScript = []byte{txscript.OP_1}
+	second.Inputs[1].AddPartialSig(pubKey(3), []byte{0x30, 0x03})
+
+	require.NoError(t, first.Combine(second))
+	require.Len(t, first.Inputs[0].PartialSigs, 2)
+	require.Equal(t, []byte{txscript.OP_1}, first.Inputs[0].WitnessScript)
+	require.NotNil(t, first.Inputs[1].PartialSig(pubKey(3)))
+
+	otherTx := testTx()
+	otherTx.TxOut[0].Value++
+	other, err := NewFromUnsignedTx(otherTx)
+	require.NoError(t, err)
+	require.Error(t, first.Combine(other))
+}
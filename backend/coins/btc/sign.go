@@ -22,6 +22,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
@@ -33,11 +34,30 @@ type ProposedTransaction struct {
 	TXProposal      *maketx.TxProposal
 	PreviousOutputs map[wire.OutPoint]*transactions.SpendableOutput
 	GetAddress      func(blockchain.ScriptHashHex) *addresses.AccountAddress
-	// Signatures collects the signatures (signatures[transactionInput][cosignerIndex]).
+	// Signatures collects the signatures (signatures[transactionInput][cosignerIndex]). The
+	// signatures of P2TR inputs are Schnorr signatures, see taproot.Sign().
 	Signatures [][]*btcec.Signature
 	SigHashes  *txscript.TxSigHashes
 }
 
+// SpentOutputs returns the outputs spent by the inputs of the transaction, in the order of the
+// inputs. They are needed to compute the signature hashes of taproot inputs.
+func (proposedTransaction *ProposedTransaction) SpentOutputs() []*wire.TxOut {
+	return spentOutputs(proposedTransaction.TXProposal.Transaction, proposedTransaction.PreviousOutputs)
+}
+
+func spentOutputs(
+	transaction *wire.MsgTx, previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+) []*wire.TxOut {
+	result := make([]*wire.TxOut, len(transaction.TxIn))
+	for index, txIn := range transaction.TxIn {
+		if spentOutput, ok := previousOutputs[txIn.PreviousOutPoint]; ok {
+			result[index] = spentOutput.TxOut
+		}
+	}
+	return result
+}
+
 // SignTransaction signs all inputs. It assumes all outputs spent belong to this
 // wallet. previousOutputs must contain all outputs which are spent by the transaction.
 func SignTransaction(
@@ -56,8 +76,8 @@ func SignTransaction(
 	}
 
 	for i := range proposedTransaction.Signatures {
-		// TODO: Replace count with configuration.NumberOfSigners()
-		proposedTransaction.Signatures[i] = make([]*btcec.Signature, keystores.Count())
+		proposedTransaction.Signatures[i] = make(
+			[]*btcec.Signature, txProposal.AccountConfiguration.NumberOfSigners())
 	}
 
 	if err := keystores.SignTransaction(proposedTransaction); err != nil {
@@ -90,6 +110,21 @@ func txValidityCheck(transaction *wire.MsgTx, previousOutputs map[wire.OutPoint]
 		if !ok {
 			return errp.New("There needs to be exactly one output being spent per input!")
 		}
+		// The script engine of the btcd version used does not validate taproot spends yet.
+		if taproot.IsPayToTaproot(spentOutput.PkScript) {
+			if len(txIn.Witness) != 1 {
+				return errp.New("A taproot key path spend needs exactly one witness item.")
+			}
+			signatureHash, err := taproot.SigHash(
+				transaction, index, spentOutputs(transaction, previousOutputs))
+			if err != nil {
+				return err
+			}
+			if !taproot.Verify(spentOutput.PkScript[2:], signatureHash, txIn.Witness[0]) {
+				return errp.New("Invalid taproot signature.")
+			}
+			continue
+		}
 		engine, err := txscript.NewEngine(spentOutput.PkScript, transaction, index,
 			txscript.StandardVerifyFlags, nil, sigHashes, spentOutput.Value)
 		if err != nil {
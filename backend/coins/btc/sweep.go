@@ -0,0 +1,267 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// sweepScript is an output script of a swept private key.
+type sweepScript struct {
+	scriptType signing.ScriptType
+	pkScript   []byte
+	// redeemScript is the witness program of a P2WPKH-P2SH output, or nil for other script types.
+	redeemScript []byte
+}
+
+// sweepScripts returns the output scripts which can be spent with the given key. Uncompressed keys
+// can only be used in P2PKH outputs.
+func sweepScripts(wif *btcutil.WIF, net *chaincfg.Params) ([]*sweepScript, error) {
+	publicKeyHash := btcutil.Hash160(wif.SerializePubKey())
+	p2pkh, err := btcutil.NewAddressPubKeyHash(publicKeyHash, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2pkhScript, err := txscript.PayToAddrScript(p2pkh)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	scripts := []*sweepScript{{scriptType: signing.ScriptTypeP2PKH, pkScript: p2pkhScript}}
+	if !wif.CompressPubKey {
+		return scripts, nil
+	}
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(publicKeyHash, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2wpkhScript, err := txscript.PayToAddrScript(p2wpkh)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2sh, err := btcutil.NewAddressScriptHash(p2wpkhScript, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2shScript, err := txscript.PayToAddrScript(p2sh)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return append(scripts,
+		&sweepScript{scriptType: signing.ScriptTypeP2WPKH, pkScript: p2wpkhScript},
+		&sweepScript{
+			scriptType:   signing.ScriptTypeP2WPKHP2SH,
+			pkScript:     p2shScript,
+			redeemScript: p2wpkhScript,
+		},
+	), nil
+}
+
+// sizes returns the maximum sizes of the signature script and the witness spending the script.
+func (script *sweepScript) sizes(publicKeySize int) (int, int) {
+	// <serialized sig> <serialized pubkey>, the signature is at most 72 bytes plus the SIGHASH op.
+	const signatureSize = 73
+	witnessSize := wire.VarIntSerializeSize(2) +
+		wire.VarIntSerializeSize(signatureSize) + signatureSize +
+		wire.VarIntSerializeSize(uint64(publicKeySize)) + publicKeySize
+	switch script.scriptType {
+	case signing.ScriptTypeP2PKH:
+		return 1 + signatureSize + 1 + publicKeySize, 0
+	case signing.ScriptTypeP2WPKHP2SH:
+		return 1 + len(script.redeemScript), witnessSize
+	default:
+		return 0, witnessSize
+	}
+}
+
+// parseSweepKey decodes a private key in the WIF format, or a BIP38 encrypted key if a passphrase
+// is given.
+func parseSweepKey(privateKey string, passphrase string, net *chaincfg.Params) (*btcutil.WIF, error) {
+	privateKey = strings.TrimSpace(privateKey)
+	if passphrase != "" {
+		return DecryptBIP38(privateKey, passphrase, net)
+	}
+	wif, err := btcutil.DecodeWIF(privateKey)
+	if err != nil {
+		return nil, errp.New("Invalid private key.")
+	}
+	if !wif.IsForNet(net) {
+		return nil, errp.New("The private key is for a different network.")
+	}
+	return wif, nil
+}
+
+// newSweepTx finds the unspent outputs of the given private key and creates a transaction sending
+// them to an unused receive address of the account.
+func (account *Account) newSweepTx(
+	wif *btcutil.WIF,
+	feeTargetCode accounts.FeeTargetCode,
+) (*maketx.TxProposal, map[wire.OutPoint]*sweepScript, map[wire.OutPoint]*transactions.SpendableOutput, error) {
+	feeRatePerKb := account.feeRatePerKb(feeTargetCode)
+	if feeRatePerKb == nil {
+		return nil, nil, nil, errp.New("Fee could not be estimated")
+	}
+	scripts, err := sweepScripts(wif, account.coin.Net())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	inputs := []*maketx.SweepInput{}
+	inputScripts := map[wire.OutPoint]*sweepScript{}
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for _, script := range scripts {
+		utxos, err := account.blockchain.ScriptHashListUnspent(
+			blockchain.ScriptHashHex(chainhash.HashH(script.pkScript).String()))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, utxo := range utxos {
+			outPoint := wire.OutPoint{Hash: utxo.TXHash.Hash(), Index: uint32(utxo.TXPos)}
+			txOut := wire.NewTxOut(utxo.Value, script.pkScript)
+			sigScriptSize, witnessSize := script.sizes(len(wif.SerializePubKey()))
+			inputs = append(inputs, &maketx.SweepInput{
+				OutPoint:      outPoint,
+				TxOut:         txOut,
+				SigScriptSize: sigScriptSize,
+				WitnessSize:   witnessSize,
+			})
+			inputScripts[outPoint] = script
+			previousOutputs[outPoint] = &transactions.SpendableOutput{TxOut: txOut}
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, nil, nil, errp.New("There are no funds on this private key.")
+	}
+	var outputAddress *addresses.AccountAddress
+	func() {
+		defer account.RLock()()
+		outputAddress = account.receiveAddresses.GetUnused()[0]
+	}()
+	txProposal, err := maketx.NewTxSweep(
+		account.coin,
+		inputs,
+		outputAddress,
+		*feeRatePerKb,
+		account.log,
+	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return txProposal, inputScripts, previousOutputs, nil
+}
+
+// SweepProposal returns the amount which is received and the fee paid when sweeping the funds of a
+// private key (WIF) or a BIP38 encrypted private key with its passphrase into the account.
+func (account *Account) SweepProposal(
+	privateKey string,
+	passphrase string,
+	feeTargetCode accounts.FeeTargetCode,
+) (coin.Amount, coin.Amount, error) {
+	wif, err := parseSweepKey(privateKey, passphrase, account.coin.Net())
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	defer wif.PrivKey.D.SetInt64(0)
+	txProposal, _, _, err := account.newSweepTx(wif, feeTargetCode)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		coin.NewAmountFromInt64(int64(txProposal.Fee)), nil
+}
+
+// Sweep sends all funds of a private key (WIF) or a BIP38 encrypted private key with its
+// passphrase to an unused receive address of the account. The key is not on a keystore, so the
+// transaction is signed in software. The key is only kept in memory during the call and never
+// persisted.
+func (account *Account) Sweep(
+	privateKey string,
+	passphrase string,
+	feeTargetCode accounts.FeeTargetCode,
+) error {
+	account.log.Info("Sweeping a private key")
+	wif, err := parseSweepKey(privateKey, passphrase, account.coin.Net())
+	if err != nil {
+		return err
+	}
+	defer wif.PrivKey.D.SetInt64(0)
+	txProposal, inputScripts, previousOutputs, err := account.newSweepTx(wif, feeTargetCode)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to create transaction")
+	}
+	transaction := txProposal.Transaction
+	sigHashes := txscript.NewTxSigHashes(transaction)
+	for index, txIn := range transaction.TxIn {
+		script := inputScripts[txIn.PreviousOutPoint]
+		spentOutput := previousOutputs[txIn.PreviousOutPoint]
+		if err := signSweepInput(
+			transaction, sigHashes, index, spentOutput.TxOut, script, wif); err != nil {
+			return errp.WithMessage(err, "Failed to sign transaction")
+		}
+	}
+	if err := txValidityCheck(transaction, previousOutputs, sigHashes); err != nil {
+		return errp.WithMessage(err, "Failed to sign transaction")
+	}
+	account.log.Info("Signed sweep transaction is broadcasted")
+	return account.blockchain.TransactionBroadcast(transaction)
+}
+
+func signSweepInput(
+	transaction *wire.MsgTx,
+	sigHashes *txscript.TxSigHashes,
+	index int,
+	spentOutput *wire.TxOut,
+	script *sweepScript,
+	wif *btcutil.WIF,
+) error {
+	txIn := transaction.TxIn[index]
+	if script.scriptType == signing.ScriptTypeP2PKH {
+		signatureScript, err := txscript.SignatureScript(
+			transaction, index, script.pkScript, txscript.SigHashAll, wif.PrivKey, wif.CompressPubKey)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		txIn.SignatureScript = signatureScript
+		return nil
+	}
+	witnessProgram := script.pkScript
+	if script.redeemScript != nil {
+		witnessProgram = script.redeemScript
+		signatureScript, err := txscript.NewScriptBuilder().AddData(script.redeemScript).Script()
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		txIn.SignatureScript = signatureScript
+	}
+	witness, err := txscript.WitnessSignature(transaction, sigHashes, index, spentOutput.Value,
+		witnessProgram, txscript.SigHashAll, wif.PrivKey, true)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	txIn.Witness = witness
+	return nil
+}
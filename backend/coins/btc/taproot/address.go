@@ -0,0 +1,172 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// See BIP350.
+const (
+	bech32Charset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32mConstant = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]>>5)
+	}
+	result = append(result, 0)
+	for i := 0; i < len(hrp); i++ {
+		result = append(result, hrp[i]&31)
+	}
+	return result
+}
+
+// encodeBech32m encodes the 5 bit data with the bech32m checksum.
+func encodeBech32m(hrp string, data []byte) string {
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ bech32mConstant
+	var result strings.Builder
+	result.WriteString(hrp)
+	result.WriteString("1")
+	for _, value := range data {
+		result.WriteByte(bech32Charset[value])
+	}
+	for i := 0; i < 6; i++ {
+		result.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return result.String()
+}
+
+// decodeBech32m decodes a bech32m string into its human readable part and 5 bit data.
+func decodeBech32m(encoded string) (string, []byte, error) {
+	if len(encoded) > 90 {
+		return "", nil, errp.New("The address is too long.")
+	}
+	for i := 0; i < len(encoded); i++ {
+		if encoded[i] < 33 || encoded[i] > 126 {
+			return "", nil, errp.Newf("Invalid bech32m character %q.", encoded[i])
+		}
+	}
+	if strings.ToLower(encoded) != encoded && strings.ToUpper(encoded) != encoded {
+		return "", nil, errp.New("The address has mixed case.")
+	}
+	encoded = strings.ToLower(encoded)
+	separator := strings.LastIndex(encoded, "1")
+	if separator < 1 || separator+7 > len(encoded) {
+		return "", nil, errp.New("Invalid bech32m separator position.")
+	}
+	hrp := encoded[:separator]
+	data := make([]byte, 0, len(encoded)-separator-1)
+	for _, char := range encoded[separator+1:] {
+		value := strings.IndexRune(bech32Charset, char)
+		if value == -1 {
+			return "", nil, errp.Newf("Invalid bech32m character %q.", char)
+		}
+		data = append(data, byte(value))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != bech32mConstant {
+		return "", nil, errp.New("Invalid bech32m checksum.")
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+// Address is a segwit v1 (taproot) address paying to a 32 byte output key. It implements
+// btcutil.Address, which does not know about taproot in the btcutil version used.
+type Address struct {
+	outputKey []byte
+	hrp       string
+}
+
+// NewAddress creates a taproot address paying to the given 32 byte x-only output key.
+func NewAddress(outputKey []byte, net *chaincfg.Params) (*Address, error) {
+	if len(outputKey) != 32 {
+		return nil, errp.New("A taproot output key has 32 bytes.")
+	}
+	return &Address{outputKey: outputKey, hrp: net.Bech32HRPSegwit}, nil
+}
+
+// DecodeAddress decodes a bech32m encoded taproot address of the given net.
+func DecodeAddress(address string, net *chaincfg.Params) (*Address, error) {
+	hrp, data, err := decodeBech32m(address)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != net.Bech32HRPSegwit {
+		return nil, errp.New("The address is for a different network.")
+	}
+	if len(data) == 0 || data[0] != 1 {
+		return nil, errp.New("Only segwit v1 addresses are supported.")
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewAddress(program, net)
+}
+
+// EncodeAddress implements btcutil.Address.
+func (address *Address) EncodeAddress() string {
+	program, err := bech32.ConvertBits(address.outputKey, 8, 5, true)
+	if err != nil {
+		panic(err)
+	}
+	return encodeBech32m(address.hrp, append([]byte{1}, program...))
+}
+
+// String implements btcutil.Address.
+func (address *Address) String() string {
+	return address.EncodeAddress()
+}
+
+// ScriptAddress implements btcutil.Address. It returns the 32 byte output key.
+func (address *Address) ScriptAddress() []byte {
+	return address.outputKey
+}
+
+// IsForNet implements btcutil.Address.
+func (address *Address) IsForNet(net *chaincfg.Params) bool {
+	return address.hrp == net.Bech32HRPSegwit
+}
+
+// PkScript returns the pubkey script paying to this address.
+func (address *Address) PkScript() []byte {
+	script, err := PayToOutputKeyScript(address.outputKey)
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
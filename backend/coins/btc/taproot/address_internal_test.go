@@ -0,0 +1,70 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of BIP350.
+func TestBech32m(t *testing.T) {
+	valid := []string{
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	}
+	for _, encoded := range valid {
+		t.Run(encoded, func(t *testing.T) {
+			hrp, data, err := decodeBech32m(encoded)
+			require.NoError(t, err)
+			require.Equal(t, strings.ToLower(encoded), encodeBech32m(hrp, data))
+
+			// Flipping a bit of the first data character invalidates the checksum.
+			separator := strings.LastIndex(encoded, "1")
+			flipped := encoded[:separator+1] + string(encoded[separator+1]^1) + encoded[separator+2:]
+			_, _, err = decodeBech32m(flipped)
+			require.Error(t, err)
+		})
+	}
+
+	invalid := []string{
+		"\x201xj0phk", // HRP character out of range
+		"\x7f1g6xzxy", // HRP character out of range
+		"\x801vctc34", // HRP character out of range
+		// overall max length exceeded
+		"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4",
+		"qyrz8wqd2c9m",  // no separator character
+		"1qyrz8wqd2c9m", // empty HRP
+		"y1b0jsk6g",     // invalid data character
+		"lt1igcx5c0",    // invalid data character
+		"in1muywd",      // too short checksum
+		"mm1crxm3i",     // invalid character in checksum
+		"au1s5cgom",     // invalid character in checksum
+		"M1VUXWEZ",      // checksum calculated with uppercase form of HRP
+		"16plkw9",       // empty HRP
+		"1p2gdwpf",      // empty HRP
+	}
+	for _, encoded := range invalid {
+		_, _, err := decodeBech32m(encoded)
+		require.Error(t, err, encoded)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taproot implements what is needed for BIP86 single key taproot outputs: Schnorr
+// signatures (BIP340), the key tweak and the key path signature hash (BIP341) and bech32m
+// addresses (BIP350).
+package taproot
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// SignatureSize is the size of a serialized Schnorr signature.
+const SignatureSize = 64
+
+// TaggedHash computes the tagged hash of BIP340: sha256(sha256(tag) || sha256(tag) || msgs).
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hash := sha256.New()
+	_, _ = hash.Write(tagHash[:])
+	_, _ = hash.Write(tagHash[:])
+	for _, msg := range msgs {
+		_, _ = hash.Write(msg)
+	}
+	return hash.Sum(nil)
+}
+
+// bytes32 serializes the number as 32 bytes big endian.
+func bytes32(number *big.Int) []byte {
+	result := make([]byte, 32)
+	numberBytes := number.Bytes()
+	copy(result[32-len(numberBytes):], numberBytes)
+	return result
+}
+
+// liftX returns the point with the given x coordinate and an even y coordinate.
+func liftX(x *big.Int) (*btcec.PublicKey, error) {
+	curve := btcec.S256()
+	if x.Cmp(curve.P) >= 0 {
+		return nil, errp.New("The x coordinate is not in the field.")
+	}
+	// y^2 = x^3 + 7
+	c := new(big.Int).Exp(x, big.NewInt(3), curve.P)
+	c.Add(c, curve.B)
+	c.Mod(c, curve.P)
+	exponent := new(big.Int).Add(curve.P, big.NewInt(1))
+	exponent.Rsh(exponent, 2)
+	y := new(big.Int).Exp(c, exponent, curve.P)
+	if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(c) != 0 {
+		return nil, errp.New("The x coordinate is not on the curve.")
+	}
+	if y.Bit(0) != 0 {
+		y.Sub(curve.P, y)
+	}
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// ParsePublicKey parses a 32 byte x-only public key.
+func ParsePublicKey(publicKey []byte) (*btcec.PublicKey, error) {
+	if len(publicKey) != 32 {
+		return nil, errp.New("An x-only public key has 32 bytes.")
+	}
+	return liftX(new(big.Int).SetBytes(publicKey))
+}
+
+// SerializePublicKey returns the 32 byte x-only serialization of the public key.
+func SerializePublicKey(publicKey *btcec.PublicKey) []byte {
+	return bytes32(publicKey.X)
+}
+
+// Sign creates a BIP340 Schnorr signature of the message, usually a 32 byte hash. auxRand are 32
+// bytes of fresh randomness. The signature is returned in a btcec.Signature whose R is the x coordinate of the
+// nonce point, so that it can be passed around like ECDSA signatures. Use SerializeSignature() to
+// get the 64 byte encoding.
+func Sign(privateKey *btcec.PrivateKey, message []byte, auxRand []byte) (*btcec.Signature, error) {
+	curve := btcec.S256()
+	if len(auxRand) != 32 {
+		return nil, errp.New("The auxiliary randomness has to be 32 bytes.")
+	}
+	d := new(big.Int).Set(privateKey.D)
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, errp.New("Invalid private key.")
+	}
+	publicKeyX, publicKeyY := curve.ScalarBaseMult(bytes32(d))
+	if publicKeyY.Bit(0) != 0 {
+		d.Sub(curve.N, d)
+	}
+	t := TaggedHash("BIP0340/aux", auxRand)
+	for i, b := range bytes32(d) {
+		t[i] ^= b
+	}
+	publicKeyBytes := bytes32(publicKeyX)
+	k := new(big.Int).SetBytes(TaggedHash("BIP0340/nonce", t, publicKeyBytes, message))
+	k.Mod(k, curve.N)
+	if k.Sign() == 0 {
+		return nil, errp.New("Invalid nonce.")
+	}
+	rX, rY := curve.ScalarBaseMult(bytes32(k))
+	if rY.Bit(0) != 0 {
+		k.Sub(curve.N, k)
+	}
+	rBytes := bytes32(rX)
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", rBytes, publicKeyBytes, message))
+	e.Mod(e, curve.N)
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, curve.N)
+	signature := &btcec.Signature{R: rX, S: s}
+	if !Verify(publicKeyBytes, message, SerializeSignature(signature)) {
+		return nil, errp.New("The created signature is invalid.")
+	}
+	return signature, nil
+}
+
+// SerializeSignature returns the 64 byte encoding of a signature created by Sign().
+func SerializeSignature(signature *btcec.Signature) []byte {
+	return append(bytes32(signature.R), bytes32(signature.S)...)
+}
+
+// Verify verifies a 64 byte BIP340 Schnorr signature of the message by the given x-only public
+// key.
+func Verify(publicKey []byte, message []byte, signature []byte) bool {
+	curve := btcec.S256()
+	if len(signature) != SignatureSize {
+		return false
+	}
+	point, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", signature[:32], publicKey, message))
+	e.Mod(e, curve.N)
+	// R = s*G - e*P = s*G + (n-e)*P
+	sGX, sGY := curve.ScalarBaseMult(bytes32(s))
+	negE := new(big.Int).Sub(curve.N, e)
+	ePX, ePY := curve.ScalarMult(point.X, point.Y, bytes32(negE))
+	rX, rY := curve.Add(sGX, sGY, ePX, ePY)
+	if rX.Sign() == 0 && rY.Sign() == 0 {
+		return false
+	}
+	return rY.Bit(0) == 0 && rX.Cmp(r) == 0
+}
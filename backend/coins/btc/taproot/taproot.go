@@ -0,0 +1,134 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// tweak returns the BIP86 tweak of the internal key, which commits to no script path.
+func tweak(internalKey *btcec.PublicKey) (*big.Int, error) {
+	t := new(big.Int).SetBytes(TaggedHash("TapTweak", SerializePublicKey(internalKey)))
+	if t.Cmp(btcec.S256().N) >= 0 {
+		return nil, errp.New("The tweak is out of range.")
+	}
+	return t, nil
+}
+
+// OutputKey returns the output key of a BIP86 taproot output with the given internal key, which
+// is the internal key tweaked with a commitment to no script path.
+func OutputKey(internalKey *btcec.PublicKey) (*btcec.PublicKey, error) {
+	curve := btcec.S256()
+	point, err := liftX(internalKey.X)
+	if err != nil {
+		return nil, err
+	}
+	t, err := tweak(internalKey)
+	if err != nil {
+		return nil, err
+	}
+	tX, tY := curve.ScalarBaseMult(bytes32(t))
+	x, y := curve.Add(point.X, point.Y, tX, tY)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, errp.New("The output key is the point at infinity.")
+	}
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// TweakPrivateKey returns the private key of the output key of a BIP86 taproot output whose
+// internal key belongs to the given private key.
+func TweakPrivateKey(privateKey *btcec.PrivateKey) (*btcec.PrivateKey, error) {
+	curve := btcec.S256()
+	d := new(big.Int).Set(privateKey.D)
+	if privateKey.PubKey().Y.Bit(0) != 0 {
+		d.Sub(curve.N, d)
+	}
+	t, err := tweak(privateKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	d.Add(d, t)
+	d.Mod(d, curve.N)
+	if d.Sign() == 0 {
+		return nil, errp.New("The tweaked private key is zero.")
+	}
+	tweaked, _ := btcec.PrivKeyFromBytes(curve, bytes32(d))
+	return tweaked, nil
+}
+
+// PayToOutputKeyScript returns the pubkey script of a taproot output: OP_1 <32 byte output key>.
+func PayToOutputKeyScript(outputKey []byte) ([]byte, error) {
+	if len(outputKey) != 32 {
+		return nil, errp.New("A taproot output key has 32 bytes.")
+	}
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_1).AddData(outputKey).Script()
+}
+
+// IsPayToTaproot returns whether the pubkey script is a segwit v1 output with a 32 byte program.
+func IsPayToTaproot(pkScript []byte) bool {
+	return len(pkScript) == 34 && pkScript[0] == txscript.OP_1 && pkScript[1] == txscript.OP_DATA_32
+}
+
+func writeTxOut(buffer *bytes.Buffer, txOut *wire.TxOut) {
+	_ = binary.Write(buffer, binary.LittleEndian, txOut.Value)
+	_ = wire.WriteVarBytes(buffer, 0, txOut.PkScript)
+}
+
+// SigHash computes the BIP341 signature hash of a key path spend of the input with the given
+// index, with SIGHASH_DEFAULT and without annex. previousOutputs are the outputs spent by all
+// inputs of the transaction, in the order of the inputs.
+func SigHash(transaction *wire.MsgTx, index int, previousOutputs []*wire.TxOut) ([]byte, error) {
+	if len(previousOutputs) != len(transaction.TxIn) {
+		return nil, errp.New("The outputs spent by all inputs are needed.")
+	}
+	if index < 0 || index >= len(transaction.TxIn) {
+		return nil, errp.Newf("The transaction has no input %d.", index)
+	}
+	var prevouts, amounts, scriptPubKeys, sequences, outputs bytes.Buffer
+	for inputIndex, txIn := range transaction.TxIn {
+		_, _ = prevouts.Write(txIn.PreviousOutPoint.Hash[:])
+		_ = binary.Write(&prevouts, binary.LittleEndian, txIn.PreviousOutPoint.Index)
+		_ = binary.Write(&amounts, binary.LittleEndian, previousOutputs[inputIndex].Value)
+		_ = wire.WriteVarBytes(&scriptPubKeys, 0, previousOutputs[inputIndex].PkScript)
+		_ = binary.Write(&sequences, binary.LittleEndian, txIn.Sequence)
+	}
+	for _, txOut := range transaction.TxOut {
+		writeTxOut(&outputs, txOut)
+	}
+
+	var sigMsg bytes.Buffer
+	// Epoch and hash type SIGHASH_DEFAULT.
+	_, _ = sigMsg.Write([]byte{0x00, 0x00})
+	_ = binary.Write(&sigMsg, binary.LittleEndian, transaction.Version)
+	_ = binary.Write(&sigMsg, binary.LittleEndian, transaction.LockTime)
+	for _, data := range [][]byte{
+		prevouts.Bytes(), amounts.Bytes(), scriptPubKeys.Bytes(), sequences.Bytes(), outputs.Bytes(),
+	} {
+		hash := sha256.Sum256(data)
+		_, _ = sigMsg.Write(hash[:])
+	}
+	// Spend type: key path spend without annex.
+	_ = sigMsg.WriteByte(0x00)
+	_ = binary.Write(&sigMsg, binary.LittleEndian, uint32(index))
+	return TaggedHash("TapSighash", sigMsg.Bytes()), nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	decoded, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return decoded
+}
+
+// TestSchnorr runs the test vectors of BIP340 in testdata/bip-0340-test-vectors.csv.
+func TestSchnorr(t *testing.T) {
+	file, err := os.Open("testdata/bip-0340-test-vectors.csv")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, file.Close()) }()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"index", "secret key", "public key", "aux_rand", "message", "signature",
+		"verification result", "comment",
+	}, records[0])
+	require.Len(t, records, 20)
+	for _, record := range records[1:] {
+		record := record
+		t.Run(record[0], func(t *testing.T) {
+			publicKey := unhex(t, record[2])
+			message := unhex(t, record[4])
+			signature := unhex(t, record[5])
+			if record[1] != "" {
+				privateKey, derivedPublicKey := btcec.PrivKeyFromBytes(btcec.S256(), unhex(t, record[1]))
+				require.Equal(t, publicKey, taproot.SerializePublicKey(derivedPublicKey))
+				created, err := taproot.Sign(privateKey, message, unhex(t, record[3]))
+				require.NoError(t, err)
+				require.Equal(t, signature, taproot.SerializeSignature(created))
+			}
+			require.Equal(t, record[6] == "TRUE", taproot.Verify(publicKey, message, signature), record[7])
+		})
+	}
+}
+
+func TestAddress(t *testing.T) {
+	// Valid segwit addresses of BIP350. Only version 1 witness programs of 32 bytes are taproot
+	// outputs.
+	valid := []struct {
+		address  string
+		net      *chaincfg.Params
+		pkScript string
+	}{
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", &chaincfg.MainNetParams, ""},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", &chaincfg.TestNet3Params, ""},
+		{
+			"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y",
+			&chaincfg.MainNetParams, "",
+		},
+		{"BC1SW50QGDZ25J", &chaincfg.MainNetParams, ""},
+		{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", &chaincfg.MainNetParams, ""},
+		{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", &chaincfg.TestNet3Params, ""},
+		{
+			"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", &chaincfg.TestNet3Params,
+			"5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+		},
+		{
+			"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", &chaincfg.MainNetParams,
+			"512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+		},
+	}
+	for _, vector := range valid {
+		address, err := taproot.DecodeAddress(vector.address, vector.net)
+		if vector.pkScript == "" {
+			require.Error(t, err, vector.address)
+			continue
+		}
+		require.NoError(t, err, vector.address)
+		require.Equal(t, vector.address, address.EncodeAddress())
+		require.Equal(t, vector.pkScript, hex.EncodeToString(address.PkScript()))
+		require.True(t, taproot.IsPayToTaproot(address.PkScript()))
+		require.True(t, address.IsForNet(vector.net))
+	}
+
+	// Invalid segwit addresses of BIP350.
+	invalid := []struct {
+		address string
+		net     *chaincfg.Params
+	}{
+		// invalid human-readable part
+		{"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut", &chaincfg.MainNetParams},
+		// invalid checksums (bech32 instead of bech32m)
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", &chaincfg.MainNetParams},
+		{"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", &chaincfg.TestNet3Params},
+		{"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", &chaincfg.MainNetParams},
+		// invalid checksums (bech32m instead of bech32)
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", &chaincfg.MainNetParams},
+		{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", &chaincfg.TestNet3Params},
+		// invalid character in checksum
+		{"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", &chaincfg.MainNetParams},
+		// invalid witness version
+		{"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", &chaincfg.MainNetParams},
+		// invalid program length (1 byte)
+		{"bc1pw5dgrnzv", &chaincfg.MainNetParams},
+		// invalid program length (41 bytes)
+		{
+			"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav",
+			&chaincfg.MainNetParams,
+		},
+		// invalid program length for witness version 0 (per BIP141)
+		{"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", &chaincfg.MainNetParams},
+		// mixed case
+		{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq", &chaincfg.TestNet3Params},
+		// zero padding of more than 4 bits
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf", &chaincfg.MainNetParams},
+		// non-zero padding in 8-to-5 conversion
+		{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j", &chaincfg.TestNet3Params},
+		// empty data section
+		{"bc1gmk9yu", &chaincfg.MainNetParams},
+	}
+	for _, vector := range invalid {
+		_, err := taproot.DecodeAddress(vector.address, vector.net)
+		require.Error(t, err, vector.address)
+	}
+
+	// A valid address of a different network.
+	_, err := taproot.DecodeAddress(
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", &chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
+
+func TestBIP86(t *testing.T) {
+	// Test vectors of BIP86.
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon "+
+		"abandon abandon abandon about", "")
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	key := master
+	for _, index := range []uint32{
+		hdkeychain.HardenedKeyStart + 86, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart, 0, 0,
+	} {
+		key, err = key.Child(index)
+		require.NoError(t, err)
+	}
+	internalKey, err := key.ECPubKey()
+	require.NoError(t, err)
+	require.Equal(t, "cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115",
+		hex.EncodeToString(taproot.SerializePublicKey(internalKey)))
+	outputKey, err := taproot.OutputKey(internalKey)
+	require.NoError(t, err)
+	require.Equal(t, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+		hex.EncodeToString(taproot.SerializePublicKey(outputKey)))
+	address, err := taproot.NewAddress(taproot.SerializePublicKey(outputKey), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		address.EncodeAddress())
+
+	// The tweaked private key belongs to the output key.
+	privateKey, err := key.ECPrivKey()
+	require.NoError(t, err)
+	tweaked, err := taproot.TweakPrivateKey(privateKey)
+	require.NoError(t, err)
+	require.Equal(t, taproot.SerializePublicKey(outputKey), taproot.SerializePublicKey(tweaked.PubKey()))
+}
+
+func TestSigHash(t *testing.T) {
+	// Key path spend of a P2TR output from Bitcoin Core's taproot script assets tests, as shipped in
+	// btcd's txscript/data/taproot-ref/72b831f893332ad3733b0af04ea0e76cac65b5dc. The signature is
+	// the one Bitcoin Core produced, so it only verifies against the correct sighash.
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	require.NoError(t, transaction.Deserialize(bytes.NewReader(unhex(t,
+		"0200000002bcb2054607a921b3c6df992a9486776863b28485e731a805931b6feb14221acf3901000000294d73f3"+
+			"8bd9b9012d1e9d0bc9c34df9d487a1d5663f1b37dbd4a857a2bddcbe25f0d0c4f000000000428d778904fbaeb6"+
+			"000000000017a9148f07d0f98cfe0d6aff29ca20bcda3fa930839374875802000000000000160014619b982e9f"+
+			"6832d2edb1a1ee4e7656a8d72c65e75802000000000000160014deb4696df95e4685eae8f9ff2e77fc7edabbe2"+
+			"fc5802000000000000160014f19f1969da9e474444a7b8fc50ae71f46e1eb796f7b3ae3c"))))
+	previousOutputs := []*wire.TxOut{
+		wire.NewTxOut(7846103, unhex(t,
+			"5120b5149551dc0241ae0d4420d11e06c98ebd87b9a952c2fc2c5fa7ce9cbc250e4b")),
+		wire.NewTxOut(4310314, unhex(t,
+			"51202540f27e90740933c99d4f17ab2dfc6c82951cfb0b8674c83ad179cfbc247b89")),
+	}
+	const index = 1
+	signature := unhex(t,
+		"2c4f4c08e82cd2748b627f594356ee1770e152d3ed937afef341d5d1405729e9"+
+			"4dcfb2a411d61060992531f5176fcc33e0ffb407fb249880edbc638e48a7e26c")
+	outputKey := previousOutputs[index].PkScript[2:]
+
+	sigHash, err := taproot.SigHash(transaction, index, previousOutputs)
+	require.NoError(t, err)
+	require.Equal(t, "51580f76f98042408359637f3e63c88ae0c24fc10d84083311cda7a4f017d850",
+		hex.EncodeToString(sigHash))
+	require.True(t, taproot.Verify(outputKey, sigHash, signature))
+
+	// The sighash commits to the amounts of all spent outputs.
+	previousOutputs[0].Value++
+	sigHash, err = taproot.SigHash(transaction, index, previousOutputs)
+	require.NoError(t, err)
+	require.False(t, taproot.Verify(outputKey, sigHash, signature))
+}
+
+// keyPathSpend is a test case of Bitcoin Core's taproot script assets tests. The files in
+// testdata/taproot-ref are the cases spending a P2TR output with a single 64 byte signature, i.e.
+// a key path spend with SIGHASH_DEFAULT, as shipped in btcd's txscript/data/taproot-ref.
+type keyPathSpend struct {
+	Tx       string   `json:"tx"`
+	Prevouts []string `json:"prevouts"`
+	Index    int      `json:"index"`
+	Comment  string   `json:"comment"`
+	Success  struct {
+		Witness []string `json:"witness"`
+	} `json:"success"`
+	Failure *struct {
+		Witness []string `json:"witness"`
+	} `json:"failure"`
+}
+
+// TestKeyPathSpends checks that the valid signatures of the spends verify against SigHash, and
+// that the invalid ones do not.
+func TestKeyPathSpends(t *testing.T) {
+	filenames, err := filepath.Glob("testdata/taproot-ref/*")
+	require.NoError(t, err)
+	require.Len(t, filenames, 54)
+	for _, filename := range filenames {
+		filename := filename
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			content, err := ioutil.ReadFile(filename)
+			require.NoError(t, err)
+			var spend keyPathSpend
+			// The files are entries of a JSON array and end with a comma.
+			require.NoError(t, json.Unmarshal(bytes.TrimRight(bytes.TrimSpace(content), ","), &spend))
+
+			transaction := wire.NewMsgTx(wire.TxVersion)
+			require.NoError(t, transaction.DeserializeNoWitness(bytes.NewReader(unhex(t, spend.Tx))))
+			previousOutputs := make([]*wire.TxOut, len(spend.Prevouts))
+			for index, prevout := range spend.Prevouts {
+				previousOutputs[index] = readTxOut(t, unhex(t, prevout))
+			}
+			outputKey := previousOutputs[spend.Index].PkScript[2:]
+			sigHash, err := taproot.SigHash(transaction, spend.Index, previousOutputs)
+			require.NoError(t, err)
+			require.True(t,
+				taproot.Verify(outputKey, sigHash, unhex(t, spend.Success.Witness[0])), spend.Comment)
+			if spend.Failure != nil && len(spend.Failure.Witness) == 1 {
+				require.False(t,
+					taproot.Verify(outputKey, sigHash, unhex(t, spend.Failure.Witness[0])), spend.Comment)
+			}
+		})
+	}
+}
+
+// readTxOut deserializes a transaction output: the amount followed by the pubkey script.
+func readTxOut(t *testing.T, serialized []byte) *wire.TxOut {
+	reader := bytes.NewReader(serialized)
+	var value int64
+	require.NoError(t, binary.Read(reader, binary.LittleEndian, &value))
+	pkScript, err := wire.ReadVarBytes(reader, 0, wire.MaxMessagePayload, "pkScript")
+	require.NoError(t, err)
+	require.Zero(t, reader.Len())
+	return wire.NewTxOut(value, pkScript)
+}
@@ -19,11 +19,13 @@ import (
 
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts/errors"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
@@ -32,6 +34,30 @@ import (
 // unitSatoshi is 1 BTC (default unit) in Satoshi.
 const unitSatoshi = 1e8
 
+// payToAddrScript returns the pubkey script paying to the address, including taproot addresses,
+// which txscript does not know about.
+func payToAddrScript(address btcutil.Address) ([]byte, error) {
+	if taprootAddress, ok := address.(*taproot.Address); ok {
+		return taprootAddress.PkScript(), nil
+	}
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return pkScript, nil
+}
+
+// feeRatePerKb returns the fee rate of the fee target with the given code, or nil if the fee target
+// does not exist or its fee rate has not been estimated yet.
+func (account *Account) feeRatePerKb(feeTargetCode accounts.FeeTargetCode) *btcutil.Amount {
+	for _, feeTarget := range account.feeTargets {
+		if feeTarget.code == feeTargetCode {
+			return feeTarget.feeRatePerKb
+		}
+	}
+	return nil
+}
+
 // newTx creates a new tx to the given recipient address. It also returns a set of used account
 // outputs, which contains all outputs that spent in the tx. Those are needed to be able to sign the
 // transaction. selectedUTXOs restricts the available coins; if empty, no restriction is applied and
@@ -51,20 +77,14 @@ func (account *Account) newTx(
 		return nil, nil, err
 	}
 
-	var feeTarget *FeeTarget
-	for _, target := range account.feeTargets {
-		if target.code == feeTargetCode {
-			feeTarget = target
-			break
-		}
-	}
-	if feeTarget == nil || feeTarget.feeRatePerKb == nil {
+	feeRatePerKb := account.feeRatePerKb(feeTargetCode)
+	if feeRatePerKb == nil {
 		return nil, nil, errp.New("Fee could not be estimated")
 	}
 
-	pkScript, err := txscript.PayToAddrScript(address)
+	pkScript, err := payToAddrScript(address)
 	if err != nil {
-		return nil, nil, errp.WithStack(err)
+		return nil, nil, err
 	}
 	utxo := account.transactions.SpendableOutputs()
 	wireUTXO := make(map[wire.OutPoint]*wire.TxOut, len(utxo))
@@ -84,7 +104,7 @@ func (account *Account) newTx(
 			account.signingConfiguration,
 			wireUTXO,
 			pkScript,
-			*feeTarget.feeRatePerKb,
+			*feeRatePerKb,
 			account.log,
 		)
 		if err != nil {
@@ -105,7 +125,7 @@ func (account *Account) newTx(
 			account.signingConfiguration,
 			wireUTXO,
 			wire.NewTxOut(parsedAmountInt64, pkScript),
-			*feeTarget.feeRatePerKb,
+			*feeRatePerKb,
 			func() *addresses.AccountAddress {
 				return account.changeAddresses.GetUnused()[0]
 			},
@@ -129,6 +149,15 @@ func (account *Account) SendTx(
 	_ []byte,
 ) error {
 	account.log.Info("Signing and sending transaction")
+	if configuration := account.signingConfiguration; configuration.Multisig() {
+		if err := account.checkKeystores(); err != nil {
+			return err
+		}
+		if account.keystores.Count() < configuration.SigningThreshold() {
+			return errp.New("Not enough keystores to sign, the signatures of the cosigners have to " +
+				"be collected in a PSBT.")
+		}
+	}
 	utxo, txProposal, err := account.newTx(
 		recipientAddress,
 		amount,
@@ -139,10 +168,7 @@ func (account *Account) SendTx(
 		return errp.WithMessage(err, "Failed to create transaction")
 	}
 	getAddress := func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
-		if address := account.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
-			return address
-		}
-		if address := account.changeAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
+		if address := account.lookupAddress(scriptHashHex); address != nil {
 			return address
 		}
 		panic("address must be present")
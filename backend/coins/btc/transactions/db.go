@@ -81,6 +81,18 @@ type DBTxInterface interface {
 
 	// AddressHistory retrieves an address history. If not found, returns an empty history.
 	AddressHistory(blockchain.ScriptHashHex) (blockchain.TxHistory, error)
+
+	// PutAddressLabel stores the user defined label of an address. An empty label removes it.
+	PutAddressLabel(blockchain.ScriptHashHex, string) error
+
+	// AddressLabels retrieves the labels of all labeled addresses.
+	AddressLabels() (map[blockchain.ScriptHashHex]string, error)
+
+	// PutTxNote stores the user defined note of a transaction. An empty note removes it.
+	PutTxNote(chainhash.Hash, string) error
+
+	// TxNotes retrieves the notes of all annotated transactions.
+	TxNotes() (map[chainhash.Hash]string, error)
 }
 
 // DBInterface can be implemented by database backends to open database transactions.
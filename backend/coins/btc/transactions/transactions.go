@@ -29,6 +29,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/headers"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/synchronizer"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/sirupsen/logrus"
@@ -453,6 +454,8 @@ type TxInfo struct {
 	timestamp *time.Time
 	// addresses money was sent to / received on (without change addresses).
 	addresses []accounts.AddressAndAmount
+	// note is the user defined note of the transaction.
+	note string
 }
 
 // Fee implements accounts.Transaction.
@@ -503,7 +506,19 @@ func (txInfo *TxInfo) Addresses() []accounts.AddressAndAmount {
 	return txInfo.addresses
 }
 
+// Note implements accounts.Transaction.
+func (txInfo *TxInfo) Note() string {
+	return txInfo.note
+}
+
 func (transactions *Transactions) outputToAddress(pkScript []byte) string {
+	if taproot.IsPayToTaproot(pkScript) {
+		address, err := taproot.NewAddress(pkScript[2:], transactions.net)
+		if err != nil {
+			return "<unknown address>"
+		}
+		return address.String()
+	}
 	_, extractedAddresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, transactions.net)
 	// unknown addresses and multisig scripts ignored.
 	if err != nil || len(extractedAddresses) != 1 {
@@ -518,7 +533,8 @@ func (transactions *Transactions) txInfo(
 	tx *wire.MsgTx,
 	height int,
 	timestamp *time.Time,
-	isChange func(blockchain.ScriptHashHex) bool) *TxInfo {
+	isChange func(blockchain.ScriptHashHex) bool,
+	labels map[blockchain.ScriptHashHex]string) *TxInfo {
 	defer transactions.RLock()()
 	var sumOurInputs btcutil.Amount
 	var result btcutil.Amount
@@ -554,6 +570,9 @@ func (transactions *Transactions) txInfo(
 			Amount:  coin.NewAmountFromInt64(txOut.Value),
 			Ours:    output != nil,
 		}
+		if output != nil {
+			addressAndAmount.Label = labels[getScriptHashHex(output)]
+		}
 		if output != nil {
 			receiveAddresses = append(receiveAddresses, addressAndAmount)
 			if isChange(getScriptHashHex(output)) {
@@ -620,29 +639,141 @@ func (transactions *Transactions) txInfo(
 
 // Transactions returns an ordered list of transactions.
 func (transactions *Transactions) Transactions(
-	isChange func(blockchain.ScriptHashHex) bool) []*TxInfo {
+	isChange func(blockchain.ScriptHashHex) bool) ([]*TxInfo, error) {
 	transactions.synchronizer.WaitSynchronized()
 	defer transactions.RLock()()
 	dbTx, err := transactions.db.Begin()
 	if err != nil {
-		// TODO
-		panic(err)
+		return nil, err
 	}
 	defer dbTx.Rollback()
 	txs := []*TxInfo{}
 	txHashes, err := dbTx.Transactions()
 	if err != nil {
-		// TODO
-		panic(err)
+		return nil, err
+	}
+	labels, err := dbTx.AddressLabels()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := dbTx.TxNotes()
+	if err != nil {
+		return nil, err
 	}
 	for _, txHash := range txHashes {
 		tx, _, height, timestamp, err := dbTx.TxInfo(txHash)
 		if err != nil {
-			// TODO
-			panic(err)
+			return nil, err
 		}
-		txs = append(txs, transactions.txInfo(dbTx, tx, height, timestamp, isChange))
+		txInfo := transactions.txInfo(dbTx, tx, height, timestamp, isChange, labels)
+		txInfo.note = notes[txHash]
+		txs = append(txs, txInfo)
 	}
 	sort.Sort(sort.Reverse(byHeight(txs)))
-	return txs
+	return txs, nil
+}
+
+// SetAddressLabel stores the user defined label of an address. An empty label removes it.
+func (transactions *Transactions) SetAddressLabel(scriptHashHex blockchain.ScriptHashHex, label string) error {
+	return transactions.PutLabels(nil, map[blockchain.ScriptHashHex]string{scriptHashHex: label})
+}
+
+// SetTxNote stores the user defined note of a transaction. An empty note removes it.
+func (transactions *Transactions) SetTxNote(txHash chainhash.Hash, note string) error {
+	return transactions.PutLabels(map[chainhash.Hash]string{txHash: note}, nil)
+}
+
+// PutLabels stores the given transaction notes and address labels at once. Empty notes and labels
+// are removed.
+func (transactions *Transactions) PutLabels(
+	txNotes map[chainhash.Hash]string,
+	addressLabels map[blockchain.ScriptHashHex]string,
+) error {
+	defer transactions.Lock()()
+	dbTx, err := transactions.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	for txHash, note := range txNotes {
+		if err := dbTx.PutTxNote(txHash, note); err != nil {
+			return err
+		}
+	}
+	for scriptHashHex, label := range addressLabels {
+		if err := dbTx.PutAddressLabel(scriptHashHex, label); err != nil {
+			return err
+		}
+	}
+	return dbTx.Commit()
+}
+
+// Labels returns all transaction notes and address labels.
+func (transactions *Transactions) Labels() (
+	map[chainhash.Hash]string, map[blockchain.ScriptHashHex]string, error) {
+	defer transactions.RLock()()
+	dbTx, err := transactions.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer dbTx.Rollback()
+	txNotes, err := dbTx.TxNotes()
+	if err != nil {
+		return nil, nil, err
+	}
+	addressLabels, err := dbTx.AddressLabels()
+	if err != nil {
+		return nil, nil, err
+	}
+	return txNotes, addressLabels, nil
+}
+
+// AddressSummary holds the user defined label, the balance and the number of transactions of an
+// address.
+type AddressSummary struct {
+	Label string
+	// Balance is the sum of the unspent outputs of the address, confirmed or not.
+	Balance         btcutil.Amount
+	NumTransactions int
+}
+
+// AddressSummaries returns the summaries of the given addresses, in the same order.
+func (transactions *Transactions) AddressSummaries(
+	scriptHashHexes []blockchain.ScriptHashHex) ([]AddressSummary, error) {
+	transactions.synchronizer.WaitSynchronized()
+	defer transactions.RLock()()
+	dbTx, err := transactions.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	labels, err := dbTx.AddressLabels()
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]AddressSummary, len(scriptHashHexes))
+	summariesByScriptHashHex := map[blockchain.ScriptHashHex]*AddressSummary{}
+	for index, scriptHashHex := range scriptHashHexes {
+		history, err := dbTx.AddressHistory(scriptHashHex)
+		if err != nil {
+			return nil, err
+		}
+		summaries[index] = AddressSummary{
+			Label:           labels[scriptHashHex],
+			NumTransactions: len(history),
+		}
+		summariesByScriptHashHex[scriptHashHex] = &summaries[index]
+	}
+	outputs, err := dbTx.Outputs()
+	if err != nil {
+		return nil, err
+	}
+	for outPoint, txOut := range outputs {
+		summary, ok := summariesByScriptHashHex[getScriptHashHex(txOut)]
+		if !ok || transactions.isInputSpent(dbTx, outPoint) {
+			continue
+		}
+		summary.Balance += btcutil.Amount(txOut.Value)
+	}
+	return summaries, nil
 }
@@ -155,6 +155,13 @@ func (s *transactionsSuite) updateAddressHistory(
 	s.blockchainMock.CallAllTransactionGetCallbacks()
 }
 
+// allTransactions returns the transactions, treating no address as a change address.
+func (s *transactionsSuite) allTransactions() []*transactions.TxInfo {
+	txs, err := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	require.NoError(s.T(), err)
+	return txs
+}
+
 func newTx(
 	fromTxHash chainhash.Hash,
 	fromTxIndex uint32,
@@ -247,7 +254,7 @@ func (s *transactionsSuite) TestUpdateAddressHistorySingleTxReceive() {
 		},
 		s.transactions.SpendableOutputs(),
 	)
-	transactions := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	transactions := s.allTransactions()
 	require.Len(s.T(), transactions, 1)
 	require.Equal(s.T(), tx1, transactions[0].Tx)
 	require.Equal(s.T(), expectedHeight, transactions[0].Height)
@@ -344,6 +351,73 @@ func (s *transactionsSuite) TestSpendableOutputs() {
 	require.Contains(s.T(), spendableOutputs, wire.OutPoint{Hash: tx22Spend.TxHash(), Index: 0})
 }
 
+// TestAddressLabels checks that address labels are persisted and show up in the address summaries
+// and in the addresses of the transactions.
+func (s *transactionsSuite) TestAddressLabels() {
+	addresses := s.addressChain.EnsureAddresses()
+	address1 := addresses[0]
+	address2 := addresses[1]
+	tx1 := newTx(chainhash.HashH(nil), 0, address1, 1000)
+	tx2 := newTx(chainhash.HashH(nil), 1, address1, 2000)
+	s.blockchainMock.RegisterTxs(tx1, tx2)
+	s.headersMock.On("HeaderByHeight", 10).Return(nil, nil).Once()
+	s.updateAddressHistory(address1, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
+	})
+
+	require.NoError(s.T(), s.transactions.SetAddressLabel(address1.PubkeyScriptHashHex(), "invoice #123"))
+	summaries, err := s.transactions.AddressSummaries([]blockchainpkg.ScriptHashHex{
+		address2.PubkeyScriptHashHex(),
+		address1.PubkeyScriptHashHex(),
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []transactions.AddressSummary{
+		{},
+		{
+			Label:           "invoice #123",
+			Balance:         3000,
+			NumTransactions: 2,
+		},
+	}, summaries)
+
+	for _, txInfo := range s.allTransactions() {
+		require.Len(s.T(), txInfo.Addresses(), 1)
+		require.Equal(s.T(), "invoice #123", txInfo.Addresses()[0].Label)
+	}
+
+	// An empty label removes the label.
+	require.NoError(s.T(), s.transactions.SetAddressLabel(address1.PubkeyScriptHashHex(), ""))
+	summaries, err = s.transactions.AddressSummaries([]blockchainpkg.ScriptHashHex{address1.PubkeyScriptHashHex()})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "", summaries[0].Label)
+}
+
+// TestTxNotes checks that transaction notes are persisted independently of the transactions and
+// show up in the transactions.
+func (s *transactionsSuite) TestTxNotes() {
+	address := s.addressChain.EnsureAddresses()[0]
+	tx := newTx(chainhash.HashH(nil), 0, address, 1000)
+	// Notes can be stored before the transaction is known, e.g. when importing labels.
+	require.NoError(s.T(), s.transactions.SetTxNote(tx.TxHash(), "rent March"))
+	s.blockchainMock.RegisterTxs(tx)
+	s.headersMock.On("HeaderByHeight", 10).Return(nil, nil).Once()
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx.TxHash()), Height: 10},
+	})
+	transactions := s.allTransactions()
+	require.Len(s.T(), transactions, 1)
+	require.Equal(s.T(), "rent March", transactions[0].Note())
+
+	txNotes, _, err := s.transactions.Labels()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[chainhash.Hash]string{tx.TxHash(): "rent March"}, txNotes)
+
+	require.NoError(s.T(), s.transactions.SetTxNote(tx.TxHash(), ""))
+	transactions = s.allTransactions()
+	require.Equal(s.T(), "", transactions[0].Note())
+}
+
 func (s *transactionsSuite) TestBalance() {
 	require.Equal(s.T(), newBalance(0, 0), s.transactions.Balance())
 	addresses := s.addressChain.EnsureAddresses()
@@ -440,7 +514,7 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 		newBalance(2+10+34, 0),
 		s.transactions.Balance())
 	require.Len(s.T(),
-		s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false }),
+		s.allTransactions(),
 		3)
 	// Remove tx3 from the history of address2. Now it's not referenced anymore and disappears.
 	s.updateAddressHistory(address2, []*blockchainpkg.TxInfo{
@@ -450,7 +524,7 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 		newBalance(12+34, 0),
 		s.transactions.Balance())
 	require.Len(s.T(),
-		s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false }),
+		s.allTransactions(),
 		2)
 }
 
@@ -471,5 +545,5 @@ func (s *transactionsSuite) TestRemoveTransactionPendingDownload() {
 		newBalance(0, 0),
 		s.transactions.Balance())
 	require.Empty(s.T(),
-		s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false }))
+		s.allTransactions())
 }
@@ -0,0 +1,44 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+
+const (
+	// MinReceiveGapLimit is the lowest receive gap limit which can be configured for an account.
+	MinReceiveGapLimit = 20
+	// MinChangeGapLimit is the lowest change gap limit which can be configured for an account.
+	MinChangeGapLimit = 6
+)
+
+// GapLimits holds the number of unused addresses which are scanned at the end of the receive and
+// change address chains of an account.
+type GapLimits struct {
+	Receive uint16 `json:"receive"`
+	Change  uint16 `json:"change"`
+}
+
+// Validate returns an error if the gap limits are lower than the standard gap limits. Lower values
+// are rejected to prevent hiding funds which are discovered with the standard gap limits. Script
+// types with higher defaults, like legacy P2PKH accounts, never use less than their defaults.
+func (gapLimits *GapLimits) Validate() error {
+	if gapLimits.Receive < MinReceiveGapLimit {
+		return errp.Newf("The receive gap limit must be at least %d.", MinReceiveGapLimit)
+	}
+	if gapLimits.Change < MinChangeGapLimit {
+		return errp.Newf("The change gap limit must be at least %d.", MinChangeGapLimit)
+	}
+	return nil
+}
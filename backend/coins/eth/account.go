@@ -2,9 +2,12 @@ package eth
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"path"
+	"sort"
+	"strings"
 	"time"
 
 	ethereum "github.com/ethereum/go-ethereum"
@@ -13,16 +16,20 @@ import (
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/synchronizer"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/db"
+	ethtypes "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/types"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/sirupsen/logrus"
 )
@@ -59,6 +66,10 @@ type Account struct {
 	nextNonce    uint64
 	transactions []accounts.Transaction
 
+	// feeTargets must be sorted by ascending priority.
+	feeTargets     []*FeeTarget
+	gasPriceOracle *gasPriceOracle
+
 	quitChan chan struct{}
 
 	log *logrus.Entry
@@ -91,9 +102,11 @@ func NewAccount(
 		getNotifier:             getNotifier,
 		onEvent:                 onEvent,
 		balance:                 coin.NewAmountFromInt64(0),
+		feeTargets:              newFeeTargets(),
+		gasPriceOracle:          newGasPriceOracle(),
 
 		initialized:     false,
-		enqueueUpdateCh: make(chan struct{}),
+		enqueueUpdateCh: make(chan struct{}, 1),
 		quitChan:        make(chan struct{}),
 		log:             log,
 	}
@@ -188,6 +201,7 @@ func (account *Account) Initialize() error {
 	)
 
 	account.coin.Initialize()
+	account.coin.RegisterOnConnectionStatusChangedEvent(account.onConnectionStatusChanged)
 	go account.poll()
 	return nil
 }
@@ -221,6 +235,45 @@ func (account *Account) poll() {
 	}
 }
 
+// confirmedTransactions returns the transactions and the internal transactions of the account,
+// sorted descending by block number. Internal transactions of our own transactions are skipped.
+func (account *Account) confirmedTransactions() ([]accounts.Transaction, error) {
+	indexer, err := account.coin.Indexer()
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := indexer.Transactions(
+		account.address.Address, account.blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	internalTransactions, err := indexer.InternalTransactions(
+		account.address.Address, account.blockNumber)
+	if errp.Cause(err) == ErrInternalTransactionsUnsupported {
+		account.log.Debug("Ether transfers made by contract calls are missing in the " +
+			"transaction history, as the indexer cannot find them")
+		internalTransactions = nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(internalTransactions) == 0 {
+		return transactions, nil
+	}
+	txIDs := map[string]struct{}{}
+	for _, transaction := range transactions {
+		txIDs[transaction.ID()] = struct{}{}
+	}
+	for _, transaction := range internalTransactions {
+		if _, ok := txIDs[transaction.ID()]; !ok {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].NumConfirmations() < transactions[j].NumConfirmations()
+	})
+	return transactions, nil
+}
+
 // pendingOutgoingTransactions gets all locally stored pending outgoing transactions. It filters out
 // already confirmed ones.
 func (account *Account) pendingOutgoingTransactions(confirmedTxs []accounts.Transaction) (
@@ -253,18 +306,42 @@ func (account *Account) pendingOutgoingTransactions(confirmedTxs []accounts.Tran
 	return transactions, nil
 }
 
+// onConnectionStatusChanged updates the account right away when the connection to the node is
+// lost or re-established, so that the offline status is reported without waiting for the next
+// poll.
+func (account *Account) onConnectionStatusChanged(status blockchain.Status) {
+	account.log.Infof("connection status changed to %d", status)
+	account.enqueueUpdate()
+}
+
+// enqueueUpdate invokes an account update without waiting for the poll loop. A pending update
+// request covers this one as well.
+func (account *Account) enqueueUpdate() {
+	select {
+	case account.enqueueUpdateCh <- struct{}{}:
+	default:
+	}
+}
+
 func (account *Account) update() error {
 	defer account.synchronizer.IncRequestsCounter()()
 
-	header, err := account.coin.client.HeaderByNumber(context.TODO(), nil)
+	client, err := account.coin.Client()
+	if err != nil {
+		return err
+	}
+	header, err := client.HeaderByNumber(context.TODO(), nil)
 	if err != nil {
 		return errp.WithStack(err)
 	}
 	account.blockNumber = header.Number
 
-	// Get confirmed transactions from EtherScan.
-	confirmedTansactions, err := account.coin.EtherScan().Transactions(
-		account.address.Address, account.blockNumber)
+	if err := account.updateFeeTargets(client, header.Number.Uint64()); err != nil {
+		return err
+	}
+
+	// Get confirmed transactions from the indexer.
+	confirmedTansactions, err := account.confirmedTransactions()
 	if err != nil {
 		return err
 	}
@@ -277,7 +354,7 @@ func (account *Account) update() error {
 
 	// Nonce to be used for the next tx, fetched from the ETH node. It might be out of date due to
 	// latency, which is addressed below by using the locally stored nonce.
-	nodeNonce, err := account.coin.client.PendingNonceAt(context.TODO(), account.address.Address)
+	nodeNonce, err := client.PendingNonceAt(context.TODO(), account.address.Address)
 	if err != nil {
 		return err
 	}
@@ -285,8 +362,9 @@ func (account *Account) update() error {
 
 	// In case the nodeNonce is not up to date, we fall back to our stored last nonce to compute the
 	// next nonce.
+	// The pending transactions are sorted descending by nonce.
 	if len(pendingOutgoingTransactions) > 0 {
-		localNonce := pendingOutgoingTransactions[len(pendingOutgoingTransactions)-1].(wrappedTransaction).tx.Nonce() + 1
+		localNonce := pendingOutgoingTransactions[0].(wrappedTransaction).tx.Nonce() + 1
 		if localNonce > account.nextNonce {
 			account.nextNonce = localNonce
 		}
@@ -298,7 +376,7 @@ func (account *Account) update() error {
 		}
 	}
 
-	balance, err := account.coin.client.BalanceAt(context.TODO(),
+	balance, err := client.BalanceAt(context.TODO(),
 		account.address.Address, account.blockNumber)
 	if err != nil {
 		return errp.WithStack(err)
@@ -308,6 +386,69 @@ func (account *Account) update() error {
 	return nil
 }
 
+// updateFeeTargets updates the gas prices of the fee targets from the recent blocks until the given
+// block. If the recent blocks have no transactions, all targets use the gas price suggested by the
+// node.
+func (account *Account) updateFeeTargets(client *ethclient.Client, blockNumber uint64) error {
+	gasPrices, err := account.gasPriceOracle.gasPrices(client, blockNumber)
+	if err != nil {
+		return err
+	}
+	var suggestedGasPrice *big.Int
+	if len(gasPrices) == 0 {
+		suggestedGasPrice, err = client.SuggestGasPrice(context.TODO())
+		if err != nil {
+			return errp.WithStack(err)
+		}
+	}
+	changed := false
+	func() {
+		defer account.Lock()()
+		for _, feeTarget := range account.feeTargets {
+			gasPrice := suggestedGasPrice
+			if gasPrice == nil {
+				gasPrice = percentile(gasPrices, feeTarget.percentile)
+			}
+			if feeTarget.gasPrice == nil || feeTarget.gasPrice.Cmp(gasPrice) != 0 {
+				feeTarget.gasPrice = gasPrice
+				changed = true
+				account.log.WithFields(logrus.Fields{"code": feeTarget.code,
+					"gas-price": gasPrice}).Debug("Gas price estimate")
+			}
+		}
+	}()
+	if changed {
+		account.onEvent(accounts.EventFeeTargetsChanged)
+	}
+	return nil
+}
+
+// gasPrice returns the gas price of the fee target with the given code. If it is not available
+// yet, the gas price suggested by the node is returned.
+func (account *Account) gasPrice(feeTargetCode accounts.FeeTargetCode) (*big.Int, error) {
+	gasPrice := func() *big.Int {
+		defer account.RLock()()
+		for _, feeTarget := range account.feeTargets {
+			if feeTarget.code == feeTargetCode {
+				return feeTarget.gasPrice
+			}
+		}
+		return nil
+	}()
+	if gasPrice != nil {
+		return gasPrice, nil
+	}
+	client, err := account.coin.Client()
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err = client.SuggestGasPrice(context.TODO())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return gasPrice, nil
+}
+
 // Initialized implements accounts.Interface.
 func (account *Account) Initialized() bool {
 	return account.initialized
@@ -327,6 +468,7 @@ func (account *Account) FatalError() bool {
 func (account *Account) Close() {
 	account.log.Info("Closed account")
 	if account.db != nil {
+		close(account.quitChan)
 		if err := account.db.Close(); err != nil {
 			account.log.WithError(err).Error("couldn't close db")
 		}
@@ -344,7 +486,163 @@ func (account *Account) Notifier() accounts.Notifier {
 // Transactions implements accounts.Interface.
 func (account *Account) Transactions() ([]accounts.Transaction, error) {
 	account.synchronizer.WaitSynchronized()
-	return account.transactions, nil
+	return account.annotateTransactions(account.transactions)
+}
+
+// annotateTransactions adds the stored notes and replacements to the given transactions.
+func (account *Account) annotateTransactions(transactions []accounts.Transaction) (
+	[]accounts.Transaction, error) {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	notes, err := dbTx.TxNotes()
+	if err != nil {
+		return nil, err
+	}
+	replacedTransactions, err := dbTx.ReplacedTransactions()
+	if err != nil {
+		return nil, err
+	}
+	contractABIs, err := dbTx.ContractABIs()
+	if err != nil {
+		return nil, err
+	}
+	annotatedTransactions := make([]accounts.Transaction, len(transactions))
+	for index, transaction := range transactions {
+		txHash := common.HexToHash(transaction.ID())
+		replacedTxID := ""
+		if replacedTxHash, ok := replacedTransactions[txHash]; ok {
+			replacedTxID = replacedTxHash.Hex()
+		}
+		annotatedTransactions[index] = annotatedTransaction{
+			Transaction:  transaction,
+			note:         notes[txHash],
+			replacedTxID: replacedTxID,
+			contractCall: decodeTransactionData(transaction, contractABIs),
+		}
+	}
+	return annotatedTransactions, nil
+}
+
+// decodeTransactionData decodes the calldata of the transaction with the stored ABI of the called
+// contract. ERC20 transfers are decoded without a stored ABI. Returns nil if the transaction is not
+// a contract call or the ABI is unknown.
+func decodeTransactionData(
+	transaction accounts.Transaction, contractABIs map[common.Address]string) *ethtypes.ContractCall {
+	ethTransaction, ok := transaction.(ethtypes.EthereumTransaction)
+	if !ok {
+		return nil
+	}
+	data := ethTransaction.Data()
+	addresses := transaction.Addresses()
+	if len(data) == 0 || len(addresses) != 1 || !common.IsHexAddress(addresses[0].Address) {
+		return nil
+	}
+	if abiJSON, ok := contractABIs[common.HexToAddress(addresses[0].Address)]; ok {
+		if contractCall, ok := DecodeContractCall(abiJSON, data); ok {
+			return contractCall
+		}
+	}
+	if contractCall, ok := decodeERC20Transfer(data); ok {
+		return contractCall
+	}
+	return nil
+}
+
+// StoreContractABI stores the JSON ABI of the contract at the given address, so that calls to the
+// contract are decoded in the transaction history.
+func (account *Account) StoreContractABI(contractAddress common.Address, abiJSON string) error {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if err := dbTx.PutContractABI(contractAddress, abiJSON); err != nil {
+		return err
+	}
+	return dbTx.Commit()
+}
+
+func (account *Account) txNotes() (map[common.Hash]string, error) {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	return dbTx.TxNotes()
+}
+
+// putTxNotes stores the given transaction notes at once. Empty notes are removed.
+func (account *Account) putTxNotes(notes map[common.Hash]string) error {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	for txHash, note := range notes {
+		if err := dbTx.PutTxNote(txHash, note); err != nil {
+			return err
+		}
+	}
+	return dbTx.Commit()
+}
+
+// parseTxHash parses a transaction ID in the 0x-prefixed hex format.
+func parseTxHash(txID string) (common.Hash, error) {
+	txHash, err := hexutil.Decode(txID)
+	if err != nil || len(txHash) != common.HashLength {
+		return common.Hash{}, errp.Newf("invalid transaction ID %s", txID)
+	}
+	return common.BytesToHash(txHash), nil
+}
+
+// SetTxNote implements accounts.Interface.
+func (account *Account) SetTxNote(txID string, note string) error {
+	txHash, err := parseTxHash(txID)
+	if err != nil {
+		return err
+	}
+	return account.putTxNotes(map[common.Hash]string{txHash: strings.TrimSpace(note)})
+}
+
+// ExportLabels implements accounts.Interface. Only transaction notes are exported, as the account
+// has only one address.
+func (account *Account) ExportLabels() ([]accounts.Label, error) {
+	notes, err := account.txNotes()
+	if err != nil {
+		return nil, err
+	}
+	labels := []accounts.Label{}
+	for txHash, note := range notes {
+		labels = append(labels, accounts.Label{
+			Type:  accounts.LabelTypeTx,
+			Ref:   txHash.Hex(),
+			Label: note,
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Ref < labels[j].Ref })
+	return labels, nil
+}
+
+// ImportLabels implements accounts.Interface.
+func (account *Account) ImportLabels(labels []accounts.Label) (int, error) {
+	notes := map[common.Hash]string{}
+	for _, label := range labels {
+		if label.Type != accounts.LabelTypeTx {
+			continue
+		}
+		txHash, err := parseTxHash(label.Ref)
+		if err != nil {
+			continue
+		}
+		notes[txHash] = strings.TrimSpace(label.Label)
+	}
+	if err := account.putTxNotes(notes); err != nil {
+		return 0, err
+	}
+	return len(notes), nil
 }
 
 // Balance implements accounts.Interface.
@@ -363,16 +661,37 @@ type TxProposal struct {
 	Keypath signing.AbsoluteKeypath
 }
 
+// ResolveRecipient returns the address of the recipient, which is either a hex address or an ENS
+// name like `name.eth`. errors.ErrInvalidAddress is returned if the recipient is invalid or the ENS
+// name cannot be resolved. Transactions are only created for hex addresses, so that the name is
+// resolved once and the address shown to the user is the one which is signed.
+func (account *Account) ResolveRecipient(recipient string) (common.Address, error) {
+	recipient = strings.TrimSpace(recipient)
+	if common.IsHexAddress(recipient) {
+		return common.HexToAddress(recipient), nil
+	}
+	if !ensChainIDs[account.coin.Net().ChainID.Uint64()] || !isENSName(recipient) {
+		return common.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	client, err := account.coin.Client()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return resolveENSName(client, ensRegistryAddress, recipient, account.log)
+}
+
 func (account *Account) newTx(
 	recipientAddress string,
 	amount coin.SendAmount,
+	feeTargetCode accounts.FeeTargetCode,
 	data []byte,
 ) (*TxProposal, error) {
 	if !common.IsHexAddress(recipientAddress) {
 		return nil, errp.WithStack(errors.ErrInvalidAddress)
 	}
+	address := common.HexToAddress(recipientAddress)
 
-	suggestedGasPrice, err := account.coin.client.SuggestGasPrice(context.TODO())
+	gasPrice, err := account.gasPrice(feeTargetCode)
 	if err != nil {
 		return nil, err
 	}
@@ -389,22 +708,25 @@ func (account *Account) newTx(
 		value = parsedAmount.BigInt()
 	}
 
-	address := common.HexToAddress(recipientAddress)
 	message := ethereum.CallMsg{
 		From:     account.address.Address,
 		To:       &address,
 		Gas:      0,
-		GasPrice: suggestedGasPrice,
+		GasPrice: gasPrice,
 		Value:    value,
 		Data:     data,
 	}
-	gasLimit, err := account.coin.client.EstimateGas(context.TODO(), message)
+	client, err := account.coin.Client()
+	if err != nil {
+		return nil, err
+	}
+	gasLimit, err := client.EstimateGas(context.TODO(), message)
 	if err != nil {
 		account.log.WithError(err).Error("Could not estimate the gas limit.")
 		return nil, errp.WithStack(errors.ErrInvalidData)
 	}
 
-	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), suggestedGasPrice)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice)
 
 	if amount.SendAll() {
 		// Set the value correctly and check that the fee is smaller than or equal to the balance.
@@ -420,8 +742,8 @@ func (account *Account) newTx(
 		}
 	}
 	tx := types.NewTransaction(account.nextNonce,
-		common.HexToAddress(recipientAddress),
-		value, gasLimit, suggestedGasPrice, data)
+		address,
+		value, gasLimit, gasPrice, data)
 	return &TxProposal{
 		Tx:      tx,
 		Fee:     fee,
@@ -450,41 +772,227 @@ func (account *Account) storePendingOutgoingTransaction(transaction *types.Trans
 func (account *Account) SendTx(
 	recipientAddress string,
 	amount coin.SendAmount,
-	_ accounts.FeeTargetCode,
+	feeTargetCode accounts.FeeTargetCode,
 	_ map[wire.OutPoint]struct{},
 	data []byte) error {
 	account.log.Info("Signing and sending transaction")
-	txProposal, err := account.newTx(recipientAddress, amount, data)
+	txProposal, err := account.newTx(recipientAddress, amount, feeTargetCode, data)
 	if err != nil {
 		return err
 	}
 	if err := account.keystores.SignTransaction(txProposal); err != nil {
 		return err
 	}
-	if err := account.coin.client.SendTransaction(context.TODO(), txProposal.Tx); err != nil {
+	client, err := account.coin.Client()
+	if err != nil {
+		return err
+	}
+	if err := client.SendTransaction(context.TODO(), txProposal.Tx); err != nil {
 		return errp.WithStack(err)
 	}
 	if err := account.storePendingOutgoingTransaction(txProposal.Tx); err != nil {
 		return err
 	}
-	account.enqueueUpdateCh <- struct{}{}
+	account.enqueueUpdate()
+	return nil
+}
+
+// replacementGasPriceBump is the minimum gas price increase in percent required by the nodes to
+// replace a pending transaction.
+const replacementGasPriceBump = 10
+
+// pendingOutgoingTransaction returns the stored pending outgoing transaction with the given ID. The
+// database keeps transactions after they are confirmed, so the confirmed transactions known to the
+// account are rejected.
+func (account *Account) pendingOutgoingTransaction(txID string) (*types.Transaction, error) {
+	txHash, err := parseTxHash(txID)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range account.transactions {
+		if common.HexToHash(transaction.ID()) == txHash && transaction.NumConfirmations() > 0 {
+			return nil, errp.New("The transaction is already confirmed.")
+		}
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	pendingOutgoingTransactions, err := dbTx.PendingOutgoingTransactions()
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range pendingOutgoingTransactions {
+		if transaction.Hash() == txHash {
+			return transaction, nil
+		}
+	}
+	return nil, errp.Newf("no pending outgoing transaction %s", txID)
+}
+
+// replaceTx signs and broadcasts the transaction created by newTx, which has the nonce of the
+// pending outgoing transaction with the given ID and thereby replaces it. The gas price is the one
+// of the given fee target, but at least the minimum required to replace the pending transaction.
+func (account *Account) replaceTx(
+	txID string,
+	feeTargetCode accounts.FeeTargetCode,
+	newTx func(replacedTx *types.Transaction, gasPrice *big.Int) *types.Transaction,
+) error {
+	replacedTx, err := account.pendingOutgoingTransaction(txID)
+	if err != nil {
+		return err
+	}
+	client, err := account.coin.Client()
+	if err != nil {
+		return err
+	}
+	// The indexer might not know about the confirmation yet, but the node does: a mined transaction
+	// has a nonce below the nonce of the next transaction to be mined.
+	minedNonce, err := client.NonceAt(context.TODO(), account.address.Address, nil)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if replacedTx.Nonce() < minedNonce {
+		return errp.New("The transaction is already confirmed.")
+	}
+	gasPrice, err := account.gasPrice(feeTargetCode)
+	if err != nil {
+		return err
+	}
+	minGasPrice := new(big.Int).Div(
+		new(big.Int).Mul(replacedTx.GasPrice(), big.NewInt(100+replacementGasPriceBump)),
+		big.NewInt(100))
+	minGasPrice.Add(minGasPrice, big.NewInt(1))
+	if gasPrice.Cmp(minGasPrice) < 0 {
+		gasPrice = minGasPrice
+	}
+	tx := newTx(replacedTx, gasPrice)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), gasPrice)
+	if new(big.Int).Add(tx.Value(), fee).Cmp(account.balance.BigInt()) == 1 {
+		return errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	txProposal := &TxProposal{
+		Tx:      tx,
+		Fee:     fee,
+		Signer:  types.MakeSigner(account.coin.Net(), account.blockNumber),
+		Keypath: account.signingConfiguration.AbsoluteKeypath(),
+	}
+	if err := account.keystores.SignTransaction(txProposal); err != nil {
+		return err
+	}
+	if err := client.SendTransaction(context.TODO(), txProposal.Tx); err != nil {
+		return errp.WithStack(err)
+	}
+	if err := account.storeReplacementTransaction(replacedTx, txProposal.Tx); err != nil {
+		return err
+	}
+	account.enqueueUpdate()
+	return nil
+}
+
+// storeReplacementTransaction replaces the pending outgoing transaction in the database and moves
+// its note to the replacement.
+func (account *Account) storeReplacementTransaction(
+	replacedTx *types.Transaction, replacementTx *types.Transaction) error {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if err := dbTx.DeletePendingOutgoingTransaction(replacedTx.Hash()); err != nil {
+		return err
+	}
+	if err := dbTx.PutPendingOutgoingTransaction(replacementTx); err != nil {
+		return err
+	}
+	if err := dbTx.PutReplacedTransaction(replacedTx.Hash(), replacementTx.Hash()); err != nil {
+		return err
+	}
+	notes, err := dbTx.TxNotes()
+	if err != nil {
+		return err
+	}
+	if note, ok := notes[replacedTx.Hash()]; ok {
+		if err := dbTx.PutTxNote(replacementTx.Hash(), note); err != nil {
+			return err
+		}
+	}
+	if err := dbTx.Commit(); err != nil {
+		return err
+	}
+	account.log.Infof("replaced pending outgoing tx with nonce: %d", replacementTx.Nonce())
 	return nil
 }
 
-// FeeTargets implements accounts.Interface.
+// SpeedUpTx replaces the pending outgoing transaction with the given ID by the same transaction
+// with a higher gas price. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) SpeedUpTx(txID string, feeTargetCode accounts.FeeTargetCode) error {
+	account.log.Info("Speeding up transaction")
+	return account.replaceTx(txID, feeTargetCode,
+		func(replacedTx *types.Transaction, gasPrice *big.Int) *types.Transaction {
+			if replacedTx.To() == nil {
+				return types.NewContractCreation(replacedTx.Nonce(), replacedTx.Value(),
+					replacedTx.Gas(), gasPrice, replacedTx.Data())
+			}
+			return types.NewTransaction(replacedTx.Nonce(), *replacedTx.To(), replacedTx.Value(),
+				replacedTx.Gas(), gasPrice, replacedTx.Data())
+		})
+}
+
+// CancelTx replaces the pending outgoing transaction with the given ID by a transaction sending
+// nothing to ourselves with a higher gas price. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) CancelTx(txID string, feeTargetCode accounts.FeeTargetCode) error {
+	account.log.Info("Canceling transaction")
+	return account.replaceTx(txID, feeTargetCode,
+		func(replacedTx *types.Transaction, gasPrice *big.Int) *types.Transaction {
+			return types.NewTransaction(replacedTx.Nonce(), account.address.Address, big.NewInt(0),
+				params.TxGas, gasPrice, nil)
+		})
+}
+
+// FeeTargets implements accounts.Interface. Only fee targets with a gas price are returned, and of
+// several targets with the same gas price only the one with the lowest priority is returned.
 func (account *Account) FeeTargets() ([]accounts.FeeTarget, accounts.FeeTargetCode) {
-	return nil, ""
+	defer account.RLock()()
+	feeTargets := []accounts.FeeTarget{}
+	var defaultGasPrice *big.Int
+outer:
+	for i := len(account.feeTargets) - 1; i >= 0; i-- {
+		feeTarget := account.feeTargets[i]
+		if feeTarget.gasPrice == nil {
+			continue
+		}
+		if feeTarget.code == accounts.DefaultFeeTarget {
+			defaultGasPrice = feeTarget.gasPrice
+		}
+		for j := i - 1; j >= 0; j-- {
+			checkFeeTarget := account.feeTargets[j]
+			if checkFeeTarget.gasPrice != nil && checkFeeTarget.gasPrice.Cmp(feeTarget.gasPrice) == 0 {
+				continue outer
+			}
+		}
+		feeTargets = append(feeTargets, feeTarget)
+	}
+	// If the default fee target was dropped, use the remaining one with the same gas price.
+	defaultFee := accounts.DefaultFeeTarget
+	for _, feeTarget := range feeTargets {
+		if defaultGasPrice != nil && feeTarget.(*FeeTarget).gasPrice.Cmp(defaultGasPrice) == 0 {
+			defaultFee = feeTarget.Code()
+		}
+	}
+	return feeTargets, defaultFee
 }
 
 // TxProposal implements accounts.Interface.
 func (account *Account) TxProposal(
 	recipientAddress string,
 	amount coin.SendAmount,
-	_ accounts.FeeTargetCode,
+	feeTargetCode accounts.FeeTargetCode,
 	_ map[wire.OutPoint]struct{},
 	data []byte) (coin.Amount, coin.Amount, coin.Amount, error) {
 
-	txProposal, err := account.newTx(recipientAddress, amount, data)
+	txProposal, err := account.newTx(recipientAddress, amount, feeTargetCode, data)
 	if err != nil {
 		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
 	}
@@ -499,9 +1007,78 @@ func (account *Account) GetUnusedReceiveAddresses() []accounts.Address {
 	return []accounts.Address{account.address}
 }
 
-// VerifyAddress implements accounts.Interface.
+// SignMessage signs the message with personal_sign. It returns the signature and the address
+// recovered from it. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) SignMessage(message []byte) ([]byte, common.Address, error) {
+	return account.signMessage(&signing.MessageProposal{
+		Message: message,
+		Hash:    personalMessageHash(message),
+	})
+}
+
+// SignTypedData signs the EIP-712 typed data. It returns the signature and the address recovered
+// from it. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) SignTypedData(typedData *TypedData) ([]byte, common.Address, error) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	encodedTypedData, err := json.Marshal(typedData)
+	if err != nil {
+		return nil, common.Address{}, errp.WithStack(err)
+	}
+	return account.signMessage(&signing.MessageProposal{
+		Message: encodedTypedData,
+		Hash:    hash,
+	})
+}
+
+// signMessage signs the hash of the message proposal and returns the signature in the format of
+// personal_sign and eth_signTypedData: 32 bytes R, 32 bytes S and the recovery ID plus 27.
+func (account *Account) signMessage(
+	messageProposal *signing.MessageProposal) ([]byte, common.Address, error) {
+	if account.signingConfiguration == nil {
+		return nil, common.Address{}, errp.New("account must be initialized")
+	}
+	account.log.Info("Signing message")
+	messageProposal.Keypath = account.signingConfiguration.AbsoluteKeypath()
+	if err := account.keystores.SignMessage(messageProposal); err != nil {
+		return nil, common.Address{}, err
+	}
+	if len(messageProposal.Signature) != 65 {
+		return nil, common.Address{}, errp.New("unexpected signature")
+	}
+	publicKey, err := crypto.SigToPub(messageProposal.Hash, messageProposal.Signature)
+	if err != nil {
+		return nil, common.Address{}, errp.WithStack(err)
+	}
+	address := crypto.PubkeyToAddress(*publicKey)
+	if address != account.address.Address {
+		return nil, common.Address{}, errp.New("the signature does not match the account address")
+	}
+	signature := append([]byte{}, messageProposal.Signature...)
+	signature[64] += 27
+	return signature, address, nil
+}
+
+// VerifyAddress verifies the receive address on a keystore. Returns false, nil if no secure output
+// exists.
 func (account *Account) VerifyAddress(addressID string) (bool, error) {
-	return true, nil
+	if account.signingConfiguration == nil {
+		return false, errp.New("account must be initialized")
+	}
+	if addressID != account.address.ID() {
+		return false, errp.New("unknown address not found")
+	}
+	canVerifyAddress, err := account.Keystores().CanVerifyAddresses(
+		account.signingConfiguration, account.Coin())
+	if err != nil {
+		return false, err
+	}
+	if canVerifyAddress {
+		return true, account.Keystores().VerifyAddress(account.signingConfiguration, account.Coin())
+	}
+	return false, nil
 }
 
 // ConvertToLegacyAddress implements accounts.Interface.
@@ -0,0 +1,81 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/db"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/test"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceTransaction(t *testing.T) {
+	accountDB, err := db.NewDB(test.TstTempFile("eth-account-db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, accountDB.Close()) }()
+	account := &Account{db: accountDB, log: logging.Get().WithGroup("eth_test")}
+
+	recipient := common.HexToAddress("0x2dD1Fd1a1E6Ec5e95aB0E4E8F3b5e0B2A1E9B43f")
+	pendingTx := types.NewTransaction(3, recipient, big.NewInt(1), 21000, big.NewInt(1e9), nil)
+	require.NoError(t, account.storePendingOutgoingTransaction(pendingTx))
+	require.NoError(t, account.SetTxNote(pendingTx.Hash().Hex(), "rent"))
+
+	transaction, err := account.pendingOutgoingTransaction(pendingTx.Hash().Hex())
+	require.NoError(t, err)
+	require.Equal(t, pendingTx.Hash(), transaction.Hash())
+
+	replacementTx := types.NewTransaction(3, recipient, big.NewInt(1), 21000, big.NewInt(2e9), nil)
+	require.NoError(t, account.storeReplacementTransaction(pendingTx, replacementTx))
+
+	_, err = account.pendingOutgoingTransaction(pendingTx.Hash().Hex())
+	require.Error(t, err)
+	pendingTransactions, err := account.pendingOutgoingTransactions(nil)
+	require.NoError(t, err)
+	require.Len(t, pendingTransactions, 1)
+
+	transactions, err := account.annotateTransactions(pendingTransactions)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	require.Equal(t, replacementTx.Hash().Hex(), transactions[0].ID())
+	require.Equal(t, "rent", transactions[0].Note())
+	require.Equal(t, pendingTx.Hash().Hex(), transactions[0].(annotatedTransaction).ReplacedTxID())
+
+	// Once confirmed, the transaction is kept in the database, but cannot be replaced anymore.
+	_, err = account.pendingOutgoingTransaction(replacementTx.Hash().Hex())
+	require.NoError(t, err)
+	account.transactions = []accounts.Transaction{confirmedTransaction{id: replacementTx.Hash().Hex()}}
+	_, err = account.pendingOutgoingTransaction(replacementTx.Hash().Hex())
+	require.Error(t, err)
+}
+
+// confirmedTransaction is a confirmed transaction as returned by the indexer.
+type confirmedTransaction struct {
+	accounts.Transaction
+	id string
+}
+
+func (tx confirmedTransaction) ID() string {
+	return tx.id
+}
+
+func (tx confirmedTransaction) NumConfirmations() int {
+	return 1
+}
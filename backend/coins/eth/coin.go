@@ -1,12 +1,17 @@
 package eth
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
-	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -14,60 +19,240 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrNotConnected is returned when a request needs the node while there is no connection to it.
+var ErrNotConnected = errors.New("not connected to the ethereum node")
+
+var (
+	// connectionCheckInterval is the interval in which the connection to the node is checked.
+	connectionCheckInterval = 30 * time.Second
+	// minReconnectDelay and maxReconnectDelay bound the exponential backoff of reconnecting.
+	minReconnectDelay = 2 * time.Second
+	maxReconnectDelay = 2 * time.Minute
+	// requestTimeout is the timeout of the request checking the connection.
+	requestTimeout = 15 * time.Second
+)
+
 // Coin models an Ethereum coin.
 type Coin struct {
 	observable.Implementation
 	initOnce              sync.Once
-	client                *ethclient.Client
 	code                  string
 	net                   *params.ChainConfig
 	blockExplorerTxPrefix string
 	nodeURL               string
-	etherScan             *etherscan.EtherScan
+
+	// indexer is created with the coin. Indexers using the node fail while there is no connection.
+	indexer Indexer
+
+	// client is nil while there is no connection to the node.
+	client           *ethclient.Client
+	connectionStatus blockchain.Status
+	initialized      bool
+	// connectionAttempted is closed after the first connection attempt.
+	connectionAttempted       chan struct{}
+	onConnectionStatusChanged []func(blockchain.Status)
+	connectionLock            locker.Locker
+	// notifyLock serializes the calls of the onConnectionStatusChanged callbacks.
+	notifyLock locker.Locker
+	// quit is closed to stop maintaining the connection.
+	quit      chan struct{}
+	closeOnce sync.Once
 
 	log *logrus.Entry
 }
 
-// NewCoin creates a new coin with the given parameters.
+// NewCoin creates a new coin with the given parameters. The transaction history is fetched from
+// the indexer created by makeIndexer, which can be nil if the transaction history is not needed.
 func NewCoin(
 	code string,
 	net *params.ChainConfig,
 	blockExplorerTxPrefix string,
 	nodeURL string,
+	makeIndexer MakeIndexer,
 ) *Coin {
-	return &Coin{
+	coin := &Coin{
 		code:                  code,
 		net:                   net,
 		blockExplorerTxPrefix: blockExplorerTxPrefix,
 		nodeURL:               nodeURL,
 
+		connectionStatus:    blockchain.DISCONNECTED,
+		connectionAttempted: make(chan struct{}),
+		quit:                make(chan struct{}),
+
 		log: logging.Get().WithGroup("coin").WithField("code", code),
 	}
+	if makeIndexer != nil {
+		coin.indexer = makeIndexer(nodeClient{coin: coin})
+	}
+	return coin
 }
 
 // Net returns the network (mainnet, testnet, etc.).
 func (coin *Coin) Net() *params.ChainConfig { return coin.net }
 
-// Initialize implements coin.Coin.
+// Initialize implements coin.Coin. The connection to the node is established in the background
+// and re-established with an increasing delay if it fails.
 func (coin *Coin) Initialize() {
 	coin.initOnce.Do(func() {
-		etherScanURL := "https://api.etherscan.io/api"
-		if coin.code == "reth" {
-			etherScanURL = "https://api-rinkeby.etherscan.io/api"
+		func() {
+			defer coin.connectionLock.Lock()()
+			coin.initialized = true
+		}()
+		go coin.maintainConnection()
+	})
+}
+
+// Close stops maintaining the connection to the node and closes it.
+func (coin *Coin) Close() {
+	coin.closeOnce.Do(func() {
+		close(coin.quit)
+	})
+}
+
+func (coin *Coin) maintainConnection() {
+	reconnectDelay := minReconnectDelay
+	for {
+		err := coin.checkConnection()
+		delay := connectionCheckInterval
+		if err == nil {
+			reconnectDelay = minReconnectDelay
+		} else {
+			coin.log.WithError(err).Warnf("could not connect to %s, retrying in %s",
+				coin.nodeURL, reconnectDelay)
+			delay = reconnectDelay
+			reconnectDelay *= 2
+			if reconnectDelay > maxReconnectDelay {
+				reconnectDelay = maxReconnectDelay
+			}
 		}
-		if coin.code == "teth" {
-			etherScanURL = "https://api-ropsten.etherscan.io/api"
+		select {
+		case <-coin.quit:
+			coin.disconnect()
+			return
+		case <-time.After(delay):
 		}
+	}
+}
+
+// disconnect closes the connection to the node.
+func (coin *Coin) disconnect() {
+	client := func() *ethclient.Client {
+		defer coin.connectionLock.RLock()()
+		return coin.client
+	}()
+	if client != nil {
+		client.Close()
+	}
+	coin.setConnection(nil)
+}
+
+// waitConnectionAttempted waits for the first connection attempt after Initialize. It returns
+// right away if the coin was not initialized or is closed.
+func (coin *Coin) waitConnectionAttempted() {
+	initialized := func() bool {
+		defer coin.connectionLock.RLock()()
+		return coin.initialized
+	}()
+	if !initialized {
+		return
+	}
+	select {
+	case <-coin.connectionAttempted:
+	case <-coin.quit:
+	}
+}
+
+// checkConnection connects to the node if not connected yet and checks that the node responds.
+func (coin *Coin) checkConnection() error {
+	client := func() *ethclient.Client {
+		defer coin.connectionLock.RLock()()
+		return coin.client
+	}()
+	if client == nil {
 		coin.log.Infof("connecting to %s", coin.nodeURL)
-		client, err := ethclient.Dial(coin.nodeURL)
+		var err error
+		client, err = ethclient.Dial(coin.nodeURL)
 		if err != nil {
-			// TODO: init conn lazily, feed error via EventStatusChanged
-			panic(err)
+			coin.setConnection(nil)
+			return errp.WithStack(err)
 		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if _, err := client.HeaderByNumber(ctx, nil); err != nil {
+		client.Close()
+		coin.setConnection(nil)
+		return errp.WithStack(err)
+	}
+	coin.setConnection(client)
+	return nil
+}
+
+// setConnection sets the client connected to the node, or nil if there is no connection, and
+// marks the connection as attempted. If the connection status changed, the observers are notified
+// in the background.
+func (coin *Coin) setConnection(client *ethclient.Client) {
+	status := blockchain.CONNECTED
+	if client == nil {
+		status = blockchain.DISCONNECTED
+	}
+	changed := func() bool {
+		defer coin.connectionLock.Lock()()
 		coin.client = client
+		select {
+		case <-coin.connectionAttempted:
+		default:
+			close(coin.connectionAttempted)
+		}
+		if status == coin.connectionStatus {
+			return false
+		}
+		coin.connectionStatus = status
+		return true
+	}()
+	if changed {
+		go coin.notifyConnectionStatus()
+	}
+}
 
-		coin.etherScan = etherscan.NewEtherScan(etherScanURL)
-	})
+// notifyConnectionStatus calls the onConnectionStatusChanged callbacks with the current connection
+// status. The calls are serialized, so the last call always reports the current status.
+func (coin *Coin) notifyConnectionStatus() {
+	defer coin.notifyLock.Lock()()
+	status, callbacks := func() (blockchain.Status, []func(blockchain.Status)) {
+		defer coin.connectionLock.RLock()()
+		return coin.connectionStatus, coin.onConnectionStatusChanged
+	}()
+	for _, callback := range callbacks {
+		callback(status)
+	}
+}
+
+// ConnectionStatus returns the current connection status to the node.
+func (coin *Coin) ConnectionStatus() blockchain.Status {
+	defer coin.connectionLock.RLock()()
+	return coin.connectionStatus
+}
+
+// RegisterOnConnectionStatusChangedEvent registers a callback which is called when the connection
+// status to the node changes. The callbacks are called one after the other on a goroutine of their
+// own, after the connection attempt is done, so they can make requests to the node. They must not
+// block, as that delays the notification of later changes.
+func (coin *Coin) RegisterOnConnectionStatusChangedEvent(onConnectionStatusChanged func(blockchain.Status)) {
+	defer coin.connectionLock.Lock()()
+	coin.onConnectionStatusChanged = append(coin.onConnectionStatusChanged, onConnectionStatusChanged)
+}
+
+// Client returns the client connected to the node, or ErrNotConnected if there is no connection.
+// It waits for the first connection attempt after Initialize.
+func (coin *Coin) Client() (*ethclient.Client, error) {
+	coin.waitConnectionAttempted()
+	defer coin.connectionLock.RLock()()
+	if coin.client == nil {
+		return nil, errp.WithStack(ErrNotConnected)
+	}
+	return coin.client, nil
 }
 
 // Code implements coin.Coin.
@@ -80,19 +265,35 @@ func (coin *Coin) Unit() string {
 	return strings.ToUpper(coin.code)
 }
 
-// FormatAmount implements coin.Coin.
-func (coin *Coin) FormatAmount(amount coin.Amount) string {
-	ether := big.NewInt(1e18)
+// etherDecimals is the number of decimals of one ether in wei.
+const etherDecimals = 18
+
+// formatAmount formats an amount given in the smallest unit with the given number of decimals.
+func formatAmount(amount *big.Int, decimals uint) string {
 	return strings.TrimRight(strings.TrimRight(
-		new(big.Rat).SetFrac(amount.BigInt(), ether).FloatString(18),
+		new(big.Rat).SetFrac(amount, unit(decimals)).FloatString(int(decimals)),
 		"0"), ".")
 }
 
+// toUnit converts an amount given in the smallest unit with the given number of decimals.
+func toUnit(amount *big.Int, decimals uint) float64 {
+	result, _ := new(big.Rat).SetFrac(amount, unit(decimals)).Float64()
+	return result
+}
+
+// unit returns 10^decimals, the amount of one unit in the smallest unit.
+func unit(decimals uint) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+}
+
+// FormatAmount implements coin.Coin.
+func (coin *Coin) FormatAmount(amount coin.Amount) string {
+	return formatAmount(amount.BigInt(), etherDecimals)
+}
+
 // ToUnit implements coin.Coin.
 func (coin *Coin) ToUnit(amount coin.Amount) float64 {
-	ether := big.NewInt(1e18)
-	result, _ := new(big.Rat).SetFrac(amount.BigInt(), ether).Float64()
-	return result
+	return toUnit(amount.BigInt(), etherDecimals)
 }
 
 // BlockExplorerTransactionURLPrefix implements coin.Coin.
@@ -100,9 +301,13 @@ func (coin *Coin) BlockExplorerTransactionURLPrefix() string {
 	return coin.blockExplorerTxPrefix
 }
 
-// EtherScan returns an instance of EtherScan.
-func (coin *Coin) EtherScan() *etherscan.EtherScan {
-	return coin.etherScan
+// Indexer returns the indexer providing the transaction history. Indexers using the node fail with
+// ErrNotConnected while there is no connection to it.
+func (coin *Coin) Indexer() (Indexer, error) {
+	if coin.indexer == nil {
+		return nil, errp.New("the coin has no indexer")
+	}
+	return coin.indexer, nil
 }
 
 func (coin *Coin) String() string {
@@ -0,0 +1,100 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// testIndexer is an indexer which does not use the node.
+type testIndexer struct {
+	Indexer
+}
+
+// TestUnreachableNode checks that a node which is down does not crash the coin, but is reported
+// as disconnected.
+func TestUnreachableNode(t *testing.T) {
+	var client NodeClient
+	coin := NewCoin("teth", params.TestnetChainConfig, "", "http://127.0.0.1:1",
+		func(nodeClient NodeClient) Indexer {
+			client = nodeClient
+			return testIndexer{}
+		})
+	defer coin.Close()
+	statuses := make(chan blockchain.Status, 1)
+	coin.RegisterOnConnectionStatusChangedEvent(func(status blockchain.Status) {
+		statuses <- status
+	})
+	coin.Initialize()
+
+	_, err := coin.Client()
+	require.Equal(t, ErrNotConnected, errp.Cause(err))
+	_, err = client.HeaderByNumber(context.Background(), nil)
+	require.Equal(t, ErrNotConnected, errp.Cause(err))
+	// The indexer does not depend on the connection.
+	indexer, err := coin.Indexer()
+	require.NoError(t, err)
+	require.Equal(t, testIndexer{}, indexer)
+	require.Equal(t, blockchain.DISCONNECTED, coin.ConnectionStatus())
+	// The coin starts out disconnected, so no change is reported.
+	require.Empty(t, statuses)
+}
+
+// TestNotInitialized checks that requests do not wait for a connection which is never attempted.
+func TestNotInitialized(t *testing.T) {
+	coin := NewCoin("teth", params.TestnetChainConfig, "", "http://127.0.0.1:1", nil)
+	_, err := coin.Client()
+	require.Equal(t, ErrNotConnected, errp.Cause(err))
+	_, err = coin.Indexer()
+	require.Error(t, err)
+
+	// Requests do not wait for a closed coin either.
+	coin.Close()
+	coin.Initialize()
+	_, err = coin.Client()
+	require.Equal(t, ErrNotConnected, errp.Cause(err))
+}
+
+// TestConnectionStatusCallback checks that the callbacks can make requests waiting for the
+// connection.
+func TestConnectionStatusCallback(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	node := newTestNode(t, 0, release)
+	defer node.Close()
+	coin := NewCoin("teth", params.TestnetChainConfig, "", node.URL, nil)
+	defer coin.Close()
+	connected := make(chan error, 1)
+	coin.RegisterOnConnectionStatusChangedEvent(func(status blockchain.Status) {
+		if status == blockchain.CONNECTED {
+			_, err := coin.Client()
+			connected <- err
+		}
+	})
+	coin.Initialize()
+	select {
+	case err := <-connected:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timeout waiting for the connection")
+	}
+}
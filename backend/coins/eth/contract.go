@@ -0,0 +1,243 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	ethtypes "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+var bigIntType = reflect.TypeOf(&big.Int{})
+
+// EncodeContractCall returns the calldata of calling the method of the contract with the given
+// JSON ABI, together with the decoded calldata to preview the call. The arguments are given as
+// strings: numbers in decimal or 0x-prefixed hex, byte strings and addresses in hex, arrays as JSON
+// arrays.
+func EncodeContractCall(abiJSON string, method string, arguments []string) (
+	[]byte, *ethtypes.ContractCall, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, nil, errp.WithStack(err)
+	}
+	abiMethod, ok := parsedABI.Methods[method]
+	if !ok {
+		return nil, nil, errp.Newf("unknown method %s", method)
+	}
+	if len(arguments) != len(abiMethod.Inputs) {
+		return nil, nil, errp.Newf("%s expects %d arguments", method, len(abiMethod.Inputs))
+	}
+	values := make([]interface{}, len(arguments))
+	for index, input := range abiMethod.Inputs {
+		value, err := parseArgument(input.Type, arguments[index])
+		if err != nil {
+			return nil, nil, errp.WithMessage(err, fmt.Sprintf("invalid argument %s", input.Name))
+		}
+		values[index] = value.Interface()
+	}
+	data, err := parsedABI.Pack(method, values...)
+	if err != nil {
+		return nil, nil, errp.WithStack(err)
+	}
+	// The preview is decoded from the calldata, so it shows exactly what is sent.
+	contractCall, ok := decodeContractCall(parsedABI, data)
+	if !ok {
+		return nil, nil, errp.New("could not decode the encoded calldata")
+	}
+	return data, contractCall, nil
+}
+
+// DecodeContractCall decodes the calldata of a call to the contract with the given JSON ABI. The
+// last return value is false if the calldata does not match a method of the ABI.
+func DecodeContractCall(abiJSON string, data []byte) (*ethtypes.ContractCall, bool) {
+	parsedABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, false
+	}
+	return decodeContractCall(parsedABI, data)
+}
+
+func decodeContractCall(parsedABI abi.ABI, data []byte) (*ethtypes.ContractCall, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	method, err := parsedABI.MethodById(data[:4])
+	if err != nil {
+		return nil, false
+	}
+	values, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil || len(values) != len(method.Inputs) {
+		return nil, false
+	}
+	contractCall := &ethtypes.ContractCall{
+		Method:    method.Sig(),
+		Arguments: make([]ethtypes.ContractCallArgument, len(values)),
+	}
+	for index, input := range method.Inputs {
+		contractCall.Arguments[index] = ethtypes.ContractCallArgument{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: formatArgument(reflect.ValueOf(values[index])),
+		}
+	}
+	return contractCall, true
+}
+
+// decodeERC20Transfer decodes the calldata of an ERC20 `transfer()` call, which is known without
+// storing the ABI of the token contract.
+func decodeERC20Transfer(data []byte) (*ethtypes.ContractCall, bool) {
+	recipient, amount, ok := erc20.DecodeTransferData(data)
+	if !ok {
+		return nil, false
+	}
+	return &ethtypes.ContractCall{
+		Method: "transfer(address,uint256)",
+		Arguments: []ethtypes.ContractCallArgument{
+			{Name: "_to", Type: "address", Value: recipient.Hex()},
+			{Name: "_value", Type: "uint256", Value: amount.String()},
+		},
+	}, true
+}
+
+// parseArgument converts the string representation of an argument to the Go type expected by the
+// abi package for the given type.
+func parseArgument(typ abi.Type, argument string) (reflect.Value, error) {
+	argument = strings.TrimSpace(argument)
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		number, ok := math.ParseBig256(argument)
+		if !ok || argument == "" {
+			return reflect.Value{}, errp.Newf("invalid integer %s", argument)
+		}
+		if typ.T == abi.UintTy && number.Sign() < 0 {
+			return reflect.Value{}, errp.Newf("negative value %s", argument)
+		}
+		if typ.Type == bigIntType {
+			return reflect.ValueOf(number), nil
+		}
+		value := reflect.New(typ.Type).Elem()
+		if typ.T == abi.UintTy {
+			if !number.IsUint64() || value.OverflowUint(number.Uint64()) {
+				return reflect.Value{}, errp.Newf("%s out of range", argument)
+			}
+			value.SetUint(number.Uint64())
+		} else {
+			if !number.IsInt64() || value.OverflowInt(number.Int64()) {
+				return reflect.Value{}, errp.Newf("%s out of range", argument)
+			}
+			value.SetInt(number.Int64())
+		}
+		return value, nil
+	case abi.BoolTy:
+		boolean, err := strconv.ParseBool(argument)
+		if err != nil {
+			return reflect.Value{}, errp.WithStack(err)
+		}
+		return reflect.ValueOf(boolean), nil
+	case abi.StringTy:
+		return reflect.ValueOf(argument), nil
+	case abi.AddressTy:
+		if !common.IsHexAddress(argument) {
+			return reflect.Value{}, errp.Newf("invalid address %s", argument)
+		}
+		return reflect.ValueOf(common.HexToAddress(argument)), nil
+	case abi.BytesTy:
+		data, err := hexutil.Decode(argument)
+		if err != nil {
+			return reflect.Value{}, errp.WithStack(err)
+		}
+		return reflect.ValueOf(data), nil
+	case abi.FixedBytesTy:
+		data, err := hexutil.Decode(argument)
+		if err != nil {
+			return reflect.Value{}, errp.WithStack(err)
+		}
+		if len(data) != typ.Size {
+			return reflect.Value{}, errp.Newf("expected %d bytes", typ.Size)
+		}
+		value := reflect.New(typ.Type).Elem()
+		reflect.Copy(value, reflect.ValueOf(data))
+		return value, nil
+	case abi.SliceTy, abi.ArrayTy:
+		var elements []json.RawMessage
+		if err := json.Unmarshal([]byte(argument), &elements); err != nil {
+			return reflect.Value{}, errp.WithStack(err)
+		}
+		var value reflect.Value
+		if typ.T == abi.SliceTy {
+			value = reflect.MakeSlice(typ.Type, len(elements), len(elements))
+		} else {
+			if len(elements) != typ.Size {
+				return reflect.Value{}, errp.Newf("expected %d elements", typ.Size)
+			}
+			value = reflect.New(typ.Type).Elem()
+		}
+		for index, element := range elements {
+			// Elements are JSON strings or plain JSON numbers and booleans.
+			var elementArgument string
+			if err := json.Unmarshal(element, &elementArgument); err != nil {
+				elementArgument = string(element)
+			}
+			elementValue, err := parseArgument(*typ.Elem, elementArgument)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			value.Index(index).Set(elementValue)
+		}
+		return value, nil
+	default:
+		return reflect.Value{}, errp.Newf("unsupported type %s", typ.String())
+	}
+}
+
+// formatArgument returns the human-readable representation of a decoded argument.
+func formatArgument(value reflect.Value) string {
+	switch specificValue := value.Interface().(type) {
+	case common.Address:
+		return specificValue.Hex()
+	case *big.Int:
+		return specificValue.String()
+	case []byte:
+		return hexutil.Encode(specificValue)
+	}
+	switch value.Kind() {
+	case reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(data), value)
+			return hexutil.Encode(data)
+		}
+		fallthrough
+	case reflect.Slice:
+		elements := make([]string, value.Len())
+		for index := range elements {
+			elements[index] = formatArgument(value.Index(index))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
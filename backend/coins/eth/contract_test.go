@@ -0,0 +1,143 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	ethtypes "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+const testContractABI = `[
+	{"constant":false,"inputs":[
+		{"name":"to","type":"address"},
+		{"name":"amount","type":"uint256"},
+		{"name":"flags","type":"uint8"},
+		{"name":"approved","type":"bool"},
+		{"name":"memo","type":"string"},
+		{"name":"payload","type":"bytes"},
+		{"name":"tag","type":"bytes4"},
+		{"name":"cosigners","type":"address[]"},
+		{"name":"limits","type":"uint256[2]"}
+	],"name":"deposit","outputs":[],"type":"function"},
+	{"constant":false,"inputs":[],"name":"withdraw","outputs":[],"type":"function"}
+]`
+
+func TestEncodeContractCall(t *testing.T) {
+	to := common.HexToAddress("0x04f264cf34440313b4a0192a352814fbe927b885")
+	cosigner := common.HexToAddress("0xd8da6bf26964af9d7eed9e03e53415d37aa96045")
+	data, contractCall, err := EncodeContractCall(testContractABI, "deposit", []string{
+		to.Hex(),
+		"1000000000000000000",
+		"0x10",
+		"true",
+		"hello",
+		"0x0102",
+		"0xdeadbeef",
+		`["` + cosigner.Hex() + `"]`,
+		`[1, "2"]`,
+	})
+	require.NoError(t, err)
+
+	parsedABI, err := abi.JSON(strings.NewReader(testContractABI))
+	require.NoError(t, err)
+	expectedData, err := parsedABI.Pack("deposit",
+		to, big.NewInt(1e18), uint8(16), true, "hello", []byte{1, 2},
+		[4]byte{0xde, 0xad, 0xbe, 0xef}, []common.Address{cosigner},
+		[2]*big.Int{big.NewInt(1), big.NewInt(2)})
+	require.NoError(t, err)
+	require.Equal(t, expectedData, data)
+
+	require.Equal(t, &ethtypes.ContractCall{
+		Method: "deposit(address,uint256,uint8,bool,string,bytes,bytes4,address[],uint256[2])",
+		Arguments: []ethtypes.ContractCallArgument{
+			{Name: "to", Type: "address", Value: to.Hex()},
+			{Name: "amount", Type: "uint256", Value: "1000000000000000000"},
+			{Name: "flags", Type: "uint8", Value: "16"},
+			{Name: "approved", Type: "bool", Value: "true"},
+			{Name: "memo", Type: "string", Value: "hello"},
+			{Name: "payload", Type: "bytes", Value: "0x0102"},
+			{Name: "tag", Type: "bytes4", Value: "0xdeadbeef"},
+			{Name: "cosigners", Type: "address[]", Value: "[" + cosigner.Hex() + "]"},
+			{Name: "limits", Type: "uint256[2]", Value: "[1, 2]"},
+		},
+	}, contractCall)
+
+	decoded, ok := DecodeContractCall(testContractABI, data)
+	require.True(t, ok)
+	require.Equal(t, contractCall, decoded)
+
+	data, contractCall, err = EncodeContractCall(testContractABI, "withdraw", nil)
+	require.NoError(t, err)
+	require.Len(t, data, 4)
+	require.Equal(t, "withdraw()", contractCall.Method)
+}
+
+func TestEncodeContractCallInvalid(t *testing.T) {
+	_, _, err := EncodeContractCall("not an abi", "deposit", nil)
+	require.Error(t, err)
+	_, _, err = EncodeContractCall(testContractABI, "unknown", nil)
+	require.Error(t, err)
+	_, _, err = EncodeContractCall(testContractABI, "withdraw", []string{"1"})
+	require.Error(t, err)
+	valid := []string{
+		"0x04f264cf34440313b4a0192a352814fbe927b885", "1", "1", "true", "", "0x", "0x00000000",
+		"[]", "[1, 2]",
+	}
+	// Invalid values by argument index. Any string is a valid memo.
+	for index, invalid := range map[int]string{
+		0: "0x01", 1: "-1", 2: "256", 3: "yes", 5: "01", 6: "0x00", 7: `["0x01"]`, 8: "[1]",
+	} {
+		arguments := append([]string{}, valid...)
+		arguments[index] = invalid
+		_, _, err := EncodeContractCall(testContractABI, "deposit", arguments)
+		require.Error(t, err, invalid)
+	}
+	_, _, err = EncodeContractCall(testContractABI, "deposit", valid)
+	require.NoError(t, err)
+}
+
+func TestDecodeTransactionData(t *testing.T) {
+	contract := common.HexToAddress("0x04f264cf34440313b4a0192a352814fbe927b885")
+	recipient := common.HexToAddress("0xd8da6bf26964af9d7eed9e03e53415d37aa96045")
+	data, contractCall, err := EncodeContractCall(testContractABI, "withdraw", nil)
+	require.NoError(t, err)
+	transaction := func(data []byte) wrappedTransaction {
+		return wrappedTransaction{
+			tx: types.NewTransaction(0, contract, big.NewInt(0), 21000, big.NewInt(1), data),
+		}
+	}
+	contractABIs := map[common.Address]string{contract: testContractABI}
+
+	require.Equal(t, contractCall, decodeTransactionData(transaction(data), contractABIs))
+	// Unknown ABI.
+	require.Nil(t, decodeTransactionData(transaction(data), nil))
+	// Plain ether transfer.
+	require.Nil(t, decodeTransactionData(transaction(nil), contractABIs))
+	// ERC20 transfers are decoded without a stored ABI.
+	transferCall := decodeTransactionData(
+		transaction(erc20.TransferData(recipient, big.NewInt(5))), nil)
+	require.NotNil(t, transferCall)
+	require.Equal(t, "transfer(address,uint256)", transferCall.Method)
+	require.Equal(t, recipient.Hex(), transferCall.Arguments[0].Value)
+	require.Equal(t, "5", transferCall.Arguments[1].Value)
+}
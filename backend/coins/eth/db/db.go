@@ -20,12 +20,16 @@ import (
 
 	bbolt "github.com/coreos/bbolt"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
 const (
 	bucketPendingOutgoingTransactions = "pendingTransactions"
+	bucketTxNotes                     = "txNotes"
+	bucketReplacedTransactions        = "replacedTransactions"
+	bucketContractABIs                = "contractABIs"
 )
 
 // DB is a bbolt key/value database.
@@ -52,9 +56,24 @@ func (db *DB) Begin() (TxInterface, error) {
 	if err != nil {
 		return nil, err
 	}
+	bucketTxNotes, err := tx.CreateBucketIfNotExists([]byte(bucketTxNotes))
+	if err != nil {
+		return nil, err
+	}
+	bucketReplacedTransactions, err := tx.CreateBucketIfNotExists([]byte(bucketReplacedTransactions))
+	if err != nil {
+		return nil, err
+	}
+	bucketContractABIs, err := tx.CreateBucketIfNotExists([]byte(bucketContractABIs))
+	if err != nil {
+		return nil, err
+	}
 	return &Tx{
 		tx:                                tx,
 		bucketPendingOutgoingTransactions: bucketPendingOutgoingTransactions,
+		bucketTxNotes:                     bucketTxNotes,
+		bucketReplacedTransactions:        bucketReplacedTransactions,
+		bucketContractABIs:                bucketContractABIs,
 	}, nil
 }
 
@@ -68,6 +87,9 @@ type Tx struct {
 	tx *bbolt.Tx
 
 	bucketPendingOutgoingTransactions *bbolt.Bucket
+	bucketTxNotes                     *bbolt.Bucket
+	bucketReplacedTransactions        *bbolt.Bucket
+	bucketContractABIs                *bbolt.Bucket
 }
 
 // Rollback implements DBTxInterface.
@@ -90,6 +112,11 @@ func (tx *Tx) PutPendingOutgoingTransaction(transaction *types.Transaction) erro
 	return tx.bucketPendingOutgoingTransactions.Put(transaction.Hash().Bytes(), txSerialized)
 }
 
+// DeletePendingOutgoingTransaction implements DBTxInterface.
+func (tx *Tx) DeletePendingOutgoingTransaction(txHash common.Hash) error {
+	return tx.bucketPendingOutgoingTransactions.Delete(txHash.Bytes())
+}
+
 type byNonce []*types.Transaction
 
 func (txs byNonce) Len() int           { return len(txs) }
@@ -113,3 +140,51 @@ func (tx *Tx) PendingOutgoingTransactions() ([]*types.Transaction, error) {
 	sort.Sort(sort.Reverse(byNonce(transactions)))
 	return transactions, nil
 }
+
+// PutTxNote implements DBTxInterface.
+func (tx *Tx) PutTxNote(txHash common.Hash, note string) error {
+	if note == "" {
+		return tx.bucketTxNotes.Delete(txHash.Bytes())
+	}
+	return tx.bucketTxNotes.Put(txHash.Bytes(), []byte(note))
+}
+
+// TxNotes implements DBTxInterface.
+func (tx *Tx) TxNotes() (map[common.Hash]string, error) {
+	notes := map[common.Hash]string{}
+	cursor := tx.bucketTxNotes.Cursor()
+	for txHash, note := cursor.First(); txHash != nil; txHash, note = cursor.Next() {
+		notes[common.BytesToHash(txHash)] = string(note)
+	}
+	return notes, nil
+}
+
+// PutReplacedTransaction implements DBTxInterface.
+func (tx *Tx) PutReplacedTransaction(replacedTxHash common.Hash, replacementTxHash common.Hash) error {
+	return tx.bucketReplacedTransactions.Put(replacementTxHash.Bytes(), replacedTxHash.Bytes())
+}
+
+// ReplacedTransactions implements DBTxInterface.
+func (tx *Tx) ReplacedTransactions() (map[common.Hash]common.Hash, error) {
+	replacedTransactions := map[common.Hash]common.Hash{}
+	cursor := tx.bucketReplacedTransactions.Cursor()
+	for replacementTxHash, replacedTxHash := cursor.First(); replacementTxHash != nil; replacementTxHash, replacedTxHash = cursor.Next() {
+		replacedTransactions[common.BytesToHash(replacementTxHash)] = common.BytesToHash(replacedTxHash)
+	}
+	return replacedTransactions, nil
+}
+
+// PutContractABI implements DBTxInterface.
+func (tx *Tx) PutContractABI(contractAddress common.Address, abiJSON string) error {
+	return tx.bucketContractABIs.Put(contractAddress.Bytes(), []byte(abiJSON))
+}
+
+// ContractABIs implements DBTxInterface.
+func (tx *Tx) ContractABIs() (map[common.Address]string, error) {
+	contractABIs := map[common.Address]string{}
+	cursor := tx.bucketContractABIs.Cursor()
+	for contractAddress, abiJSON := cursor.First(); contractAddress != nil; contractAddress, abiJSON = cursor.Next() {
+		contractABIs[common.BytesToAddress(contractAddress)] = string(abiJSON)
+	}
+	return contractABIs, nil
+}
@@ -14,7 +14,10 @@
 
 package db
 
-import "github.com/ethereum/go-ethereum/core/types"
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
 
 // TxInterface needs to be implemented to persist all wallet/transaction related data.
 type TxInterface interface {
@@ -28,9 +31,34 @@ type TxInterface interface {
 	// transactions.
 	PutPendingOutgoingTransaction(*types.Transaction) error
 
+	// DeletePendingOutgoingTransaction removes the transaction with the given hash from the
+	// collection of pending outgoing transactions.
+	DeletePendingOutgoingTransaction(common.Hash) error
+
 	// PendingOutgoingTransactions returns the stored list of pending outgoing transactions, sorted
 	// descending by the transaction nonce.
 	PendingOutgoingTransactions() ([]*types.Transaction, error)
+
+	// PutTxNote stores the user defined note of a transaction. An empty note removes it.
+	PutTxNote(common.Hash, string) error
+
+	// TxNotes returns the notes of all annotated transactions.
+	TxNotes() (map[common.Hash]string, error)
+
+	// PutReplacedTransaction records that the first transaction was replaced by the second one,
+	// which has the same nonce.
+	PutReplacedTransaction(replacedTxHash common.Hash, replacementTxHash common.Hash) error
+
+	// ReplacedTransactions maps the hashes of replacement transactions to the hashes of the
+	// transactions they replaced.
+	ReplacedTransactions() (map[common.Hash]common.Hash, error)
+
+	// PutContractABI stores the JSON ABI of the contract at the given address, used to decode the
+	// calldata of calls to the contract.
+	PutContractABI(contractAddress common.Address, abiJSON string) error
+
+	// ContractABIs returns the stored JSON ABIs by contract address.
+	ContractABIs() (map[common.Address]string, error)
 }
 
 // Interface can be implemented by database backends to open database transactions.
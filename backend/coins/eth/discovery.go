@@ -0,0 +1,59 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stateReader is the part of ethclient.Client used to check whether an address has been used.
+type stateReader interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// HasHistory returns whether the address of the account with the given signing configuration has
+// been used, i.e. whether it has sent a transaction or holds ether. An address which has only
+// received tokens is not detected.
+func HasHistory(coin *Coin, configuration *signing.Configuration) (bool, error) {
+	coin.Initialize()
+	client, err := coin.Client()
+	if err != nil {
+		return false, err
+	}
+	address := crypto.PubkeyToAddress(*configuration.PublicKeys()[0].ToECDSA())
+	return hasHistory(client, address)
+}
+
+func hasHistory(client stateReader, address common.Address) (bool, error) {
+	nonce, err := client.NonceAt(context.TODO(), address, nil)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	if nonce > 0 {
+		return true, nil
+	}
+	balance, err := client.BalanceAt(context.TODO(), address, nil)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	return balance.Sign() > 0, nil
+}
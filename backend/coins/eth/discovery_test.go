@@ -0,0 +1,52 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type stateMock struct {
+	nonces   map[common.Address]uint64
+	balances map[common.Address]int64
+}
+
+func (mock *stateMock) NonceAt(_ context.Context, account common.Address, _ *big.Int) (uint64, error) {
+	return mock.nonces[account], nil
+}
+
+func (mock *stateMock) BalanceAt(_ context.Context, account common.Address, _ *big.Int) (*big.Int, error) {
+	return big.NewInt(mock.balances[account]), nil
+}
+
+func TestHasHistory(t *testing.T) {
+	sender := common.HexToAddress("0x1")
+	holder := common.HexToAddress("0x2")
+	unused := common.HexToAddress("0x3")
+	mock := &stateMock{
+		nonces:   map[common.Address]uint64{sender: 2},
+		balances: map[common.Address]int64{holder: 1},
+	}
+	for address, expected := range map[common.Address]bool{sender: true, holder: true, unused: false} {
+		used, err := hasHistory(mock, address)
+		require.NoError(t, err)
+		require.Equal(t, expected, used, address.Hex())
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ensABI contains the registry and resolver functions needed to resolve a name to an address, see
+// https://eips.ethereum.org/EIPS/eip-137.
+const ensABI = `[
+	{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver",
+	 "outputs":[{"name":"","type":"address"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"addr",
+	 "outputs":[{"name":"","type":"address"}],"type":"function"}
+]`
+
+var parsedENSABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(ensABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// ensRegistryAddress is the address of the ENS registry with fallback, which replaced the original
+// registry. It is deployed at the same address on all chains in ensChainIDs.
+var ensRegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// ensChainIDs are the IDs of the chains ENS names can be resolved on.
+var ensChainIDs = map[uint64]bool{
+	params.MainnetChainConfig.ChainID.Uint64(): true,
+	params.TestnetChainConfig.ChainID.Uint64(): true,
+	params.RinkebyChainConfig.ChainID.Uint64(): true,
+}
+
+// ensNameProfile maps labels for lookup as defined by UTS #46 without the transitional mapping,
+// e.g. it lowercases letters and keeps "ß", and rejects disallowed characters and hyphens in the
+// wrong places.
+var ensNameProfile = idna.New(idna.MapForLookup(), idna.BidiRule())
+
+// ensScriptGroups are the scripts which can be mixed within a label, as they are commonly written
+// together.
+var ensScriptGroups = [][]string{
+	{"Han", "Hiragana", "Katakana"},
+	{"Han", "Hangul"},
+	{"Han", "Bopomofo"},
+}
+
+// isENSName returns whether the recipient looks like an ENS name, e.g. `name.eth`, as opposed to a
+// hex address.
+func isENSName(recipient string) bool {
+	return strings.Contains(recipient, ".") && !strings.HasPrefix(recipient, "0x")
+}
+
+// labelScripts returns the scripts of the letters of the label. Characters shared by scripts, like
+// digits and hyphens, are not included.
+func labelScripts(label string) map[string]bool {
+	scripts := map[string]bool{}
+	for _, r := range label {
+		if unicode.In(r, unicode.Common, unicode.Inherited) {
+			continue
+		}
+		for script, table := range unicode.Scripts {
+			if unicode.Is(table, r) {
+				scripts[script] = true
+				break
+			}
+		}
+	}
+	return scripts
+}
+
+// mixesScripts returns whether the label contains letters of scripts which are not commonly written
+// together, e.g. a Cyrillic "а" in an otherwise Latin label, as used to imitate other names.
+func mixesScripts(label string) bool {
+	scripts := labelScripts(label)
+	if len(scripts) <= 1 {
+		return false
+	}
+	for _, group := range ensScriptGroups {
+		inGroup := 0
+		for _, script := range group {
+			if scripts[script] {
+				inGroup++
+			}
+		}
+		if inGroup == len(scripts) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeENSName normalizes the name following ENSIP-15: the labels are mapped as defined by
+// UTS #46, and names with invalid labels are rejected. Underscores are only allowed at the start of
+// a label, and ASCII labels with "--" at the third and fourth position, like punycode labels, are
+// rejected. Labels mixing scripts are rejected, but confusable labels written in a single script
+// are not detected. Unlike ENSIP-15, labels starting or ending with a hyphen are rejected as well.
+func normalizeENSName(name string) (string, error) {
+	labels := strings.Split(name, ".")
+	for index, label := range labels {
+		folded := strings.ToLower(norm.NFKC.String(label))
+		if len(folded) >= 4 && folded[2:4] == "--" {
+			return "", errp.Newf("invalid label %q", label)
+		}
+		underscores := len(label) - len(strings.TrimLeft(label, "_"))
+		mapped, err := ensNameProfile.ToUnicode(label[underscores:])
+		if err != nil {
+			return "", errp.WithStack(err)
+		}
+		if (mapped == "" && underscores == 0) || strings.Contains(mapped, ".") {
+			return "", errp.Newf("invalid label %q", label)
+		}
+		if mixesScripts(mapped) {
+			return "", errp.Newf("label %q mixes scripts", label)
+		}
+		labels[index] = label[:underscores] + mapped
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// ensNamehash computes the node of the normalized name as defined in EIP-137.
+func ensNamehash(name string) common.Hash {
+	node := common.Hash{}
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node[:], crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// ensCall calls the view function `method(node)` of the ENS contract and returns the address the
+// function returns.
+func ensCall(
+	caller ethereum.ContractCaller,
+	contractAddress common.Address,
+	method string,
+	node common.Hash,
+) (common.Address, error) {
+	data, err := parsedENSABI.Pack(method, node)
+	if err != nil {
+		return common.Address{}, errp.WithStack(err)
+	}
+	output, err := caller.CallContract(
+		context.TODO(), ethereum.CallMsg{To: &contractAddress, Data: data}, nil)
+	if err != nil {
+		return common.Address{}, errp.WithStack(err)
+	}
+	var address common.Address
+	if err := parsedENSABI.Unpack(&address, method, output); err != nil {
+		return common.Address{}, errp.WithStack(err)
+	}
+	return address, nil
+}
+
+// resolveENSName normalizes the name, looks up its resolver in the registry and queries the
+// resolver for the address of the name. errors.ErrInvalidAddress is returned if the name is invalid
+// or cannot be resolved.
+func resolveENSName(
+	caller ethereum.ContractCaller,
+	registryAddress common.Address,
+	name string,
+	log *logrus.Entry,
+) (common.Address, error) {
+	log = log.WithField("name", name)
+	normalizedName, err := normalizeENSName(name)
+	if err != nil {
+		log.WithError(err).Info("Invalid ENS name")
+		return common.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	node := ensNamehash(normalizedName)
+	resolverAddress, err := ensCall(caller, registryAddress, "resolver", node)
+	if err != nil {
+		log.WithError(err).Error("Could not query the ENS resolver")
+		return common.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	if resolverAddress == (common.Address{}) {
+		log.Info("No ENS resolver set")
+		return common.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	address, err := ensCall(caller, resolverAddress, "addr", node)
+	if err != nil {
+		log.WithError(err).Error("Could not query the ENS address")
+		return common.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	if address == (common.Address{}) {
+		log.Info("No address set for the ENS name")
+		return common.Address{}, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	return address, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package erc20 provides the contract calls needed to hold and transfer ERC20 tokens.
+package erc20
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// erc20ABI is the part of the ERC20 interface used by the wallet, see
+// https://eips.ethereum.org/EIPS/eip-20.
+const erc20ABI = `[
+	{"constant":true,"inputs":[{"name":"_owner","type":"address"}],"name":"balanceOf",
+	 "outputs":[{"name":"balance","type":"uint256"}],"type":"function"},
+	{"constant":false,"inputs":[{"name":"_to","type":"address"},{"name":"_value","type":"uint256"}],
+	 "name":"transfer","outputs":[{"name":"success","type":"bool"}],"type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"_from","type":"address"},
+	 {"indexed":true,"name":"_to","type":"address"},{"indexed":false,"name":"_value","type":"uint256"}],
+	 "name":"Transfer","type":"event"}
+]`
+
+var parsedABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// TransferEventID is the topic identifying `Transfer(from, to, value)` event logs.
+var TransferEventID = parsedABI.Events["Transfer"].Id()
+
+// Token holds the information needed to handle an ERC20 token.
+type Token struct {
+	contractAddress common.Address
+	symbol          string
+	decimals        uint
+}
+
+// NewToken creates a new token with the given contract address, symbol (e.g. "USDT") and number of
+// decimals.
+func NewToken(contractAddress common.Address, symbol string, decimals uint) *Token {
+	return &Token{
+		contractAddress: contractAddress,
+		symbol:          symbol,
+		decimals:        decimals,
+	}
+}
+
+// ContractAddress returns the address of the token contract.
+func (token *Token) ContractAddress() common.Address {
+	return token.contractAddress
+}
+
+// Symbol returns the ticker symbol of the token.
+func (token *Token) Symbol() string {
+	return token.symbol
+}
+
+// Decimals returns the number of decimals of the token. An amount of 1 token is 10^decimals in the
+// smallest unit.
+func (token *Token) Decimals() uint {
+	return token.decimals
+}
+
+// BalanceOfData returns the calldata of `balanceOf(owner)`.
+func BalanceOfData(owner common.Address) []byte {
+	data, err := parsedABI.Pack("balanceOf", owner)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// UnpackBalanceOf decodes the result of a `balanceOf()` call.
+func UnpackBalanceOf(output []byte) (*big.Int, error) {
+	var balance *big.Int
+	if err := parsedABI.Unpack(&balance, "balanceOf", output); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return balance, nil
+}
+
+// TransferData returns the calldata of `transfer(recipient, amount)`.
+func TransferData(recipient common.Address, amount *big.Int) []byte {
+	data, err := parsedABI.Pack("transfer", recipient, amount)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// DecodeTransferData decodes the recipient and the amount of `transfer()` calldata. The last return
+// value is false if the data is not a transfer call.
+func DecodeTransferData(data []byte) (common.Address, *big.Int, bool) {
+	method := parsedABI.Methods["transfer"]
+	if len(data) < 4 || !strings.EqualFold(common.Bytes2Hex(data[:4]), common.Bytes2Hex(method.Id())) {
+		return common.Address{}, nil, false
+	}
+	values, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil || len(values) != 2 {
+		return common.Address{}, nil, false
+	}
+	recipient, ok := values[0].(common.Address)
+	if !ok {
+		return common.Address{}, nil, false
+	}
+	amount, ok := values[1].(*big.Int)
+	if !ok {
+		return common.Address{}, nil, false
+	}
+	return recipient, amount, true
+}
+
+// DecodeTransferLog decodes the sender, the recipient and the amount of a `Transfer` event log. The
+// last return value is false if the log is not a transfer event.
+func DecodeTransferLog(log *types.Log) (common.Address, common.Address, *big.Int, bool) {
+	if len(log.Topics) != 3 || log.Topics[0] != TransferEventID || len(log.Data) != common.HashLength {
+		return common.Address{}, common.Address{}, nil, false
+	}
+	return common.BytesToAddress(log.Topics[1].Bytes()),
+		common.BytesToAddress(log.Topics[2].Bytes()),
+		new(big.Int).SetBytes(log.Data),
+		true
+}
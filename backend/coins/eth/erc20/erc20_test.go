@@ -0,0 +1,80 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package erc20_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+var recipient = common.HexToAddress("0x2dD1Fd1a1E6Ec5e95aB0E4E8F3b5e0B2A1E9B43f")
+
+func TestBalanceOf(t *testing.T) {
+	require.Equal(t,
+		"70a08231"+"0000000000000000000000002dd1fd1a1e6ec5e95ab0e4e8f3b5e0b2a1e9b43f",
+		common.Bytes2Hex(erc20.BalanceOfData(recipient)))
+	balance, err := erc20.UnpackBalanceOf(common.Hex2Bytes(
+		"00000000000000000000000000000000000000000000000000000000000f4240"))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000000), balance)
+}
+
+func TestTransfer(t *testing.T) {
+	data := erc20.TransferData(recipient, big.NewInt(1000000))
+	require.Equal(t,
+		"a9059cbb"+
+			"0000000000000000000000002dd1fd1a1e6ec5e95ab0e4e8f3b5e0b2a1e9b43f"+
+			"00000000000000000000000000000000000000000000000000000000000f4240",
+		common.Bytes2Hex(data))
+
+	decodedRecipient, amount, ok := erc20.DecodeTransferData(data)
+	require.True(t, ok)
+	require.Equal(t, recipient, decodedRecipient)
+	require.Equal(t, big.NewInt(1000000), amount)
+
+	_, _, ok = erc20.DecodeTransferData(erc20.BalanceOfData(recipient))
+	require.False(t, ok)
+	_, _, ok = erc20.DecodeTransferData(nil)
+	require.False(t, ok)
+}
+
+func TestTransferLog(t *testing.T) {
+	require.Equal(t,
+		"ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+		common.Bytes2Hex(erc20.TransferEventID.Bytes()))
+	sender := common.HexToAddress("0x773a1FD2A1e9CB8F1C5B0D5C6a4D0B2e2BbD8E31")
+	log := &types.Log{
+		Topics: []common.Hash{
+			erc20.TransferEventID,
+			common.BytesToHash(sender.Bytes()),
+			common.BytesToHash(recipient.Bytes()),
+		},
+		Data: common.LeftPadBytes(big.NewInt(1000000).Bytes(), common.HashLength),
+	}
+	decodedSender, decodedRecipient, amount, ok := erc20.DecodeTransferLog(log)
+	require.True(t, ok)
+	require.Equal(t, sender, decodedSender)
+	require.Equal(t, recipient, decodedRecipient)
+	require.Equal(t, big.NewInt(1000000), amount)
+
+	log.Topics = log.Topics[:2]
+	_, _, _, ok = erc20.DecodeTransferLog(log)
+	require.False(t, ok)
+}
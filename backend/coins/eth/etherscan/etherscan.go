@@ -14,12 +14,14 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // etherscan rate limits to one request per 0.2 seconds.
 var callInterval = 210 * time.Millisecond
 
-// EtherScan is a rate-limited etherscan api client. See https://etherscan.io/apis.
+// EtherScan is a rate-limited etherscan api client. See https://etherscan.io/apis. It implements
+// eth.Indexer.
 type EtherScan struct {
 	url         string
 	rateLimiter <-chan time.Time
@@ -103,7 +105,11 @@ type jsonTransaction struct {
 	ContractAddressAsString string `json:"contractAddress"`
 	contractAddress         *common.Address
 
-	Value jsonBigInt `json:"value"`
+	Value       jsonBigInt `json:"value"`
+	BlockNumber jsonBigInt `json:"blockNumber"`
+	// Input is the hex encoded calldata. It is empty for internal transactions and not set for
+	// token transfers.
+	Input string `json:"input"`
 }
 
 // Transaction implemements accounts.Transaction (TODO).
@@ -186,6 +192,11 @@ func (tx *Transaction) Addresses() []accounts.AddressAndAmount {
 	}}
 }
 
+// Note implements accounts.Transaction. Notes are stored and added by the account.
+func (tx *Transaction) Note() string {
+	return ""
+}
+
 // Gas implements ethtypes.EthereumTransaction.
 func (tx *Transaction) Gas() uint64 {
 	if !tx.jsonTransaction.GasUsed.BigInt().IsInt64() {
@@ -194,6 +205,26 @@ func (tx *Transaction) Gas() uint64 {
 	return uint64(tx.jsonTransaction.GasUsed.BigInt().Int64())
 }
 
+// ReplacedTxID implements ethtypes.EthereumTransaction. Replacements are stored and added by the
+// account.
+func (tx *Transaction) ReplacedTxID() string {
+	return ""
+}
+
+// Data implements ethtypes.EthereumTransaction.
+func (tx *Transaction) Data() []byte {
+	data, err := hexutil.Decode(tx.jsonTransaction.Input)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// ContractCall implements ethtypes.EthereumTransaction. The calldata is decoded by the account.
+func (tx *Transaction) ContractCall() *ethtypes.ContractCall {
+	return nil
+}
+
 // prepareTransactions casts to []accounts.Transactions and removes duplicate entries. Duplicate entries
 // appear in the etherscan result if the recipient and sender are the same. It also sets the
 // transaction type (send, receive, send to self) based on the account address.
@@ -256,3 +287,58 @@ func (etherScan *EtherScan) Transactions(address common.Address, endBlock *big.I
 
 	return prepareTransactions(result.Result, address)
 }
+
+// TokenTransactions queries EtherScan for transfers of the ERC20 token at the given contract
+// address from or to the given account, until endBlock. The amount of the returned transactions is
+// the token amount, the fee is in the parent coin.
+func (etherScan *EtherScan) TokenTransactions(
+	address common.Address, contractAddress common.Address, endBlock *big.Int) (
+	[]accounts.Transaction, error) {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", "tokentx")
+	params.Set("startblock", "0")
+	params.Set("sort", "desc") // desc by block number
+
+	params.Set("endblock", endBlock.Text(10))
+	params.Set("address", address.Hex())
+	params.Set("contractaddress", contractAddress.Hex())
+
+	result := struct {
+		Result []*Transaction
+	}{}
+	if err := etherScan.call(params, &result); err != nil {
+		return nil, err
+	}
+
+	return prepareTransactions(result.Result, address)
+}
+
+// InternalTransactions queries EtherScan for internal transactions (ether transfers made by
+// contract calls) for the given account, until endBlock.
+func (etherScan *EtherScan) InternalTransactions(address common.Address, endBlock *big.Int) (
+	[]accounts.Transaction, error) {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", "txlistinternal")
+	params.Set("startblock", "0")
+	params.Set("sort", "desc") // desc by block number
+
+	params.Set("endblock", endBlock.Text(10))
+	params.Set("address", address.Hex())
+
+	result := struct {
+		Result []*Transaction
+	}{}
+	if err := etherScan.call(params, &result); err != nil {
+		return nil, err
+	}
+	for _, transaction := range result.Result {
+		// Internal transactions come without the number of confirmations, and the fee was paid by
+		// the caller of the contract.
+		confirmations := new(big.Int).Sub(endBlock, transaction.jsonTransaction.BlockNumber.BigInt())
+		transaction.jsonTransaction.Confirmations = jsonBigInt(*confirmations.Add(confirmations, big.NewInt(1)))
+		transaction.jsonTransaction.GasUsed = jsonBigInt{}
+	}
+	return prepareTransactions(result.Result, address)
+}
@@ -0,0 +1,291 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ethtest provides a local blockchain executing transactions with the EVM of go-ethereum,
+// for tests against a node.
+package ethtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// Net is the chain configuration of Chain, with all protocol changes enabled from the genesis
+// block.
+var Net = params.AllEthashProtocolChanges
+
+const blockGasLimit = 8000000
+
+// Chain is a local blockchain implementing eth.NodeClient. The transactions are executed by the
+// EVM of go-ethereum.
+type Chain struct {
+	state    *memoryState
+	blocks   []*types.Block
+	pending  []*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	txs      map[common.Hash]*types.Transaction
+	logs     []types.Log
+
+	// FetchedBlocks counts the calls to BlockByNumber.
+	FetchedBlocks int
+}
+
+// NewChain creates a chain whose genesis block funds the given wallets.
+func NewChain(wallets ...*Wallet) *Chain {
+	chain := &Chain{
+		state:    newMemoryState(),
+		receipts: map[common.Hash]*types.Receipt{},
+		txs:      map[common.Hash]*types.Transaction{},
+	}
+	for _, wallet := range wallets {
+		chain.state.AddBalance(wallet.Address, big.NewInt(1e18))
+	}
+	chain.blocks = []*types.Block{types.NewBlock(chain.header(0), nil, nil, nil)}
+	return chain
+}
+
+func (chain *Chain) header(number uint64) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Time:       big.NewInt(1546300800 + int64(number)*15),
+		GasLimit:   blockGasLimit,
+		Difficulty: big.NewInt(1),
+	}
+}
+
+// Head returns the number of the latest mined block.
+func (chain *Chain) Head() *big.Int {
+	return big.NewInt(int64(len(chain.blocks) - 1))
+}
+
+// Storage returns the value stored at the key in the storage of the contract.
+func (chain *Chain) Storage(contract common.Address, key common.Hash) common.Hash {
+	return chain.state.GetState(contract, key)
+}
+
+// intrinsicGas returns the gas paid by a transaction before its execution.
+func intrinsicGas(tx *types.Transaction) uint64 {
+	gas := params.TxGas
+	if tx.To() == nil {
+		gas = params.TxGasContractCreation
+	}
+	for _, b := range tx.Data() {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGas
+		}
+	}
+	return gas
+}
+
+// newEVM returns an EVM executing calls of origin in the block of the header.
+func (chain *Chain) newEVM(origin common.Address, gasPrice *big.Int, header *types.Header) *vm.EVM {
+	return vm.NewEVM(vm.Context{
+		CanTransfer: func(state vm.StateDB, address common.Address, amount *big.Int) bool {
+			return state.GetBalance(address).Cmp(amount) >= 0
+		},
+		Transfer: func(state vm.StateDB, from common.Address, to common.Address, amount *big.Int) {
+			state.SubBalance(from, amount)
+			state.AddBalance(to, amount)
+		},
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Origin:      origin,
+		GasPrice:    gasPrice,
+		GasLimit:    header.GasLimit,
+		BlockNumber: header.Number,
+		Time:        header.Time,
+		Difficulty:  header.Difficulty,
+	}, chain.state, Net, vm.Config{})
+}
+
+// Send executes the transaction in the next block and returns its receipt.
+func (chain *Chain) Send(t *testing.T, tx *types.Transaction) *types.Receipt {
+	header := chain.header(uint64(len(chain.blocks)))
+	from, err := types.Sender(types.MakeSigner(Net, header.Number), tx)
+	require.NoError(t, err)
+	require.Equal(t, chain.state.GetNonce(from), tx.Nonce())
+	require.True(t, intrinsicGas(tx) <= tx.Gas())
+
+	chain.state.SubBalance(from, new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice()))
+	chain.state.refund = 0
+	logs := len(chain.state.logs)
+	evm := chain.newEVM(from, tx.GasPrice(), header)
+	gas := tx.Gas() - intrinsicGas(tx)
+	var contractAddress common.Address
+	if tx.To() == nil {
+		_, contractAddress, gas, err = evm.Create(vm.AccountRef(from), tx.Data(), gas, tx.Value())
+	} else {
+		chain.state.SetNonce(from, tx.Nonce()+1)
+		_, gas, err = evm.Call(vm.AccountRef(from), *tx.To(), tx.Data(), gas, tx.Value())
+	}
+	gasUsed := tx.Gas() - gas
+	refund := chain.state.GetRefund()
+	if refund > gasUsed/2 {
+		refund = gasUsed / 2
+	}
+	gasUsed -= refund
+	chain.state.AddBalance(from,
+		new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()-gasUsed), tx.GasPrice()))
+
+	receipt := types.NewReceipt(nil, err != nil, gasUsed)
+	receipt.TxHash = tx.Hash()
+	receipt.GasUsed = gasUsed
+	receipt.ContractAddress = contractAddress
+	receipt.Logs = chain.state.logs[logs:]
+	for _, log := range receipt.Logs {
+		log.BlockNumber = header.Number.Uint64()
+		log.TxHash = tx.Hash()
+		log.TxIndex = uint(len(chain.pending))
+		log.Index = uint(len(chain.logs))
+		chain.logs = append(chain.logs, *log)
+	}
+	chain.pending = append(chain.pending, tx)
+	chain.txs[tx.Hash()] = tx
+	chain.receipts[tx.Hash()] = receipt
+	return receipt
+}
+
+// Mine adds a block with the transactions sent since the previous block.
+func (chain *Chain) Mine() {
+	receipts := make([]*types.Receipt, len(chain.pending))
+	for index, tx := range chain.pending {
+		receipts[index] = chain.receipts[tx.Hash()]
+	}
+	chain.blocks = append(chain.blocks,
+		types.NewBlock(chain.header(uint64(len(chain.blocks))), chain.pending, nil, receipts))
+	chain.pending = nil
+}
+
+func (chain *Chain) block(number *big.Int) (*types.Block, error) {
+	if !number.IsUint64() || number.Uint64() >= uint64(len(chain.blocks)) {
+		return nil, errp.Newf("block %s not found", number)
+	}
+	return chain.blocks[number.Uint64()], nil
+}
+
+func (chain *Chain) BlockByNumber(_ context.Context, number *big.Int) (*types.Block, error) {
+	chain.FetchedBlocks++
+	return chain.block(number)
+}
+
+func (chain *Chain) HeaderByNumber(_ context.Context, number *big.Int) (*types.Header, error) {
+	block, err := chain.block(number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (chain *Chain) TransactionByHash(_ context.Context, hash common.Hash) (
+	*types.Transaction, bool, error) {
+	tx, ok := chain.txs[hash]
+	if !ok {
+		return nil, false, errp.Newf("transaction %s not found", hash.Hex())
+	}
+	return tx, false, nil
+}
+
+func (chain *Chain) TransactionReceipt(_ context.Context, hash common.Hash) (
+	*types.Receipt, error) {
+	receipt, ok := chain.receipts[hash]
+	if !ok {
+		return nil, errp.Newf("receipt of %s not found", hash.Hex())
+	}
+	return receipt, nil
+}
+
+// CallContract executes the call in the next block without changing the state, like eth_call.
+func (chain *Chain) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) (
+	[]byte, error) {
+	if call.To == nil {
+		return nil, errp.New("missing contract address")
+	}
+	gas := call.Gas
+	if gas == 0 {
+		gas = blockGasLimit
+	}
+	gasPrice, value := new(big.Int), new(big.Int)
+	if call.GasPrice != nil {
+		gasPrice = call.GasPrice
+	}
+	if call.Value != nil {
+		value = call.Value
+	}
+	snapshot := chain.state.Snapshot()
+	defer chain.state.RevertToSnapshot(snapshot)
+	evm := chain.newEVM(call.From, gasPrice, chain.header(uint64(len(chain.blocks))))
+	output, _, err := evm.Call(vm.AccountRef(call.From), *call.To, call.Data, gas, value)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return output, nil
+}
+
+// FilterLogs returns the logs of the mined blocks matching the query, like eth_getLogs.
+func (chain *Chain) FilterLogs(_ context.Context, query ethereum.FilterQuery) (
+	[]types.Log, error) {
+	matches := func(log types.Log) bool {
+		if log.BlockNumber < query.FromBlock.Uint64() || log.BlockNumber > query.ToBlock.Uint64() ||
+			log.BlockNumber >= uint64(len(chain.blocks)) {
+			return false
+		}
+		if len(query.Addresses) != 0 && !containsAddress(query.Addresses, log.Address) {
+			return false
+		}
+		if len(query.Topics) > len(log.Topics) {
+			return false
+		}
+		for index, topics := range query.Topics {
+			if len(topics) != 0 && !containsHash(topics, log.Topics[index]) {
+				return false
+			}
+		}
+		return true
+	}
+	logs := []types.Log{}
+	for _, log := range chain.logs {
+		if matches(log) {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, candidate := range addresses {
+		if candidate == address {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, candidate := range hashes {
+		if candidate == hash {
+			return true
+		}
+	}
+	return false
+}
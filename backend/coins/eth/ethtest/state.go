@@ -0,0 +1,211 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethtest
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stateAccount is an account of memoryState.
+type stateAccount struct {
+	balance  *big.Int
+	nonce    uint64
+	code     []byte
+	storage  map[common.Hash]common.Hash
+	suicided bool
+}
+
+func (account *stateAccount) copy() *stateAccount {
+	copied := *account
+	copied.balance = new(big.Int).Set(account.balance)
+	copied.storage = make(map[common.Hash]common.Hash, len(account.storage))
+	for key, value := range account.storage {
+		copied.storage[key] = value
+	}
+	return &copied
+}
+
+// memoryState is an in-memory vm.StateDB. The state database of go-ethereum (and with it its
+// simulated backend) cannot be used, as its dependencies are not vendored.
+type memoryState struct {
+	accounts  map[common.Address]*stateAccount
+	logs      []*types.Log
+	refund    uint64
+	snapshots []memorySnapshot
+}
+
+type memorySnapshot struct {
+	accounts map[common.Address]*stateAccount
+	logs     int
+	refund   uint64
+}
+
+func newMemoryState() *memoryState {
+	return &memoryState{accounts: map[common.Address]*stateAccount{}}
+}
+
+func (state *memoryState) account(address common.Address) *stateAccount {
+	account, ok := state.accounts[address]
+	if !ok {
+		account = &stateAccount{balance: new(big.Int), storage: map[common.Hash]common.Hash{}}
+		state.accounts[address] = account
+	}
+	return account
+}
+
+func (state *memoryState) CreateAccount(address common.Address) {
+	balance := state.GetBalance(address)
+	state.accounts[address] = &stateAccount{
+		balance: balance, storage: map[common.Hash]common.Hash{}}
+}
+
+func (state *memoryState) SubBalance(address common.Address, amount *big.Int) {
+	account := state.account(address)
+	account.balance = new(big.Int).Sub(account.balance, amount)
+}
+
+func (state *memoryState) AddBalance(address common.Address, amount *big.Int) {
+	account := state.account(address)
+	account.balance = new(big.Int).Add(account.balance, amount)
+}
+
+func (state *memoryState) GetBalance(address common.Address) *big.Int {
+	if account, ok := state.accounts[address]; ok {
+		return new(big.Int).Set(account.balance)
+	}
+	return new(big.Int)
+}
+
+func (state *memoryState) GetNonce(address common.Address) uint64 {
+	if account, ok := state.accounts[address]; ok {
+		return account.nonce
+	}
+	return 0
+}
+
+func (state *memoryState) SetNonce(address common.Address, nonce uint64) {
+	state.account(address).nonce = nonce
+}
+
+func (state *memoryState) GetCodeHash(address common.Address) common.Hash {
+	if !state.Exist(address) {
+		return common.Hash{}
+	}
+	return crypto.Keccak256Hash(state.GetCode(address))
+}
+
+func (state *memoryState) GetCode(address common.Address) []byte {
+	if account, ok := state.accounts[address]; ok {
+		return account.code
+	}
+	return nil
+}
+
+func (state *memoryState) SetCode(address common.Address, code []byte) {
+	state.account(address).code = code
+}
+
+func (state *memoryState) GetCodeSize(address common.Address) int {
+	return len(state.GetCode(address))
+}
+
+func (state *memoryState) AddRefund(gas uint64) {
+	state.refund += gas
+}
+
+func (state *memoryState) SubRefund(gas uint64) {
+	state.refund -= gas
+}
+
+func (state *memoryState) GetRefund() uint64 {
+	return state.refund
+}
+
+func (state *memoryState) GetCommittedState(address common.Address, key common.Hash) common.Hash {
+	return state.GetState(address, key)
+}
+
+func (state *memoryState) GetState(address common.Address, key common.Hash) common.Hash {
+	if account, ok := state.accounts[address]; ok {
+		return account.storage[key]
+	}
+	return common.Hash{}
+}
+
+func (state *memoryState) SetState(address common.Address, key common.Hash, value common.Hash) {
+	state.account(address).storage[key] = value
+}
+
+func (state *memoryState) Suicide(address common.Address) bool {
+	account, ok := state.accounts[address]
+	if !ok {
+		return false
+	}
+	account.suicided = true
+	account.balance = new(big.Int)
+	return true
+}
+
+func (state *memoryState) HasSuicided(address common.Address) bool {
+	account, ok := state.accounts[address]
+	return ok && account.suicided
+}
+
+func (state *memoryState) Exist(address common.Address) bool {
+	_, ok := state.accounts[address]
+	return ok
+}
+
+func (state *memoryState) Empty(address common.Address) bool {
+	account, ok := state.accounts[address]
+	return !ok || (account.nonce == 0 && account.balance.Sign() == 0 && len(account.code) == 0)
+}
+
+func (state *memoryState) RevertToSnapshot(id int) {
+	snapshot := state.snapshots[id]
+	state.accounts = snapshot.accounts
+	state.logs = state.logs[:snapshot.logs]
+	state.refund = snapshot.refund
+	state.snapshots = state.snapshots[:id]
+}
+
+func (state *memoryState) Snapshot() int {
+	accounts := make(map[common.Address]*stateAccount, len(state.accounts))
+	for address, account := range state.accounts {
+		accounts[address] = account.copy()
+	}
+	state.snapshots = append(state.snapshots,
+		memorySnapshot{accounts: accounts, logs: len(state.logs), refund: state.refund})
+	return len(state.snapshots) - 1
+}
+
+func (state *memoryState) AddLog(log *types.Log) {
+	state.logs = append(state.logs, log)
+}
+
+func (state *memoryState) AddPreimage(common.Hash, []byte) {}
+
+func (state *memoryState) ForEachStorage(
+	address common.Address, callback func(common.Hash, common.Hash) bool) {
+	for key, value := range state.account(address).storage {
+		if !callback(key, value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethtest
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// gasPrice is the gas price of all transactions created by a wallet.
+var gasPrice = big.NewInt(1e9)
+
+// Wallet creates transactions signed by a generated key. The transactions are executed with
+// Chain.Send in the order they are created.
+type Wallet struct {
+	Address common.Address
+	key     *ecdsa.PrivateKey
+	nonce   uint64
+}
+
+// NewWallet creates a wallet with a new key.
+func NewWallet(t *testing.T) *Wallet {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &Wallet{Address: crypto.PubkeyToAddress(key.PublicKey), key: key}
+}
+
+// Sign signs the transaction, which must use the next nonce of the wallet.
+func (wallet *Wallet) Sign(t *testing.T, tx *types.Transaction) *types.Transaction {
+	require.Equal(t, wallet.nonce, tx.Nonce())
+	signedTx, err := types.SignTx(tx, types.MakeSigner(Net, big.NewInt(1)), wallet.key)
+	require.NoError(t, err)
+	wallet.nonce++
+	return signedTx
+}
+
+// Transfer returns a transaction sending value wei to the address.
+func (wallet *Wallet) Transfer(t *testing.T, to common.Address, value int64) *types.Transaction {
+	return wallet.Sign(t,
+		types.NewTransaction(wallet.nonce, to, big.NewInt(value), params.TxGas, gasPrice, nil))
+}
+
+// Call returns a transaction calling the contract with the data.
+func (wallet *Wallet) Call(
+	t *testing.T, contract common.Address, data []byte, gas uint64) *types.Transaction {
+	return wallet.Sign(t,
+		types.NewTransaction(wallet.nonce, contract, big.NewInt(0), gas, gasPrice, data))
+}
+
+// Deploy returns a transaction creating a contract with the creation code.
+func (wallet *Wallet) Deploy(t *testing.T, code []byte, gas uint64) *types.Transaction {
+	return wallet.Sign(t,
+		types.NewContractCreation(wallet.nonce, big.NewInt(0), gas, gasPrice, code))
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// gasPriceOracleBlocks is the number of recent blocks the gas prices are derived from.
+const gasPriceOracleBlocks = 20
+
+// FeeTarget contains the gas price for a specific fee target.
+type FeeTarget struct {
+	// percentile of the recent gas prices which is used for this target.
+	percentile int
+
+	// code is the identifier for the UI.
+	code accounts.FeeTargetCode
+
+	// gasPrice is the gas price in wei needed for this target. Can be nil until populated.
+	gasPrice *big.Int
+}
+
+// Code implements accounts.FeeTarget.
+func (feeTarget *FeeTarget) Code() accounts.FeeTargetCode {
+	return feeTarget.code
+}
+
+// GasPrice returns the gas price of the fee target in wei.
+func (feeTarget *FeeTarget) GasPrice() *big.Int {
+	return feeTarget.gasPrice
+}
+
+// newFeeTargets returns the fee targets, sorted by ascending priority.
+func newFeeTargets() []*FeeTarget {
+	return []*FeeTarget{
+		{percentile: 10, code: accounts.FeeTargetCodeEconomy},
+		{percentile: 30, code: accounts.FeeTargetCodeLow},
+		{percentile: 60, code: accounts.FeeTargetCodeNormal},
+		{percentile: 90, code: accounts.FeeTargetCodeHigh},
+	}
+}
+
+// blockFetcher is the part of ethclient.Client used to fetch blocks.
+type blockFetcher interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// gasPriceOracle derives gas prices from the lowest gas price paid in each of the recent blocks,
+// similar to the gas price oracle of geth. Blocks are fetched only once.
+type gasPriceOracle struct {
+	// blockGasPrices maps a block number to the lowest gas price paid in that block. Blocks
+	// without transactions map to nil.
+	blockGasPrices map[uint64]*big.Int
+}
+
+func newGasPriceOracle() *gasPriceOracle {
+	return &gasPriceOracle{
+		blockGasPrices: map[uint64]*big.Int{},
+	}
+}
+
+// gasPrices returns the gas prices of the recent blocks up to the given block number in ascending
+// order. Missing blocks are fetched using the given client.
+func (oracle *gasPriceOracle) gasPrices(client blockFetcher, blockNumber uint64) ([]*big.Int, error) {
+	first := uint64(0)
+	if blockNumber+1 > gasPriceOracleBlocks {
+		first = blockNumber + 1 - gasPriceOracleBlocks
+	}
+	for number := range oracle.blockGasPrices {
+		if number < first || number > blockNumber {
+			delete(oracle.blockGasPrices, number)
+		}
+	}
+	gasPrices := []*big.Int{}
+	for number := first; number <= blockNumber; number++ {
+		gasPrice, ok := oracle.blockGasPrices[number]
+		if !ok {
+			block, err := client.BlockByNumber(context.TODO(), new(big.Int).SetUint64(number))
+			if err != nil {
+				return nil, errp.WithStack(err)
+			}
+			for _, tx := range block.Transactions() {
+				if tx.GasPrice().Sign() == 0 {
+					continue
+				}
+				if gasPrice == nil || tx.GasPrice().Cmp(gasPrice) < 0 {
+					gasPrice = tx.GasPrice()
+				}
+			}
+			oracle.blockGasPrices[number] = gasPrice
+		}
+		if gasPrice != nil {
+			gasPrices = append(gasPrices, gasPrice)
+		}
+	}
+	sort.Slice(gasPrices, func(i, j int) bool { return gasPrices[i].Cmp(gasPrices[j]) < 0 })
+	return gasPrices, nil
+}
+
+// percentile returns the given percentile of the sorted values, or nil if there are no values.
+func percentile(sortedValues []*big.Int, percentile int) *big.Int {
+	if len(sortedValues) == 0 {
+		return nil
+	}
+	return sortedValues[(len(sortedValues)-1)*percentile/100]
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// blocksMock returns blocks whose transactions pay the gas prices given per block number.
+type blocksMock struct {
+	gasPrices map[uint64][]int64
+	fetched   []uint64
+}
+
+func (mock *blocksMock) BlockByNumber(_ context.Context, number *big.Int) (*types.Block, error) {
+	mock.fetched = append(mock.fetched, number.Uint64())
+	txs := []*types.Transaction{}
+	for _, gasPrice := range mock.gasPrices[number.Uint64()] {
+		txs = append(txs, types.NewTransaction(
+			0, common.Address{}, big.NewInt(0), 21000, big.NewInt(gasPrice), nil))
+	}
+	return types.NewBlock(&types.Header{Number: number}, txs, nil, nil), nil
+}
+
+func TestGasPriceOracle(t *testing.T) {
+	mock := &blocksMock{gasPrices: map[uint64][]int64{}}
+	for number := uint64(0); number < 30; number++ {
+		// The lowest gas price of block n is n, zero gas prices are ignored.
+		mock.gasPrices[number] = []int64{int64(number) + 5, int64(number), 0}
+	}
+	mock.gasPrices[0] = nil
+	mock.gasPrices[1] = nil
+	oracle := newGasPriceOracle()
+
+	gasPrices, err := oracle.gasPrices(mock, 10)
+	require.NoError(t, err)
+	require.Len(t, gasPrices, 9)
+	require.Equal(t, big.NewInt(2), gasPrices[0])
+	require.Equal(t, big.NewInt(10), gasPrices[8])
+	require.Len(t, mock.fetched, 11)
+
+	// Only new blocks are fetched, and old blocks are dropped.
+	mock.fetched = nil
+	gasPrices, err = oracle.gasPrices(mock, 29)
+	require.NoError(t, err)
+	require.Len(t, gasPrices, gasPriceOracleBlocks)
+	require.Equal(t, big.NewInt(10), gasPrices[0])
+	require.Equal(t, big.NewInt(29), gasPrices[gasPriceOracleBlocks-1])
+	require.Len(t, mock.fetched, 19)
+	require.Len(t, oracle.blockGasPrices, gasPriceOracleBlocks)
+
+	require.Equal(t, big.NewInt(11), percentile(gasPrices, 10))
+	require.Equal(t, big.NewInt(21), percentile(gasPrices, 60))
+	require.Equal(t, big.NewInt(29), percentile(gasPrices, 100))
+	require.Nil(t, percentile(nil, 60))
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrInternalTransactionsUnsupported is returned by indexers which cannot find internal
+// transactions.
+var ErrInternalTransactionsUnsupported = errors.New("internal transactions are not supported")
+
+// Indexer provides the confirmed transaction history of addresses, which an Ethereum node does not
+// offer by itself. The returned transactions implement ethtypes.EthereumTransaction and are sorted
+// descending by block number.
+type Indexer interface {
+	// Transactions returns the transactions sent from or to the given address, until endBlock.
+	Transactions(address common.Address, endBlock *big.Int) ([]accounts.Transaction, error)
+
+	// InternalTransactions returns the ether transfers from or to the given address made by
+	// contract calls, until endBlock. Indexers which cannot find them return
+	// ErrInternalTransactionsUnsupported.
+	InternalTransactions(address common.Address, endBlock *big.Int) ([]accounts.Transaction, error)
+
+	// TokenTransactions returns the transfers of the ERC20 token at the given contract address from
+	// or to the given address, until endBlock. The amounts are token amounts, the fees are in ether.
+	TokenTransactions(address common.Address, contractAddress common.Address, endBlock *big.Int) (
+		[]accounts.Transaction, error)
+}
+
+// NodeClient is the part of ethclient.Client available to indexers. The requests are forwarded to
+// the node the coin is currently connected to, and fail with ErrNotConnected while there is no
+// connection.
+type NodeClient interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// MakeIndexer creates the indexer of a coin. Indexers which do not use the node can ignore the
+// client.
+type MakeIndexer func(client NodeClient) Indexer
@@ -0,0 +1,286 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// personalMessageHash returns the hash signed by personal_sign, which prefixes the message so that
+// it cannot be a transaction.
+func personalMessageHash(message []byte) []byte {
+	return crypto.Keccak256(
+		[]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), message)
+}
+
+// TypedDataField is a member of a struct type of EIP-712 typed data.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is the EIP-712 typed data signed by eth_signTypedData, see
+// https://eips.ethereum.org/EIPS/eip-712.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ParseTypedData parses the JSON encoding of EIP-712 typed data.
+func ParseTypedData(jsonTypedData []byte) (*TypedData, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonTypedData))
+	// Keep integers which do not fit into a float64.
+	decoder.UseNumber()
+	typedData := &TypedData{}
+	if err := decoder.Decode(typedData); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		return nil, errp.New("typed data is missing the EIP712Domain type")
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, errp.Newf("typed data is missing the primary type %s", typedData.PrimaryType)
+	}
+	return typedData, nil
+}
+
+// Hash returns the hash signed by eth_signTypedData.
+func (typedData *TypedData) Hash() ([]byte, error) {
+	domainHash, err := typedData.hashStruct("EIP712Domain", typedData.Domain)
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := typedData.hashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte("\x19\x01"), domainHash, messageHash), nil
+}
+
+// baseType strips the array suffixes of a type, e.g. `Person[][2]` becomes `Person`.
+func baseType(typ string) string {
+	if index := strings.Index(typ, "["); index >= 0 {
+		return typ[:index]
+	}
+	return typ
+}
+
+// dependencies adds the struct type and all struct types it references to found.
+func (typedData *TypedData) dependencies(typ string, found map[string]struct{}) {
+	typ = baseType(typ)
+	if _, ok := found[typ]; ok {
+		return
+	}
+	fields, ok := typedData.Types[typ]
+	if !ok {
+		return
+	}
+	found[typ] = struct{}{}
+	for _, field := range fields {
+		typedData.dependencies(field.Type, found)
+	}
+}
+
+// encodeType returns the encoding of the struct type, e.g. `Mail(Person from,Person to,string
+// contents)Person(string name,address wallet)`.
+func (typedData *TypedData) encodeType(primaryType string) string {
+	found := map[string]struct{}{}
+	typedData.dependencies(primaryType, found)
+	delete(found, primaryType)
+	types := []string{}
+	for typ := range found {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	var buffer bytes.Buffer
+	for _, typ := range append([]string{primaryType}, types...) {
+		members := []string{}
+		for _, field := range typedData.Types[typ] {
+			members = append(members, field.Type+" "+field.Name)
+		}
+		buffer.WriteString(typ + "(" + strings.Join(members, ",") + ")")
+	}
+	return buffer.String()
+}
+
+func (typedData *TypedData) hashStruct(typ string, data map[string]interface{}) ([]byte, error) {
+	encoded := crypto.Keccak256([]byte(typedData.encodeType(typ)))
+	for _, field := range typedData.Types[typ] {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, errp.Newf("missing value of %s.%s", typ, field.Name)
+		}
+		encodedValue, err := typedData.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, errp.WithMessage(err, fmt.Sprintf("invalid value of %s.%s", typ, field.Name))
+		}
+		encoded = append(encoded, encodedValue...)
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+var (
+	arrayTypeRegexp = regexp.MustCompile(`^(.*)\[([0-9]*)\]$`)
+	intTypeRegexp   = regexp.MustCompile(`^(u?)int([0-9]*)$`)
+	bytesTypeRegexp = regexp.MustCompile(`^bytes([0-9]+)$`)
+)
+
+// encodeValue returns the 32 byte encoding of the value of the given type.
+func (typedData *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if match := arrayTypeRegexp.FindStringSubmatch(typ); match != nil {
+		elements, ok := value.([]interface{})
+		if !ok {
+			return nil, errp.New("expected an array")
+		}
+		if match[2] != "" && match[2] != strconv.Itoa(len(elements)) {
+			return nil, errp.Newf("expected %s elements", match[2])
+		}
+		encoded := []byte{}
+		for _, element := range elements {
+			encodedElement, err := typedData.encodeValue(match[1], element)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, encodedElement...)
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	if _, ok := typedData.Types[typ]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errp.New("expected an object")
+		}
+		return typedData.hashStruct(typ, data)
+	}
+	switch typ {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, errp.New("expected a string")
+		}
+		return crypto.Keccak256([]byte(str)), nil
+	case "bytes":
+		data, err := decodeHexValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(data), nil
+	case "bool":
+		boolean, ok := value.(bool)
+		if !ok {
+			return nil, errp.New("expected a boolean")
+		}
+		if boolean {
+			return math.PaddedBigBytes(big.NewInt(1), 32), nil
+		}
+		return make([]byte, 32), nil
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, errp.New("expected an address")
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+	}
+	if match := bytesTypeRegexp.FindStringSubmatch(typ); match != nil {
+		size, err := strconv.Atoi(match[1])
+		if err != nil || size < 1 || size > 32 {
+			return nil, errp.Newf("unsupported type %s", typ)
+		}
+		data, err := decodeHexValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != size {
+			return nil, errp.Newf("expected %d bytes", size)
+		}
+		return common.RightPadBytes(data, 32), nil
+	}
+	if match := intTypeRegexp.FindStringSubmatch(typ); match != nil {
+		bits := 256
+		if match[2] != "" {
+			var err error
+			bits, err = strconv.Atoi(match[2])
+			if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+				return nil, errp.Newf("unsupported type %s", typ)
+			}
+		}
+		number, err := decodeIntegerValue(value)
+		if err != nil {
+			return nil, err
+		}
+		// The bits needed to represent the number, without the sign bit. -2^(bits-1) fits into the
+		// signed type, as does 2^(bits-1)-1.
+		magnitude := number
+		if number.Sign() < 0 {
+			magnitude = new(big.Int).Sub(new(big.Int).Neg(number), big.NewInt(1))
+		}
+		unsigned := match[1] == "u"
+		if unsigned && (number.Sign() < 0 || number.BitLen() > bits) ||
+			!unsigned && magnitude.BitLen() > bits-1 {
+			return nil, errp.Newf("integer out of range of %s", typ)
+		}
+		return math.PaddedBigBytes(math.U256(number), 32), nil
+	}
+	return nil, errp.Newf("unsupported type %s", typ)
+}
+
+// decodeHexValue decodes a 0x-prefixed hex string.
+func decodeHexValue(value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, errp.New("expected a hex string")
+	}
+	data, err := hexutil.Decode(str)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return data, nil
+}
+
+// decodeIntegerValue decodes an integer given as a JSON number or as a decimal or 0x-prefixed hex
+// string.
+func decodeIntegerValue(value interface{}) (*big.Int, error) {
+	var str string
+	switch specificValue := value.(type) {
+	case json.Number:
+		str = specificValue.String()
+	case string:
+		str = specificValue
+	case float64:
+		str = strconv.FormatFloat(specificValue, 'f', -1, 64)
+	default:
+		return nil, errp.New("expected an integer")
+	}
+	number, ok := math.ParseBig256(str)
+	if !ok || str == "" {
+		return nil, errp.Newf("invalid integer %s", str)
+	}
+	return number, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// mailTypedData is the example of EIP-712.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestPersonalMessageHash(t *testing.T) {
+	require.Equal(t,
+		"0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2",
+		hexutil.Encode(personalMessageHash([]byte("Hello World"))))
+}
+
+func TestTypedDataHash(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+	require.Equal(t,
+		"Mail(Person from,Person to,string contents)Person(string name,address wallet)",
+		typedData.encodeType("Mail"))
+	hash, err := typedData.Hash()
+	require.NoError(t, err)
+	require.Equal(t,
+		"0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2",
+		hexutil.Encode(hash))
+
+	// The signature of the example, made with the private key keccak256("cow").
+	privateKey, err := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	require.NoError(t, err)
+	signature, err := crypto.Sign(hash, privateKey)
+	require.NoError(t, err)
+	require.Equal(t,
+		"0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d"+
+			"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562"+"01",
+		hexutil.Encode(signature))
+}
+
+func TestTypedDataInvalid(t *testing.T) {
+	_, err := ParseTypedData([]byte(`{"types": {}, "primaryType": "Mail"}`))
+	require.Error(t, err)
+
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+	typedData.Message["contents"] = 1
+	_, err = typedData.Hash()
+	require.Error(t, err)
+
+	for typ, value := range map[string]interface{}{
+		"uint8":   "256",
+		"int8":    "128",
+		"uint256": "-1",
+		"bytes2":  "0x010203",
+		"address": "0x01",
+		"bool":    "true",
+		"uint7":   "1",
+	} {
+		_, err := typedData.encodeValue(typ, value)
+		require.Error(t, err, typ)
+	}
+	_, err = typedData.encodeValue("int8", "-128")
+	require.NoError(t, err)
+	encoded, err := typedData.encodeValue("int8", "-1")
+	require.NoError(t, err)
+	for _, b := range encoded {
+		require.Equal(t, byte(0xff), b)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// nodeClient implements NodeClient by forwarding the requests to the client currently connected to
+// the node of the coin.
+type nodeClient struct {
+	coin *Coin
+}
+
+func (client nodeClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	ethClient, err := client.coin.Client()
+	if err != nil {
+		return nil, err
+	}
+	return ethClient.BlockByNumber(ctx, number)
+}
+
+func (client nodeClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ethClient, err := client.coin.Client()
+	if err != nil {
+		return nil, err
+	}
+	return ethClient.HeaderByNumber(ctx, number)
+}
+
+func (client nodeClient) TransactionByHash(ctx context.Context, hash common.Hash) (
+	*types.Transaction, bool, error) {
+	ethClient, err := client.coin.Client()
+	if err != nil {
+		return nil, false, err
+	}
+	return ethClient.TransactionByHash(ctx, hash)
+}
+
+func (client nodeClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (
+	*types.Receipt, error) {
+	ethClient, err := client.coin.Client()
+	if err != nil {
+		return nil, err
+	}
+	return ethClient.TransactionReceipt(ctx, txHash)
+}
+
+func (client nodeClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (
+	[]types.Log, error) {
+	ethClient, err := client.coin.Client()
+	if err != nil {
+		return nil, err
+	}
+	return ethClient.FilterLogs(ctx, query)
+}
@@ -0,0 +1,148 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeindexer
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	bbolt "github.com/coreos/bbolt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const bucketScans = "scans"
+
+// DB is a bbolt key/value database storing the scans of the indexer.
+type DB struct {
+	db *bbolt.DB
+}
+
+// NewDB creates/opens a new db.
+func NewDB(filename string) (*DB, error) {
+	db, err := bbolt.Open(filename, 0600, nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the db.
+func (db *DB) Close() error {
+	return errp.WithStack(db.db.Close())
+}
+
+// storedTransaction is the serialized form of Transaction.
+type storedTransaction struct {
+	Hash        common.Hash     `json:"hash"`
+	BlockNumber uint64          `json:"blockNumber"`
+	Timestamp   int64           `json:"timestamp"`
+	GasUsed     uint64          `json:"gasUsed"`
+	GasPrice    *big.Int        `json:"gasPrice"`
+	TxType      accounts.TxType `json:"type"`
+	Address     common.Address  `json:"address"`
+	Amount      *big.Int        `json:"amount"`
+	Data        hexutil.Bytes   `json:"data,omitempty"`
+}
+
+// storedScan is the serialized form of scan. The scan is only valid for the birthday block it was
+// started at.
+type storedScan struct {
+	BirthdayBlock uint64              `json:"birthdayBlock"`
+	NextBlock     uint64              `json:"nextBlock"`
+	Transactions  []storedTransaction `json:"transactions"`
+}
+
+func (key scanKey) bytes() []byte {
+	return append(key.address.Bytes(), key.contractAddress.Bytes()...)
+}
+
+// scan returns the stored scan of the key, or nil if there is none for the birthday block.
+func (db *DB) scan(key scanKey, birthdayBlock uint64) (*scan, error) {
+	var value []byte
+	err := db.db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket([]byte(bucketScans)); bucket != nil {
+			value = append([]byte{}, bucket.Get(key.bytes())...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var stored storedScan
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if stored.BirthdayBlock != birthdayBlock {
+		return nil, nil
+	}
+	keyScan := &scan{
+		nextBlock:    stored.NextBlock,
+		transactions: make([]*Transaction, len(stored.Transactions)),
+	}
+	for index, transaction := range stored.Transactions {
+		keyScan.transactions[index] = &Transaction{
+			hash:        transaction.Hash,
+			blockNumber: transaction.BlockNumber,
+			timestamp:   time.Unix(transaction.Timestamp, 0),
+			gasUsed:     transaction.GasUsed,
+			gasPrice:    transaction.GasPrice,
+			txType:      transaction.TxType,
+			address:     transaction.Address,
+			amount:      transaction.Amount,
+			data:        transaction.Data,
+		}
+	}
+	return keyScan, nil
+}
+
+// putScan stores the scan of the key, which was started at the birthday block.
+func (db *DB) putScan(key scanKey, birthdayBlock uint64, keyScan *scan) error {
+	stored := storedScan{
+		BirthdayBlock: birthdayBlock,
+		NextBlock:     keyScan.nextBlock,
+		Transactions:  make([]storedTransaction, len(keyScan.transactions)),
+	}
+	for index, transaction := range keyScan.transactions {
+		stored.Transactions[index] = storedTransaction{
+			Hash:        transaction.hash,
+			BlockNumber: transaction.blockNumber,
+			Timestamp:   transaction.timestamp.Unix(),
+			GasUsed:     transaction.gasUsed,
+			GasPrice:    transaction.gasPrice,
+			TxType:      transaction.txType,
+			Address:     transaction.address,
+			Amount:      transaction.amount,
+			Data:        transaction.data,
+		}
+	}
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(db.db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(bucketScans))
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key.bytes(), value)
+	}))
+}
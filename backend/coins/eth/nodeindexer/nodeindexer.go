@@ -0,0 +1,318 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodeindexer finds the transaction history of addresses using only the Ethereum node of
+// the user, so that the addresses are not revealed to a third party.
+package nodeindexer
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	ethereum "github.com/ethereum/go-ethereum"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// reorgSafetyBlocks is the number of confirmations after which the transactions of a block are
+// cached. More recent blocks can still be reorganized and are scanned again on every query.
+const reorgSafetyBlocks = 12
+
+// scanChunkBlocks and tokenScanChunkBlocks are the number of blocks scanned at once for ether and
+// token transactions. The progress is stored after each chunk.
+const (
+	scanChunkBlocks      = 100
+	tokenScanChunkBlocks = 10000
+)
+
+// scan holds the transactions found in the already scanned blocks.
+type scan struct {
+	// nextBlock is the first block which is not scanned yet.
+	nextBlock    uint64
+	transactions []*Transaction
+}
+
+// scanKey identifies the scan of the ether transactions of an address, or of the transfers of a
+// token from or to an address if contractAddress is not empty.
+type scanKey struct {
+	address         common.Address
+	contractAddress common.Address
+}
+
+// NodeIndexer implements eth.Indexer by scanning all blocks starting at a birthday block, which
+// should be the block in which the addresses were first used. Token transfers are found using the
+// log filter of the node. The first query of an address can take long if the birthday block is
+// old. The scans are stored in the database, so that only new blocks are scanned after a restart.
+type NodeIndexer struct {
+	client        eth.NodeClient
+	net           *params.ChainConfig
+	birthdayBlock uint64
+	// db can be nil, in which case the scans are not stored.
+	db *DB
+
+	scans map[scanKey]*scan
+	// lock protects scans. It is not held while requesting the node.
+	lock locker.Locker
+}
+
+// NewNodeIndexer creates a new indexer scanning the blocks of the given node, starting at the given
+// birthday block. The scans are stored in db unless it is nil.
+func NewNodeIndexer(
+	client eth.NodeClient, net *params.ChainConfig, birthdayBlock uint64, db *DB) *NodeIndexer {
+	return &NodeIndexer{
+		client:        client,
+		net:           net,
+		birthdayBlock: birthdayBlock,
+		db:            db,
+		scans:         map[scanKey]*scan{},
+	}
+}
+
+// safeBlock returns the last block which is considered final at the given block.
+func safeBlock(endBlock uint64) int64 {
+	return int64(endBlock) - reorgSafetyBlocks
+}
+
+// blockTransactions returns the transactions of the block with the given number sent from or to
+// the given address.
+func (indexer *NodeIndexer) blockTransactions(address common.Address, number uint64) (
+	[]*Transaction, error) {
+	block, err := indexer.client.BlockByNumber(context.TODO(), new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	signer := types.MakeSigner(indexer.net, block.Number())
+	transactions := []*Transaction{}
+	for _, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if from != address && (tx.To() == nil || *tx.To() != address) {
+			continue
+		}
+		receipt, err := indexer.client.TransactionReceipt(context.TODO(), tx.Hash())
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		to := receipt.ContractAddress
+		if tx.To() != nil {
+			to = *tx.To()
+		}
+		transactions = append(transactions,
+			newTransaction(address, tx, receipt, block.Header(), from, to, tx.Value(), tx.Data()))
+	}
+	return transactions, nil
+}
+
+// nextBlock returns the first block not scanned yet for the key. The stored scan is loaded on the
+// first call.
+func (indexer *NodeIndexer) nextBlock(key scanKey) (uint64, error) {
+	defer indexer.lock.Lock()()
+	if keyScan, ok := indexer.scans[key]; ok {
+		return keyScan.nextBlock, nil
+	}
+	keyScan := &scan{nextBlock: indexer.birthdayBlock}
+	if indexer.db != nil {
+		storedScan, err := indexer.db.scan(key, indexer.birthdayBlock)
+		if err != nil {
+			return 0, err
+		}
+		if storedScan != nil {
+			keyScan = storedScan
+		}
+	}
+	indexer.scans[key] = keyScan
+	return keyScan.nextBlock, nil
+}
+
+// addScanned adds the transactions found in the blocks from fromBlock to toBlock to the scan of
+// the key and stores the scan. Nothing is added if the blocks were added concurrently.
+func (indexer *NodeIndexer) addScanned(
+	key scanKey, fromBlock uint64, toBlock uint64, transactions []*Transaction) error {
+	defer indexer.lock.Lock()()
+	keyScan := indexer.scans[key]
+	if keyScan.nextBlock != fromBlock {
+		return nil
+	}
+	updatedScan := &scan{
+		nextBlock:    toBlock + 1,
+		transactions: append(append([]*Transaction{}, keyScan.transactions...), transactions...),
+	}
+	if indexer.db != nil {
+		if err := indexer.db.putScan(key, indexer.birthdayBlock, updatedScan); err != nil {
+			return err
+		}
+	}
+	indexer.scans[key] = updatedScan
+	return nil
+}
+
+// query returns the transactions of the key up to endBlock. The blocks up to the last safe block
+// which are not scanned yet are scanned in chunks of chunkBlocks using scanBlocks and added to the
+// scan, the more recent blocks are scanned again on every query.
+func (indexer *NodeIndexer) query(
+	key scanKey,
+	endBlock *big.Int,
+	chunkBlocks uint64,
+	scanBlocks func(fromBlock uint64, toBlock uint64) ([]*Transaction, error),
+) ([]accounts.Transaction, error) {
+	end := endBlock.Uint64()
+	for {
+		nextBlock, err := indexer.nextBlock(key)
+		if err != nil {
+			return nil, err
+		}
+		if int64(nextBlock) > safeBlock(end) {
+			break
+		}
+		toBlock := nextBlock + chunkBlocks - 1
+		if int64(toBlock) > safeBlock(end) {
+			toBlock = uint64(safeBlock(end))
+		}
+		transactions, err := scanBlocks(nextBlock, toBlock)
+		if err != nil {
+			return nil, err
+		}
+		if err := indexer.addScanned(key, nextBlock, toBlock, transactions); err != nil {
+			return nil, err
+		}
+	}
+	unlock := indexer.lock.RLock()
+	keyScan := indexer.scans[key]
+	unlock()
+	recentTransactions := []*Transaction{}
+	if keyScan.nextBlock <= end {
+		var err error
+		recentTransactions, err = scanBlocks(keyScan.nextBlock, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result(append(recentTransactions, keyScan.transactions...), end), nil
+}
+
+// Transactions implements eth.Indexer.
+func (indexer *NodeIndexer) Transactions(address common.Address, endBlock *big.Int) (
+	[]accounts.Transaction, error) {
+	return indexer.query(scanKey{address: address}, endBlock, scanChunkBlocks,
+		func(fromBlock uint64, toBlock uint64) ([]*Transaction, error) {
+			transactions := []*Transaction{}
+			for number := fromBlock; number <= toBlock; number++ {
+				blockTransactions, err := indexer.blockTransactions(address, number)
+				if err != nil {
+					return nil, err
+				}
+				transactions = append(transactions, blockTransactions...)
+			}
+			return transactions, nil
+		})
+}
+
+// InternalTransactions implements eth.Indexer. Ether transfers made by contract calls can only be
+// found by tracing the execution of all transactions, which regular nodes do not offer, so
+// eth.ErrInternalTransactionsUnsupported is returned.
+func (indexer *NodeIndexer) InternalTransactions(common.Address, *big.Int) (
+	[]accounts.Transaction, error) {
+	return nil, errp.WithStack(eth.ErrInternalTransactionsUnsupported)
+}
+
+// tokenTransactions returns the transfers of the token from or to the given address in the given
+// range of blocks, one for each Transfer event.
+func (indexer *NodeIndexer) tokenTransactions(
+	address common.Address, contractAddress common.Address, fromBlock uint64, toBlock uint64) (
+	[]*Transaction, error) {
+	addressTopic := []common.Hash{common.BytesToHash(address.Bytes())}
+	logs := []types.Log{}
+	for _, topics := range [][][]common.Hash{
+		{{erc20.TransferEventID}, addressTopic},
+		{{erc20.TransferEventID}, nil, addressTopic},
+	} {
+		filteredLogs, err := indexer.client.FilterLogs(context.TODO(), ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(fromBlock),
+			ToBlock:   new(big.Int).SetUint64(toBlock),
+			Addresses: []common.Address{contractAddress},
+			Topics:    topics,
+		})
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		logs = append(logs, filteredLogs...)
+	}
+	// Transfers to self match both filters.
+	type logKey struct {
+		txHash common.Hash
+		index  uint
+	}
+	seen := map[logKey]struct{}{}
+	transactions := []*Transaction{}
+	for index := range logs {
+		log := &logs[index]
+		from, to, amount, ok := erc20.DecodeTransferLog(log)
+		if !ok {
+			continue
+		}
+		key := logKey{txHash: log.TxHash, index: log.Index}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		header, err := indexer.client.HeaderByNumber(context.TODO(), new(big.Int).SetUint64(log.BlockNumber))
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		tx, _, err := indexer.client.TransactionByHash(context.TODO(), log.TxHash)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		receipt, err := indexer.client.TransactionReceipt(context.TODO(), log.TxHash)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		transactions = append(transactions, newTransaction(address, tx, receipt, header, from, to, amount, nil))
+	}
+	return transactions, nil
+}
+
+// TokenTransactions implements eth.Indexer.
+func (indexer *NodeIndexer) TokenTransactions(
+	address common.Address, contractAddress common.Address, endBlock *big.Int) (
+	[]accounts.Transaction, error) {
+	return indexer.query(
+		scanKey{address: address, contractAddress: contractAddress}, endBlock, tokenScanChunkBlocks,
+		func(fromBlock uint64, toBlock uint64) ([]*Transaction, error) {
+			return indexer.tokenTransactions(address, contractAddress, fromBlock, toBlock)
+		})
+}
+
+// result sorts the transactions descending by block number and sets their number of
+// confirmations at the given block.
+func result(transactions []*Transaction, endBlock uint64) []accounts.Transaction {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].blockNumber > transactions[j].blockNumber
+	})
+	result := make([]accounts.Transaction, len(transactions))
+	for index, transaction := range transactions {
+		result[index] = transaction.withConfirmations(endBlock)
+	}
+	return result
+}
@@ -0,0 +1,258 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeindexer_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/ethtest"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/nodeindexer"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/test"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/stretchr/testify/require"
+)
+
+// Both indexers are interchangeable.
+var _ eth.Indexer = &etherscan.EtherScan{}
+var _ eth.Indexer = &nodeindexer.NodeIndexer{}
+
+// reorgSafetyBlocks mirrors the number of confirmations after which blocks are not scanned again.
+const reorgSafetyBlocks = 12
+
+func transferToken(t *testing.T, wallet *ethtest.Wallet,
+	contract common.Address, to common.Address, amount int64) *types.Transaction {
+	return wallet.Call(t, contract, erc20.TransferData(to, big.NewInt(amount)), 100000)
+}
+
+func deployToken(t *testing.T, wallet *ethtest.Wallet, supply byte) *types.Transaction {
+	return wallet.Deploy(t, tokenCode(supply), 200000)
+}
+
+// tokenCode returns the creation code of a minimal ERC20 token, which assigns the supply to its
+// creator and only implements transfer(address,uint256). The balance of an address is stored in
+// the storage slot of the same number.
+func tokenCode(supply byte) []byte {
+	const revertPlaceholder = 0xff
+	// Revert unless the function is transfer(address,uint256). The function selector is the first
+	// four bytes of the call data, which are shifted down by dividing by 2^224.
+	runtime := []byte{byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD), byte(vm.PUSH29), 1}
+	runtime = append(runtime, make([]byte, 28)...)
+	runtime = append(runtime, byte(vm.SWAP1), byte(vm.DIV), byte(vm.PUSH4))
+	runtime = append(runtime, erc20.TransferData(common.Address{}, big.NewInt(0))[:4]...)
+	runtime = append(runtime,
+		byte(vm.EQ), byte(vm.ISZERO), byte(vm.PUSH1), revertPlaceholder, byte(vm.JUMPI),
+		// Stack: to, amount, balance of the caller.
+		byte(vm.PUSH1), 4, byte(vm.CALLDATALOAD),
+		byte(vm.PUSH1), 36, byte(vm.CALLDATALOAD),
+		byte(vm.CALLER), byte(vm.SLOAD),
+		// Revert if the balance is lower than the amount.
+		byte(vm.DUP2), byte(vm.DUP2), byte(vm.LT), byte(vm.PUSH1), revertPlaceholder, byte(vm.JUMPI),
+		// Subtract the amount from the balance of the caller.
+		byte(vm.DUP2), byte(vm.SWAP1), byte(vm.SUB), byte(vm.CALLER), byte(vm.SSTORE),
+		// Add the amount to the balance of the recipient.
+		byte(vm.DUP2), byte(vm.SLOAD), byte(vm.DUP2), byte(vm.ADD), byte(vm.DUP3), byte(vm.SSTORE),
+		// Emit Transfer(caller, to, amount).
+		byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.CALLER),
+		byte(vm.PUSH32),
+	)
+	runtime = append(runtime, erc20.TransferEventID.Bytes()...)
+	runtime = append(runtime,
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG3),
+		// Return true.
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+	)
+	revert := byte(len(runtime))
+	for index := 0; index < len(runtime)-1; index++ {
+		if runtime[index] == byte(vm.PUSH1) && runtime[index+1] == revertPlaceholder {
+			runtime[index+1] = revert
+		}
+	}
+	runtime = append(runtime, byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.REVERT))
+
+	constructor := []byte{
+		byte(vm.PUSH1), supply, byte(vm.CALLER), byte(vm.SSTORE),
+		// Return the runtime code following the constructor.
+		byte(vm.PUSH1), byte(len(runtime)), byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.CODECOPY), byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}
+	constructor[8] = byte(len(constructor))
+	return append(constructor, runtime...)
+}
+
+// batchTransferCode returns the creation code of a contract emitting two Transfer events from the
+// caller to the address in the call data, of 1 and 2 tokens, like a token transferring twice in
+// one transaction.
+func batchTransferCode() []byte {
+	runtime := []byte{}
+	for _, amount := range []byte{1, 2} {
+		runtime = append(runtime,
+			byte(vm.PUSH1), amount, byte(vm.PUSH1), 0, byte(vm.MSTORE),
+			byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD),
+			byte(vm.CALLER),
+			byte(vm.PUSH32),
+		)
+		runtime = append(runtime, erc20.TransferEventID.Bytes()...)
+		runtime = append(runtime, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG3))
+	}
+	runtime = append(runtime, byte(vm.STOP))
+	constructor := []byte{
+		byte(vm.PUSH1), byte(len(runtime)), byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+		byte(vm.CODECOPY), byte(vm.PUSH1), 0, byte(vm.RETURN),
+	}
+	constructor[4] = byte(len(constructor))
+	return append(constructor, runtime...)
+}
+
+func TestTransactions(t *testing.T) {
+	ours, other := ethtest.NewWallet(t), ethtest.NewWallet(t)
+	chain := ethtest.NewChain(ours, other)
+
+	chain.Send(t, other.Transfer(t, other.Address, 1)) // before the birthday
+	chain.Mine()
+	chain.Send(t, other.Transfer(t, ours.Address, 1000))
+	chain.Mine()
+	chain.Send(t, ours.Transfer(t, other.Address, 300))
+	chain.Send(t, other.Transfer(t, other.Address, 2))
+	chain.Mine()
+	for i := 0; i < 20; i++ {
+		chain.Mine()
+	}
+	db, err := nodeindexer.NewDB(test.TstTempFile("nodeindexer-db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	indexer := nodeindexer.NewNodeIndexer(chain, ethtest.Net, 2, db)
+
+	transactions, err := indexer.Transactions(ours.Address, chain.Head())
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	require.Equal(t, accounts.TxTypeSend, transactions[0].Type())
+	require.Equal(t, big.NewInt(300), transactions[0].Amount().BigInt())
+	require.Equal(t, 21, transactions[0].NumConfirmations())
+	require.Equal(t, big.NewInt(21000*1e9), transactions[0].Fee().BigInt())
+	require.Equal(t, other.Address.Hex(), transactions[0].Addresses()[0].Address)
+	require.Equal(t, accounts.TxTypeReceive, transactions[1].Type())
+	require.Equal(t, big.NewInt(1000), transactions[1].Amount().BigInt())
+	require.Equal(t, 22, transactions[1].NumConfirmations())
+	require.Equal(t, int64(1546300800+2*15), transactions[1].Timestamp().Unix())
+
+	// Blocks with enough confirmations are not scanned again.
+	chain.FetchedBlocks = 0
+	chain.Send(t, ours.Transfer(t, ours.Address, 5))
+	chain.Mine()
+	transactions, err = indexer.Transactions(ours.Address, chain.Head())
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+	require.Equal(t, accounts.TxTypeSendSelf, transactions[0].Type())
+	require.Equal(t, 1, transactions[0].NumConfirmations())
+	require.Equal(t, 22, transactions[1].NumConfirmations())
+	require.Equal(t, reorgSafetyBlocks+1, chain.FetchedBlocks)
+
+	// The scan is stored, so that only the recent blocks are scanned after a restart.
+	chain.FetchedBlocks = 0
+	restartedTransactions, err := nodeindexer.NewNodeIndexer(chain, ethtest.Net, 2, db).
+		Transactions(ours.Address, chain.Head())
+	require.NoError(t, err)
+	require.Equal(t, transactions, restartedTransactions)
+	require.Equal(t, reorgSafetyBlocks, chain.FetchedBlocks)
+
+	// The stored scan is not used if the birthday block changes.
+	chain.FetchedBlocks = 0
+	transactions, err = nodeindexer.NewNodeIndexer(chain, ethtest.Net, 0, db).
+		Transactions(ours.Address, chain.Head())
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+	require.Equal(t, int(chain.Head().Int64())+1, chain.FetchedBlocks)
+
+	_, err = indexer.InternalTransactions(ours.Address, chain.Head())
+	require.Equal(t, eth.ErrInternalTransactionsUnsupported, errp.Cause(err))
+}
+
+func TestTokenTransactions(t *testing.T) {
+	ours, other := ethtest.NewWallet(t), ethtest.NewWallet(t)
+	chain := ethtest.NewChain(ours, other)
+
+	contract := chain.Send(t, deployToken(t, other, 100)).ContractAddress
+	otherContract := chain.Send(t, deployToken(t, other, 100)).ContractAddress
+	chain.Mine()
+	for _, tx := range []*types.Transaction{
+		transferToken(t, other, contract, ours.Address, 70),
+		transferToken(t, other, otherContract, ours.Address, 1),
+	} {
+		require.Equal(t, types.ReceiptStatusSuccessful, chain.Send(t, tx).Status)
+	}
+	chain.Mine()
+	for i := 0; i < 20; i++ {
+		chain.Mine()
+	}
+	receipt := chain.Send(t, transferToken(t, ours, contract, other.Address, 20))
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	// The transfer exceeds the remaining balance of 50 and is reverted.
+	receipt = chain.Send(t, transferToken(t, ours, contract, other.Address, 51))
+	require.Equal(t, types.ReceiptStatusFailed, receipt.Status)
+	require.Empty(t, receipt.Logs)
+	chain.Mine()
+	balance := chain.Storage(contract, common.BytesToHash(ours.Address.Bytes()))
+	require.Equal(t, common.BigToHash(big.NewInt(50)), balance)
+	indexer := nodeindexer.NewNodeIndexer(chain, ethtest.Net, 0, nil)
+
+	for i := 0; i < 2; i++ {
+		transactions, err := indexer.TokenTransactions(ours.Address, contract, chain.Head())
+		require.NoError(t, err)
+		require.Len(t, transactions, 2)
+		require.Equal(t, accounts.TxTypeSend, transactions[0].Type())
+		require.Equal(t, big.NewInt(20), transactions[0].Amount().BigInt())
+		require.Equal(t, other.Address.Hex(), transactions[0].Addresses()[0].Address)
+		require.Equal(t, 1, transactions[0].NumConfirmations())
+		require.Equal(t, accounts.TxTypeReceive, transactions[1].Type())
+		require.Equal(t, big.NewInt(70), transactions[1].Amount().BigInt())
+		require.Equal(t, 22, transactions[1].NumConfirmations())
+	}
+}
+
+func TestMultipleTokenTransfers(t *testing.T) {
+	ours, other := ethtest.NewWallet(t), ethtest.NewWallet(t)
+	chain := ethtest.NewChain(ours, other)
+
+	contract := chain.Send(t, other.Deploy(t, batchTransferCode(), 100000)).ContractAddress
+	chain.Mine()
+	receipt := chain.Send(t,
+		other.Call(t, contract, common.LeftPadBytes(ours.Address.Bytes(), 32), 100000))
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Len(t, receipt.Logs, 2)
+	chain.Mine()
+	for i := 0; i < 20; i++ {
+		chain.Mine()
+	}
+	indexer := nodeindexer.NewNodeIndexer(chain, ethtest.Net, 0, nil)
+
+	transactions, err := indexer.TokenTransactions(ours.Address, contract, chain.Head())
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	for index, transaction := range transactions {
+		require.Equal(t, receipt.TxHash.Hex(), transaction.ID())
+		require.Equal(t, accounts.TxTypeReceive, transaction.Type())
+		require.Equal(t, big.NewInt(int64(index+1)), transaction.Amount().BigInt())
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodeindexer
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	ethtypes "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Transaction is a confirmed transaction found in a block. It implements accounts.Transaction.
+type Transaction struct {
+	hash        common.Hash
+	blockNumber uint64
+	timestamp   time.Time
+	gasUsed     uint64
+	gasPrice    *big.Int
+	txType      accounts.TxType
+	// address is the recipient of the ether or tokens.
+	address common.Address
+	amount  *big.Int
+	// data is the calldata of the transaction, nil for token transfers.
+	data []byte
+
+	// numConfirmations is set relative to the block the transactions are queried for.
+	numConfirmations int
+}
+
+// assertion because not implementing the interface fails silently.
+var _ ethtypes.EthereumTransaction = &Transaction{}
+
+// newTransaction creates a transaction from the point of view of the given account address.
+func newTransaction(
+	account common.Address,
+	tx *types.Transaction,
+	receipt *types.Receipt,
+	header *types.Header,
+	from common.Address,
+	to common.Address,
+	amount *big.Int,
+	data []byte,
+) *Transaction {
+	var txType accounts.TxType
+	switch {
+	case from == account && to == account:
+		txType = accounts.TxTypeSendSelf
+	case from == account:
+		txType = accounts.TxTypeSend
+	default:
+		txType = accounts.TxTypeReceive
+	}
+	return &Transaction{
+		hash:        tx.Hash(),
+		blockNumber: header.Number.Uint64(),
+		timestamp:   time.Unix(header.Time.Int64(), 0),
+		gasUsed:     receipt.GasUsed,
+		gasPrice:    tx.GasPrice(),
+		txType:      txType,
+		address:     to,
+		amount:      amount,
+		data:        data,
+	}
+}
+
+// withConfirmations returns a copy of the transaction with the number of confirmations at the
+// given block.
+func (tx Transaction) withConfirmations(endBlock uint64) *Transaction {
+	tx.numConfirmations = int(endBlock - tx.blockNumber + 1)
+	return &tx
+}
+
+// Fee implements accounts.Transaction.
+func (tx *Transaction) Fee() *coin.Amount {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.gasUsed), tx.gasPrice)
+	amount := coin.NewAmount(fee)
+	return &amount
+}
+
+// Timestamp implements accounts.Transaction.
+func (tx *Transaction) Timestamp() *time.Time {
+	timestamp := tx.timestamp
+	return &timestamp
+}
+
+// ID implements accounts.Transaction.
+func (tx *Transaction) ID() string {
+	return tx.hash.Hex()
+}
+
+// NumConfirmations implements accounts.Transaction.
+func (tx *Transaction) NumConfirmations() int {
+	return tx.numConfirmations
+}
+
+// Type implements accounts.Transaction.
+func (tx *Transaction) Type() accounts.TxType {
+	return tx.txType
+}
+
+// Amount implements accounts.Transaction.
+func (tx *Transaction) Amount() coin.Amount {
+	return coin.NewAmount(tx.amount)
+}
+
+// Addresses implements accounts.Transaction.
+func (tx *Transaction) Addresses() []accounts.AddressAndAmount {
+	return []accounts.AddressAndAmount{{
+		Address: tx.address.Hex(),
+		Amount:  tx.Amount(),
+	}}
+}
+
+// Note implements accounts.Transaction. Notes are stored and added by the account.
+func (tx *Transaction) Note() string {
+	return ""
+}
+
+// Gas implements ethtypes.EthereumTransaction.
+func (tx *Transaction) Gas() uint64 {
+	return tx.gasUsed
+}
+
+// ReplacedTxID implements ethtypes.EthereumTransaction. Replacements are stored and added by the
+// account.
+func (tx *Transaction) ReplacedTxID() string {
+	return ""
+}
+
+// Data implements ethtypes.EthereumTransaction.
+func (tx *Transaction) Data() []byte {
+	return tx.data
+}
+
+// ContractCall implements ethtypes.EthereumTransaction. The calldata is decoded by the account.
+func (tx *Transaction) ContractCall() *ethtypes.ContractCall {
+	return nil
+}
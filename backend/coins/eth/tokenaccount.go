@@ -0,0 +1,442 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts/errors"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/synchronizer"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// TokenAccount is an ERC20 token account. It shares the keystores, the address, the nonce and the
+// database of the parent Ethereum account. Fees are paid from the balance of the parent account.
+type TokenAccount struct {
+	locker.Locker
+
+	synchronizer *synchronizer.Synchronizer
+	parent       *Account
+	coin         *TokenCoin
+	code         string
+	name         string
+	getNotifier  func(*signing.Configuration) accounts.Notifier
+	notifier     accounts.Notifier
+	offline      bool
+	onEvent      func(accounts.Event)
+
+	initialized bool
+	// enqueueUpdateCh is used to invoke an account update outside of the regular poll update
+	// interval.
+	enqueueUpdateCh chan struct{}
+
+	balance      coin.Amount
+	transactions []accounts.Transaction
+
+	quitChan chan struct{}
+
+	log *logrus.Entry
+}
+
+// NewTokenAccount creates a new token account on top of the given Ethereum account.
+func NewTokenAccount(
+	parent *Account,
+	tokenCoin *TokenCoin,
+	code string,
+	name string,
+	getNotifier func(*signing.Configuration) accounts.Notifier,
+	onEvent func(accounts.Event),
+	log *logrus.Entry,
+) *TokenAccount {
+	log = log.WithField("group", "eth").
+		WithFields(logrus.Fields{"coin": tokenCoin.String(), "code": code, "name": name})
+	log.Debug("Creating new token account")
+
+	account := &TokenAccount{
+		parent:      parent,
+		coin:        tokenCoin,
+		code:        code,
+		name:        name,
+		getNotifier: getNotifier,
+		onEvent:     onEvent,
+		balance:     coin.NewAmountFromInt64(0),
+
+		initialized:     false,
+		enqueueUpdateCh: make(chan struct{}, 1),
+		quitChan:        make(chan struct{}),
+		log:             log,
+	}
+	account.synchronizer = synchronizer.NewSynchronizer(
+		func() { onEvent(accounts.EventSyncStarted) },
+		func() {
+			if !account.initialized {
+				account.initialized = true
+				onEvent(accounts.EventStatusChanged)
+			}
+			onEvent(accounts.EventSyncDone)
+		},
+		log,
+	)
+	return account
+}
+
+// Parent returns the Ethereum account the token account is based on.
+func (account *TokenAccount) Parent() *Account {
+	return account.parent
+}
+
+// Info implements accounts.Interface.
+func (account *TokenAccount) Info() *accounts.Info {
+	return account.parent.Info()
+}
+
+// Code implements accounts.Interface.
+func (account *TokenAccount) Code() string {
+	return account.code
+}
+
+// Name implements accounts.Interface.
+func (account *TokenAccount) Name() string {
+	return account.name
+}
+
+// Coin implements accounts.Interface.
+func (account *TokenAccount) Coin() coin.Coin {
+	return account.coin
+}
+
+// Initialize implements accounts.Interface. The parent account is initialized as well.
+func (account *TokenAccount) Initialize() error {
+	if err := account.parent.Initialize(); err != nil {
+		return err
+	}
+	defer account.Lock()()
+	if account.notifier != nil {
+		account.log.Debug("Account has already been initialized")
+		return nil
+	}
+	account.notifier = account.getNotifier(account.parent.signingConfiguration)
+	account.parent.coin.RegisterOnConnectionStatusChangedEvent(account.onConnectionStatusChanged)
+	go account.poll()
+	return nil
+}
+
+func (account *TokenAccount) poll() {
+	timer := time.After(0)
+	for {
+		select {
+		case <-account.quitChan:
+			return
+		default:
+			select {
+			case <-account.quitChan:
+				return
+			case <-timer:
+			case <-account.enqueueUpdateCh:
+				account.log.Info("extraordinary account update invoked")
+			}
+			if err := account.update(); err != nil {
+				account.log.WithError(err).Error("error updating account")
+				if !account.offline {
+					account.offline = true
+					account.onEvent(accounts.EventStatusChanged)
+				}
+			} else if account.offline {
+				account.offline = false
+				account.onEvent(accounts.EventStatusChanged)
+			}
+			timer = time.After(pollInterval)
+		}
+	}
+}
+
+// pendingTransactions returns the pending outgoing token transfers, which are the pending outgoing
+// transactions of the parent account calling the token contract.
+func (account *TokenAccount) pendingTransactions(confirmedTxs []accounts.Transaction) (
+	[]accounts.Transaction, error) {
+	parentTransactions, err := account.parent.pendingOutgoingTransactions(confirmedTxs)
+	if err != nil {
+		return nil, err
+	}
+	transactions := []accounts.Transaction{}
+	for _, transaction := range parentTransactions {
+		tx := transaction.(wrappedTransaction).tx
+		if tx.To() == nil || *tx.To() != account.coin.token.ContractAddress() {
+			continue
+		}
+		recipient, amount, ok := erc20.DecodeTransferData(tx.Data())
+		if !ok {
+			continue
+		}
+		transactions = append(transactions, pendingTokenTransaction{
+			wrappedTransaction: wrappedTransaction{tx: tx},
+			recipient:          recipient,
+			amount:             amount,
+		})
+	}
+	return transactions, nil
+}
+
+// onConnectionStatusChanged updates the account right away when the connection to the node is
+// lost or re-established.
+func (account *TokenAccount) onConnectionStatusChanged(blockchain.Status) {
+	account.enqueueUpdate()
+}
+
+// enqueueUpdate invokes an account update without waiting for the poll loop. A pending update
+// request covers this one as well.
+func (account *TokenAccount) enqueueUpdate() {
+	select {
+	case account.enqueueUpdateCh <- struct{}{}:
+	default:
+	}
+}
+
+func (account *TokenAccount) update() error {
+	defer account.synchronizer.IncRequestsCounter()()
+
+	client, err := account.parent.coin.Client()
+	if err != nil {
+		return err
+	}
+	header, err := client.HeaderByNumber(context.TODO(), nil)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	blockNumber := header.Number
+
+	address := account.parent.address.Address
+	contractAddress := account.coin.token.ContractAddress()
+	indexer, err := account.parent.coin.Indexer()
+	if err != nil {
+		return err
+	}
+	confirmedTransactions, err := indexer.TokenTransactions(
+		address, contractAddress, blockNumber)
+	if err != nil {
+		return err
+	}
+	pendingTransactions, err := account.pendingTransactions(confirmedTransactions)
+	if err != nil {
+		return err
+	}
+	account.transactions = append(pendingTransactions, confirmedTransactions...)
+	for _, transaction := range account.transactions {
+		if err := account.notifier.Put([]byte(transaction.ID())); err != nil {
+			return err
+		}
+	}
+
+	output, err := client.CallContract(context.TODO(), ethereum.CallMsg{
+		From: address,
+		To:   &contractAddress,
+		Data: erc20.BalanceOfData(address),
+	}, blockNumber)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	balance, err := erc20.UnpackBalanceOf(output)
+	if err != nil {
+		return err
+	}
+	account.balance = coin.NewAmount(balance)
+	return nil
+}
+
+// Initialized implements accounts.Interface.
+func (account *TokenAccount) Initialized() bool {
+	return account.initialized
+}
+
+// Offline implements accounts.Interface.
+func (account *TokenAccount) Offline() bool {
+	return account.offline || account.parent.Offline()
+}
+
+// FatalError implements accounts.Interface.
+func (account *TokenAccount) FatalError() bool {
+	return false
+}
+
+// Close implements accounts.Interface. The parent account, which owns the database, is closed
+// separately.
+func (account *TokenAccount) Close() {
+	account.log.Info("Closed account")
+	func() {
+		defer account.Lock()()
+		if account.notifier != nil {
+			close(account.quitChan)
+		}
+	}()
+	account.initialized = false
+	account.onEvent(accounts.EventStatusChanged)
+}
+
+// Notifier implements accounts.Interface.
+func (account *TokenAccount) Notifier() accounts.Notifier {
+	return account.notifier
+}
+
+// Transactions implements accounts.Interface.
+func (account *TokenAccount) Transactions() ([]accounts.Transaction, error) {
+	account.synchronizer.WaitSynchronized()
+	return account.parent.annotateTransactions(account.transactions)
+}
+
+// SetTxNote implements accounts.Interface. Notes are shared with the parent account.
+func (account *TokenAccount) SetTxNote(txID string, note string) error {
+	return account.parent.SetTxNote(txID, note)
+}
+
+// ExportLabels implements accounts.Interface.
+func (account *TokenAccount) ExportLabels() ([]accounts.Label, error) {
+	return account.parent.ExportLabels()
+}
+
+// ImportLabels implements accounts.Interface.
+func (account *TokenAccount) ImportLabels(labels []accounts.Label) (int, error) {
+	return account.parent.ImportLabels(labels)
+}
+
+// Balance implements accounts.Interface.
+func (account *TokenAccount) Balance() (*accounts.Balance, error) {
+	account.synchronizer.WaitSynchronized()
+	return accounts.NewBalance(account.balance, coin.NewAmountFromInt64(0)), nil
+}
+
+// newTx creates a transaction calling `transfer()` of the token contract. The returned proposal
+// transfers no ether, its fee is paid by the parent account.
+func (account *TokenAccount) newTx(
+	recipientAddress string,
+	amount coin.SendAmount,
+	feeTargetCode accounts.FeeTargetCode,
+	data []byte,
+) (*TxProposal, *big.Int, error) {
+	if !common.IsHexAddress(recipientAddress) {
+		return nil, nil, errp.WithStack(errors.ErrInvalidAddress)
+	}
+	if len(data) != 0 {
+		return nil, nil, errp.WithStack(errors.ErrInvalidData)
+	}
+	var value *big.Int
+	if amount.SendAll() {
+		value = account.balance.BigInt()
+	} else {
+		allowZero := false
+		parsedAmount, err := amount.Amount(unit(account.coin.token.Decimals()), allowZero)
+		if err != nil {
+			return nil, nil, err
+		}
+		value = parsedAmount.BigInt()
+	}
+	if value.Sign() == 0 || value.Cmp(account.balance.BigInt()) == 1 {
+		return nil, nil, errp.WithStack(errors.ErrInsufficientFunds)
+	}
+	txProposal, err := account.parent.newTx(
+		account.coin.token.ContractAddress().Hex(),
+		coin.NewSendAmount("0"),
+		feeTargetCode,
+		erc20.TransferData(common.HexToAddress(recipientAddress), value),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return txProposal, value, nil
+}
+
+// SendTx implements accounts.Interface.
+func (account *TokenAccount) SendTx(
+	recipientAddress string,
+	amount coin.SendAmount,
+	feeTargetCode accounts.FeeTargetCode,
+	_ map[wire.OutPoint]struct{},
+	data []byte) error {
+	account.log.Info("Signing and sending token transaction")
+	txProposal, _, err := account.newTx(recipientAddress, amount, feeTargetCode, data)
+	if err != nil {
+		return err
+	}
+	if err := account.parent.keystores.SignTransaction(txProposal); err != nil {
+		return err
+	}
+	client, err := account.parent.coin.Client()
+	if err != nil {
+		return err
+	}
+	if err := client.SendTransaction(context.TODO(), txProposal.Tx); err != nil {
+		return errp.WithStack(err)
+	}
+	if err := account.parent.storePendingOutgoingTransaction(txProposal.Tx); err != nil {
+		return err
+	}
+	account.parent.enqueueUpdate()
+	account.enqueueUpdate()
+	return nil
+}
+
+// FeeTargets implements accounts.Interface.
+func (account *TokenAccount) FeeTargets() ([]accounts.FeeTarget, accounts.FeeTargetCode) {
+	return account.parent.FeeTargets()
+}
+
+// TxProposal implements accounts.Interface. The amount and the total are token amounts, the fee is
+// an amount of the parent coin.
+func (account *TokenAccount) TxProposal(
+	recipientAddress string,
+	amount coin.SendAmount,
+	feeTargetCode accounts.FeeTargetCode,
+	_ map[wire.OutPoint]struct{},
+	data []byte) (coin.Amount, coin.Amount, coin.Amount, error) {
+	txProposal, value, err := account.newTx(recipientAddress, amount, feeTargetCode, data)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+	}
+	return coin.NewAmount(value), coin.NewAmount(txProposal.Fee), coin.NewAmount(value), nil
+}
+
+// GetUnusedReceiveAddresses implements accounts.Interface.
+func (account *TokenAccount) GetUnusedReceiveAddresses() []accounts.Address {
+	return account.parent.GetUnusedReceiveAddresses()
+}
+
+// VerifyAddress implements accounts.Interface.
+func (account *TokenAccount) VerifyAddress(addressID string) (bool, error) {
+	return account.parent.VerifyAddress(addressID)
+}
+
+// ConvertToLegacyAddress implements accounts.Interface.
+func (account *TokenAccount) ConvertToLegacyAddress(string) (btcutil.Address, error) {
+	panic("not used")
+}
+
+// Keystores implements accounts.Interface.
+func (account *TokenAccount) Keystores() *keystore.Keystores {
+	return account.parent.Keystores()
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts/mocks"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/db"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/test"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenIndexer is an indexer without token transfers.
+type tokenIndexer struct {
+	Indexer
+}
+
+func (tokenIndexer) TokenTransactions(common.Address, common.Address, *big.Int) (
+	[]accounts.Transaction, error) {
+	return []accounts.Transaction{}, nil
+}
+
+// newTestNode starts a JSON-RPC server answering the requests of a token account. The first
+// request is answered only after release is closed.
+func newTestNode(t *testing.T, tokenBalance int64, release <-chan struct{}) *httptest.Server {
+	header, err := json.Marshal(&types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(1),
+		Time:       big.NewInt(1546300800),
+	})
+	require.NoError(t, err)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result interface{}
+		switch request.Method {
+		case "eth_getBlockByNumber":
+			result = json.RawMessage(header)
+		case "eth_call":
+			result = hexutil.Bytes(common.LeftPadBytes(big.NewInt(tokenBalance).Bytes(), 32))
+		default:
+			http.Error(w, "unexpected method "+request.Method, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": request.ID, "result": result,
+		})
+	}))
+}
+
+// TestTokenAccountConnect checks that a token account waiting for the first connection to the node
+// does not block the coin connecting to it.
+func TestTokenAccountConnect(t *testing.T) {
+	release := make(chan struct{})
+	node := newTestNode(t, 1000, release)
+	defer node.Close()
+
+	coin := NewCoin("teth", params.TestnetChainConfig, "", node.URL,
+		func(NodeClient) Indexer { return tokenIndexer{} })
+	defer coin.Close()
+
+	accountDB, err := db.NewDB(test.TstTempFile("eth-token-account-db"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, accountDB.Close()) }()
+	address := common.HexToAddress("0x2dD1Fd1a1E6Ec5e95aB0E4E8F3b5e0B2A1E9B43f")
+	// The parent account is already initialized, so it does not poll the node itself.
+	parent := &Account{
+		coin: coin,
+		db:   accountDB,
+		signingConfiguration: signing.NewAddressConfiguration(
+			signing.ScriptTypeP2WPKH, signing.NewEmptyAbsoluteKeypath(), address.Hex()),
+		address: Address{Address: address},
+		log:     logging.Get().WithGroup("eth_test"),
+	}
+
+	events := make(chan accounts.Event, 10)
+	tokenAccount := NewTokenAccount(
+		parent,
+		NewTokenCoin(coin, erc20.NewToken(
+			common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"), "USDT", 6)),
+		"teth-erc20-usdt",
+		"Tether",
+		func(*signing.Configuration) accounts.Notifier { return &mocks.Notifier{} },
+		func(event accounts.Event) { events <- event },
+		logging.Get().WithGroup("eth_test"),
+	)
+	defer tokenAccount.Close()
+
+	coin.Initialize()
+	require.NoError(t, tokenAccount.Initialize())
+	waitEvent := func(expected accounts.Event) {
+		for {
+			select {
+			case event := <-events:
+				if event == expected {
+					return
+				}
+			case <-time.After(10 * time.Second):
+				require.FailNow(t, "timeout waiting for event", expected)
+			}
+		}
+	}
+	// The update of the token account waits for the connection, which is established now.
+	waitEvent(accounts.EventSyncStarted)
+	close(release)
+	waitEvent(accounts.EventSyncDone)
+
+	balance, err := tokenAccount.Balance()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000), balance.Available().BigInt())
+	require.False(t, tokenAccount.Offline())
+}
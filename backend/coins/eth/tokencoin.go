@@ -0,0 +1,90 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/erc20"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+)
+
+// TokenCoin models an ERC20 token on top of an Ethereum coin. It uses the node and block explorer
+// of the parent coin.
+type TokenCoin struct {
+	observable.Implementation
+	parent *Coin
+	token  *erc20.Token
+}
+
+// NewTokenCoin creates a new token coin of the given parent coin.
+func NewTokenCoin(parent *Coin, token *erc20.Token) *TokenCoin {
+	return &TokenCoin{
+		parent: parent,
+		token:  token,
+	}
+}
+
+// Parent returns the Ethereum coin the token lives on. Fees are paid in the parent coin.
+func (coin *TokenCoin) Parent() *Coin {
+	return coin.parent
+}
+
+// Token returns the token.
+func (coin *TokenCoin) Token() *erc20.Token {
+	return coin.token
+}
+
+// Initialize implements coin.Coin.
+func (coin *TokenCoin) Initialize() {
+	coin.parent.Initialize()
+}
+
+// Code implements coin.Coin.
+func (coin *TokenCoin) Code() string {
+	return coin.parent.Code() + "-erc20-" + strings.ToLower(coin.token.Symbol())
+}
+
+// Unit implements coin.Coin.
+func (coin *TokenCoin) Unit() string {
+	return coin.token.Symbol()
+}
+
+// FormatAmount implements coin.Coin.
+func (coin *TokenCoin) FormatAmount(amount coin.Amount) string {
+	return formatAmount(amount.BigInt(), coin.token.Decimals())
+}
+
+// ToUnit implements coin.Coin.
+func (coin *TokenCoin) ToUnit(amount coin.Amount) float64 {
+	return toUnit(amount.BigInt(), coin.token.Decimals())
+}
+
+// BlockExplorerTransactionURLPrefix implements coin.Coin.
+func (coin *TokenCoin) BlockExplorerTransactionURLPrefix() string {
+	return coin.parent.BlockExplorerTransactionURLPrefix()
+}
+
+// SmallestUnit implements coin.Coin. Tokens have no named smallest unit, so it is described as a
+// fraction of the token, e.g. "USDT*10^-6".
+func (coin *TokenCoin) SmallestUnit() string {
+	return fmt.Sprintf("%s*10^-%d", coin.token.Symbol(), coin.token.Decimals())
+}
+
+func (coin *TokenCoin) String() string {
+	return coin.Code()
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	ethtypes "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/types"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
@@ -72,7 +73,90 @@ func (tx wrappedTransaction) Addresses() []accounts.AddressAndAmount {
 	}}
 }
 
+// Note implements accounts.Transaction. The note is added by annotatedTransaction.
+func (tx wrappedTransaction) Note() string {
+	return ""
+}
+
 // Gas implements ethtypes.EthereumTransaction.
 func (tx wrappedTransaction) Gas() uint64 {
 	return tx.tx.Gas()
 }
+
+// ReplacedTxID implements ethtypes.EthereumTransaction. It is added by annotatedTransaction.
+func (tx wrappedTransaction) ReplacedTxID() string {
+	return ""
+}
+
+// Data implements ethtypes.EthereumTransaction.
+func (tx wrappedTransaction) Data() []byte {
+	return tx.tx.Data()
+}
+
+// ContractCall implements ethtypes.EthereumTransaction. It is added by annotatedTransaction.
+func (tx wrappedTransaction) ContractCall() *ethtypes.ContractCall {
+	return nil
+}
+
+// annotatedTransaction adds the locally stored information to a transaction: the user defined
+// note, the transaction it replaced and the calldata decoded with the stored contract ABIs.
+type annotatedTransaction struct {
+	accounts.Transaction
+	note         string
+	replacedTxID string
+	contractCall *ethtypes.ContractCall
+}
+
+// assertion because not implementing the interface fails silently.
+var _ ethtypes.EthereumTransaction = annotatedTransaction{}
+
+// Note implements accounts.Transaction.
+func (tx annotatedTransaction) Note() string {
+	return tx.note
+}
+
+// Gas implements ethtypes.EthereumTransaction.
+func (tx annotatedTransaction) Gas() uint64 {
+	return tx.Transaction.(ethtypes.EthereumTransaction).Gas()
+}
+
+// ReplacedTxID implements ethtypes.EthereumTransaction.
+func (tx annotatedTransaction) ReplacedTxID() string {
+	return tx.replacedTxID
+}
+
+// Data implements ethtypes.EthereumTransaction.
+func (tx annotatedTransaction) Data() []byte {
+	return tx.Transaction.(ethtypes.EthereumTransaction).Data()
+}
+
+// ContractCall implements ethtypes.EthereumTransaction.
+func (tx annotatedTransaction) ContractCall() *ethtypes.ContractCall {
+	return tx.contractCall
+}
+
+// pendingTokenTransaction is an outgoing pending ERC20 token transfer. The amount and the
+// recipient are decoded from the `transfer()` call.
+type pendingTokenTransaction struct {
+	wrappedTransaction
+	recipient common.Address
+	amount    *big.Int
+}
+
+// Amount implements accounts.Transaction.
+func (tx pendingTokenTransaction) Amount() coin.Amount {
+	return coin.NewAmount(tx.amount)
+}
+
+// Addresses implements accounts.Transaction.
+func (tx pendingTokenTransaction) Addresses() []accounts.AddressAndAmount {
+	return []accounts.AddressAndAmount{{
+		Address: tx.recipient.Hex(),
+		Amount:  tx.Amount(),
+	}}
+}
+
+// Data implements ethtypes.EthereumTransaction. The transfer is shown instead of the call.
+func (tx pendingTokenTransaction) Data() []byte {
+	return nil
+}
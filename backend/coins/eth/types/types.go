@@ -18,4 +18,27 @@ package types
 type EthereumTransaction interface {
 	// Gas returns the gas limit for pending tx, and the gas used for confirmed tx.
 	Gas() uint64
+	// ReplacedTxID returns the ID of the pending transaction which was replaced by this transaction
+	// (sped up or canceled), or an empty string.
+	ReplacedTxID() string
+	// Data returns the calldata of the contract call made by the transaction. It is empty for plain
+	// ether transfers and for token and internal transactions.
+	Data() []byte
+	// ContractCall returns the decoded calldata, or nil if the transaction is not a contract call
+	// or the ABI of the contract is unknown.
+	ContractCall() *ContractCall
+}
+
+// ContractCallArgument is a decoded argument of a contract call.
+type ContractCallArgument struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// ContractCall is the human-readable decoding of the calldata of a contract call.
+type ContractCall struct {
+	// Method is the signature of the called method, e.g. `transfer(address,uint256)`.
+	Method    string                 `json:"method"`
+	Arguments []ContractCallArgument `json:"arguments"`
 }
@@ -14,7 +14,14 @@
 
 package config
 
-import "github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/types"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+)
 
 // Account holds information related to an account.
 type Account struct {
@@ -22,6 +29,27 @@ type Account struct {
 	Name          string                 `json:"name"`
 	Code          string                 `json:"code"`
 	Configuration *signing.Configuration `json:"configuration"`
+	// Keystore is true for accounts which are derived from the registered keystore. Only the script
+	// type and the keypath of these accounts are persisted. The signing configuration is derived
+	// when the keystore is registered. In watch-only mode, the signing configuration is persisted
+	// as well, so the account can be loaded while the keystore is absent.
+	Keystore   bool                    `json:"keystore,omitempty"`
+	ScriptType signing.ScriptType      `json:"scriptType,omitempty"`
+	Keypath    signing.AbsoluteKeypath `json:"keypath,omitempty"`
+	// RootFingerprint identifies the keystore a keystore account is derived from: the hex encoded
+	// BIP32 root fingerprint, or the concatenated fingerprints of all cosigners in multisig mode.
+	// Accounts persisted before the fingerprints were recorded have an empty fingerprint. They are
+	// claimed by the next keystore which is registered.
+	RootFingerprint string `json:"rootFingerprint,omitempty"`
+	// VerifiedExtendedPublicKeys are the extended public keys of the account which the user
+	// verified on the screen of their keystores.
+	VerifiedExtendedPublicKeys []string `json:"verifiedExtendedPublicKeys,omitempty"`
+	// GapLimits overrides the default gap limits of btc based accounts. Nil means the defaults are
+	// used.
+	GapLimits *types.GapLimits `json:"gapLimits,omitempty"`
+	// Archived accounts are not loaded, but their configuration and their cached data is kept, so
+	// they can be restored at any time.
+	Archived bool `json:"archived,omitempty"`
 }
 
 // AccountsConfig persists the list of accounts added to the app.
@@ -29,6 +57,55 @@ type AccountsConfig struct {
 	Accounts []Account `json:"accounts"`
 }
 
+// Lookup returns the account with the given code, or nil if no such account exists.
+func (accountsConfig AccountsConfig) Lookup(code string) *Account {
+	for index := range accountsConfig.Accounts {
+		if accountsConfig.Accounts[index].Code == code {
+			return &accountsConfig.Accounts[index]
+		}
+	}
+	return nil
+}
+
+// LookupForKeystore returns the account with the given code, or nil if no such account exists.
+// Different keystores derive accounts with the same code, so keystore accounts only match if they
+// belong to the keystore with the given root fingerprint.
+func (accountsConfig AccountsConfig) LookupForKeystore(code string, rootFingerprint string) *Account {
+	for index := range accountsConfig.Accounts {
+		account := &accountsConfig.Accounts[index]
+		if account.Code != code || (account.Keystore && account.RootFingerprint != rootFingerprint) {
+			continue
+		}
+		return account
+	}
+	return nil
+}
+
+// KeystoreAccountCode returns the code of the keystore account with the given BIP44 account index.
+// The first account keeps the code of the account type, e.g. `btc-p2wpkh`, so that existing
+// settings and caches stay valid. The code of every further account has the account index
+// appended, e.g. `btc-p2wpkh-1`.
+func KeystoreAccountCode(typeCode string, accountIndex uint32) string {
+	if accountIndex == 0 {
+		return typeCode
+	}
+	return fmt.Sprintf("%s-%d", typeCode, accountIndex)
+}
+
+// SplitKeystoreAccountCode is the inverse of KeystoreAccountCode. It returns the code of the
+// account type and the account index.
+func SplitKeystoreAccountCode(code string) (string, uint32) {
+	separatorIndex := strings.LastIndex(code, "-")
+	if separatorIndex == -1 {
+		return code, 0
+	}
+	accountIndex, err := strconv.ParseUint(code[separatorIndex+1:], 10, 32)
+	if err != nil || accountIndex == 0 {
+		return code, 0
+	}
+	return code[:separatorIndex], uint32(accountIndex)
+}
+
 // newDefaultAccountsonfig returns the default accounts config.
 func newDefaultAccountsonfig() AccountsConfig {
 	return AccountsConfig{
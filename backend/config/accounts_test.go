@@ -0,0 +1,68 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeystoreAccountCode(t *testing.T) {
+	require.Equal(t, "btc-p2wpkh", KeystoreAccountCode("btc-p2wpkh", 0))
+	require.Equal(t, "btc-p2wpkh-3", KeystoreAccountCode("btc-p2wpkh", 3))
+
+	for _, test := range []struct {
+		code         string
+		typeCode     string
+		accountIndex uint32
+	}{
+		{"btc-p2wpkh", "btc-p2wpkh", 0},
+		{"btc-p2wpkh-p2sh", "btc-p2wpkh-p2sh", 0},
+		{"btc-p2wpkh-p2sh-12", "btc-p2wpkh-p2sh", 12},
+		{"eth", "eth", 0},
+		{"eth-0", "eth-0", 0},
+		{"ltc-p2wpkh-x1", "ltc-p2wpkh-x1", 0},
+	} {
+		typeCode, accountIndex := SplitKeystoreAccountCode(test.code)
+		require.Equal(t, test.typeCode, typeCode, test.code)
+		require.Equal(t, test.accountIndex, accountIndex, test.code)
+	}
+}
+
+func TestLookupForKeystore(t *testing.T) {
+	accountsConfig := AccountsConfig{Accounts: []Account{
+		{Code: "btc-p2wpkh", Keystore: true, RootFingerprint: "aaaaaaaa", Name: "first"},
+		{Code: "btc-p2wpkh", Keystore: true, RootFingerprint: "bbbbbbbb", Name: "second"},
+		{Code: "btc-p2wpkh-1", Keystore: true, Name: "legacy"},
+		{Code: "abcd-btc", Name: "xpub"},
+	}}
+	require.Equal(t, "first", accountsConfig.LookupForKeystore("btc-p2wpkh", "aaaaaaaa").Name)
+	require.Equal(t, "second", accountsConfig.LookupForKeystore("btc-p2wpkh", "bbbbbbbb").Name)
+	require.Nil(t, accountsConfig.LookupForKeystore("btc-p2wpkh", "cccccccc"))
+	require.Nil(t, accountsConfig.LookupForKeystore("btc-p2wpkh-1", "aaaaaaaa"))
+	require.Equal(t, "legacy", accountsConfig.LookupForKeystore("btc-p2wpkh-1", "").Name)
+	// Accounts which are not derived from a keystore match regardless of the fingerprint.
+	require.Equal(t, "xpub", accountsConfig.LookupForKeystore("abcd-btc", "aaaaaaaa").Name)
+}
+
+func TestAccountActive(t *testing.T) {
+	backend := Backend{BitcoinP2WPKHActive: true}
+	require.True(t, backend.AccountActive("btc-p2wpkh"))
+	require.True(t, backend.AccountActive("btc-p2wpkh-2"))
+	require.False(t, backend.AccountActive("btc-p2wpkh-p2sh"))
+	require.False(t, backend.AccountActive("btc-p2wpkh-p2sh-2"))
+	require.True(t, backend.AccountActive("btc-multisig"))
+}
@@ -16,7 +16,6 @@ package config
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
@@ -29,9 +28,30 @@ type btcCoinConfig struct {
 	ElectrumServers []*rpc.ServerInfo `json:"electrumServers"`
 }
 
-// ethCoinConfig holds configurations for ethereum coins.
-type ethCoinConfig struct {
-	NodeURL string `json:"nodeURL"`
+// ERC20Token holds the configuration of an ERC20 token, for which a token account is added to
+// each account of the ethereum coin.
+type ERC20Token struct {
+	ContractAddress string `json:"contractAddress"`
+	Symbol          string `json:"symbol"`
+	Decimals        uint   `json:"decimals"`
+}
+
+const (
+	// ETHIndexerEtherScan fetches the ethereum transaction history from etherscan.io.
+	ETHIndexerEtherScan = "etherscan"
+	// ETHIndexerNode finds the ethereum transaction history by scanning the blocks of the node
+	// configured by NodeURL, starting at BirthdayBlock. Ether transfers made by contract calls
+	// (internal transactions) cannot be found this way and are missing in the transaction history.
+	ETHIndexerNode = "node"
+)
+
+// ETHCoinConfig holds configurations for ethereum coins.
+type ETHCoinConfig struct {
+	NodeURL     string       `json:"nodeURL"`
+	ERC20Tokens []ERC20Token `json:"erc20Tokens"`
+	// Indexer is ETHIndexerEtherScan or ETHIndexerNode. Empty means ETHIndexerEtherScan.
+	Indexer       string `json:"indexer"`
+	BirthdayBlock uint64 `json:"birthdayBlock"`
 }
 
 // Backend holds the backend specific configuration.
@@ -39,28 +59,37 @@ type Backend struct {
 	BitcoinP2PKHActive       bool `json:"bitcoinP2PKHActive"`
 	BitcoinP2WPKHP2SHActive  bool `json:"bitcoinP2WPKHP2SHActive"`
 	BitcoinP2WPKHActive      bool `json:"bitcoinP2WPKHActive"`
+	BitcoinP2TRActive        bool `json:"bitcoinP2TRActive"`
 	LitecoinP2WPKHP2SHActive bool `json:"litecoinP2WPKHP2SHActive"`
 	LitecoinP2WPKHActive     bool `json:"litecoinP2WPKHActive"`
 	EthereumActive           bool `json:"ethereumActive"`
+	// WatchOnly enables remembering the signing configurations of the keystore accounts, so that
+	// they are loaded as watch-only accounts while the keystore is absent.
+	WatchOnly bool `json:"watchOnly"`
 
 	BTC  btcCoinConfig `json:"btc"`
 	TBTC btcCoinConfig `json:"tbtc"`
 	LTC  btcCoinConfig `json:"ltc"`
 	TLTC btcCoinConfig `json:"tltc"`
-	ETH  ethCoinConfig `json:"eth"`
-	TETH ethCoinConfig `json:"teth"`
-	RETH ethCoinConfig `json:"reth"`
+	ETH  ETHCoinConfig `json:"eth"`
+	TETH ETHCoinConfig `json:"teth"`
+	RETH ETHCoinConfig `json:"reth"`
 }
 
-// AccountActive returns the Active setting for a coin by code.
+// AccountActive returns the Active setting for a coin by code. Further keystore accounts of the
+// same type, e.g. `btc-p2wpkh-1`, share the setting of the first account. Accounts which are not
+// covered by a setting are always active.
 func (backend Backend) AccountActive(code string) bool {
-	switch code {
+	typeCode, _ := SplitKeystoreAccountCode(code)
+	switch typeCode {
 	case "tbtc-p2pkh", "btc-p2pkh", "rbtc-p2pkh":
 		return backend.BitcoinP2PKHActive
 	case "tbtc-p2wpkh-p2sh", "btc-p2wpkh-p2sh", "rbtc-p2wpkh-p2sh":
 		return backend.BitcoinP2WPKHP2SHActive
 	case "tbtc-p2wpkh", "btc-p2wpkh", "rbtc-p2wpkh":
 		return backend.BitcoinP2WPKHActive
+	case "tbtc-p2tr", "btc-p2tr", "rbtc-p2tr":
+		return backend.BitcoinP2TRActive
 	case "tltc-p2wpkh-p2sh", "ltc-p2wpkh-p2sh":
 		return backend.LitecoinP2WPKHP2SHActive
 	case "tltc-p2wpkh", "ltc-p2wpkh":
@@ -68,7 +97,21 @@ func (backend Backend) AccountActive(code string) bool {
 	case "eth", "teth", "reth":
 		return backend.EthereumActive
 	default:
-		panic(fmt.Sprintf("unknown code %s", code))
+		return true
+	}
+}
+
+// ETHCoinConfig returns the configuration of the ethereum coin with the given code.
+func (backend Backend) ETHCoinConfig(coinCode string) ETHCoinConfig {
+	switch coinCode {
+	case "eth":
+		return backend.ETH
+	case "teth":
+		return backend.TETH
+	case "reth":
+		return backend.RETH
+	default:
+		panic("unknown ethereum coin " + coinCode)
 	}
 }
 
@@ -123,6 +166,7 @@ func NewDefaultAppConfig() AppConfig {
 			BitcoinP2PKHActive:       false,
 			BitcoinP2WPKHP2SHActive:  true,
 			BitcoinP2WPKHActive:      false,
+			BitcoinP2TRActive:        false,
 			LitecoinP2WPKHP2SHActive: true,
 			LitecoinP2WPKHActive:     false,
 			EthereumActive:           true,
@@ -182,14 +226,20 @@ func NewDefaultAppConfig() AppConfig {
 					},
 				},
 			},
-			ETH: ethCoinConfig{
-				NodeURL: "https://mainnet.infura.io/v3/2ce516f67c0b48e8af5387b714ab8a61",
+			ETH: ETHCoinConfig{
+				NodeURL:     "https://mainnet.infura.io/v3/2ce516f67c0b48e8af5387b714ab8a61",
+				ERC20Tokens: []ERC20Token{},
+				Indexer:     ETHIndexerEtherScan,
 			},
-			TETH: ethCoinConfig{
-				NodeURL: "https://ropsten.infura.io/v3/2ce516f67c0b48e8af5387b714ab8a61",
+			TETH: ETHCoinConfig{
+				NodeURL:     "https://ropsten.infura.io/v3/2ce516f67c0b48e8af5387b714ab8a61",
+				ERC20Tokens: []ERC20Token{},
+				Indexer:     ETHIndexerEtherScan,
 			},
-			RETH: ethCoinConfig{
-				NodeURL: "https://rinkeby.infura.io/v3/2ce516f67c0b48e8af5387b714ab8a61",
+			RETH: ETHCoinConfig{
+				NodeURL:     "https://rinkeby.infura.io/v3/2ce516f67c0b48e8af5387b714ab8a61",
+				ERC20Tokens: []ERC20Token{},
+				Indexer:     ETHIndexerEtherScan,
 			},
 		},
 	}
@@ -250,10 +300,13 @@ func (config *Config) SetAppConfig(appConfig AppConfig) error {
 	return config.save(config.appConfigFilename, config.appConfig)
 }
 
-// AccountsConfig returns the accounts config.
+// AccountsConfig returns the accounts config. The returned config can be modified and passed to
+// SetAccountsConfig() without affecting the current config beforehand.
 func (config *Config) AccountsConfig() AccountsConfig {
 	defer config.lock.RLock()()
-	return config.accountsConfig
+	accountsConfig := config.accountsConfig
+	accountsConfig.Accounts = append([]Account{}, config.accountsConfig.Accounts...)
+	return accountsConfig
 }
 
 // SetAccountsConfig sets and persists the accounts config.
@@ -263,6 +316,20 @@ func (config *Config) SetAccountsConfig(accountsConfig AccountsConfig) error {
 	return config.save(config.accountsConfigFilename, config.accountsConfig)
 }
 
+// ModifyAccountsConfig calls f with the current accounts config, and persists the config modified
+// by f, unless f returns an error. The config is locked during the call, so concurrent
+// modifications don't overwrite each other.
+func (config *Config) ModifyAccountsConfig(f func(*AccountsConfig) error) error {
+	defer config.lock.Lock()()
+	accountsConfig := config.accountsConfig
+	accountsConfig.Accounts = append([]Account{}, config.accountsConfig.Accounts...)
+	if err := f(&accountsConfig); err != nil {
+		return err
+	}
+	config.accountsConfig = accountsConfig
+	return config.save(config.accountsConfigFilename, config.accountsConfig)
+}
+
 func (config *Config) save(filename string, conf interface{}) error {
 	jsonBytes, err := json.MarshalIndent(conf, "", "    ")
 	if err != nil {
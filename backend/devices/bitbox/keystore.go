@@ -49,11 +49,16 @@ func (keystore *keystore) CosignerIndex() int {
 // CanVerifyAddress implements keystore.Keystore.
 func (keystore *keystore) CanVerifyAddress(
 	configuration *signing.Configuration, coin coin.Coin) (bool, error) {
+	if _, ok := coin.(*eth.Coin); ok {
+		// The mobile app only derives bitcoin-like addresses from the xpub echo.
+		return false, nil
+	}
 	deviceInfo, err := keystore.dbb.DeviceInfo()
 	if err != nil {
 		return false, err
 	}
-	return deviceInfo.Pairing && keystore.dbb.HasMobileChannel() && configuration.Singlesig(), nil
+	return deviceInfo.Pairing && keystore.dbb.HasMobileChannel() && configuration.Singlesig() &&
+		keystore.SupportsScriptType(configuration.ScriptType()), nil
 }
 
 // VerifyAddress implements keystore.Keystore.
@@ -70,6 +75,21 @@ func (keystore *keystore) VerifyAddress(
 		configuration.AbsoluteKeypath().Encode(), fmt.Sprintf("%s-%s", coin.Code(), string(configuration.ScriptType())))
 }
 
+// RootFingerprint implements keystore.Keystore.
+func (keystore *keystore) RootFingerprint() ([]byte, error) {
+	root, err := keystore.dbb.xpub(signing.NewEmptyAbsoluteKeypath().Encode())
+	if err != nil {
+		return nil, err
+	}
+	return keystorePkg.RootFingerprintOf(root)
+}
+
+// SupportsScriptType implements keystore.Keystore.
+func (keystore *keystore) SupportsScriptType(scriptType signing.ScriptType) bool {
+	// The BitBox signs signature hashes with ECDSA and cannot create Schnorr signatures.
+	return scriptType != signing.ScriptTypeP2TR
+}
+
 // CanVerifyExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) CanVerifyExtendedPublicKey() bool {
 	return false
@@ -142,28 +162,35 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 	return nil
 }
 
-func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
-	signatureHashes := [][]byte{
-		txProposal.Signer.Hash(txProposal.Tx).Bytes(),
-	}
-	signatures, err := keystore.dbb.Sign(nil, signatureHashes, []string{txProposal.Keypath.Encode()})
+// signHash signs the hash with the key at the keypath and returns the 65 byte signature: 32
+// bytes R, 32 bytes S and the recovery ID.
+func (keystore *keystore) signHash(hash []byte, keypath signing.AbsoluteKeypath) ([]byte, error) {
+	signatures, err := keystore.dbb.Sign(nil, [][]byte{hash}, []string{keypath.Encode()})
 	if isErrorAbort(err) {
-		return errp.WithStack(keystorePkg.ErrSigningAborted)
+		return nil, errp.WithStack(keystorePkg.ErrSigningAborted)
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(signatures) != 1 {
 		panic("expecting one signature")
 	}
 	signature := signatures[0]
-	// We serialize the sig (including the recid at the last byte) so we can use WithSignature()
-	// without modifications, even though it deserializes it again immediately. We do this because
-	// it also modifies the `V` value according to EIP155.
 	sig := make([]byte, 65)
 	copy(sig[:32], math.PaddedBigBytes(signature.R, 32))
 	copy(sig[32:64], math.PaddedBigBytes(signature.S, 32))
 	sig[64] = byte(signature.RecID)
+	return sig, nil
+}
+
+func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
+	// We serialize the sig (including the recid at the last byte) so we can use WithSignature()
+	// without modifications, even though it deserializes it again immediately. We do this because
+	// it also modifies the `V` value according to EIP155.
+	sig, err := keystore.signHash(txProposal.Signer.Hash(txProposal.Tx).Bytes(), txProposal.Keypath)
+	if err != nil {
+		return err
+	}
 	signedTx, err := txProposal.Tx.WithSignature(txProposal.Signer, sig)
 	if err != nil {
 		return err
@@ -183,3 +210,15 @@ func (keystore *keystore) SignTransaction(proposedTx interface{}) error {
 		panic("unknown proposal type")
 	}
 }
+
+// SignMessage implements keystore.Keystore. The BitBox signs the hash of the message, it cannot
+// display the message itself.
+func (keystore *keystore) SignMessage(messageProposal *signing.MessageProposal) error {
+	keystore.log.Info("Sign message")
+	signature, err := keystore.signHash(messageProposal.Hash, messageProposal.Keypath)
+	if err != nil {
+		return err
+	}
+	messageProposal.Signature = signature
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox02
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/devices/bitbox02/messages"
+	devicepkg "github.com/digitalbitbox/bitbox-wallet-app/backend/devices/device"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/flynn/noise"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// communicationMock simulates the device side of the communication: the handshake, the noise
+// channel and the api calls, which are answered by handleRequest.
+type communicationMock struct {
+	t             *testing.T
+	handshake     *noise.HandshakeState
+	handshakeStep int
+	// receiveCipher decrypts requests from the app, sendCipher encrypts responses to the app.
+	receiveCipher, sendCipher *noise.CipherState
+	frames                    chan []byte
+	handleRequest             func(*messages.Request) *messages.Response
+}
+
+func newCommunicationMock(
+	t *testing.T, handleRequest func(*messages.Request) *messages.Response) *communicationMock {
+	cipherSuite := noise.NewCipherSuite(noise.DH25519, noise.CipherChaChaPoly, noise.HashSHA256)
+	keypair, err := cipherSuite.GenerateKeypair(rand.Reader)
+	require.NoError(t, err)
+	handshake, err := noise.NewHandshakeState(noise.Config{
+		CipherSuite:   cipherSuite,
+		Random:        rand.Reader,
+		Pattern:       noise.HandshakeXX,
+		StaticKeypair: keypair,
+		Prologue:      []byte("Noise_XX_25519_ChaChaPoly_SHA256"),
+		Initiator:     false,
+	})
+	require.NoError(t, err)
+	return &communicationMock{
+		t:             t,
+		handshake:     handshake,
+		frames:        make(chan []byte, 10),
+		handleRequest: handleRequest,
+	}
+}
+
+// SendFrame implements Communication.
+func (communication *communicationMock) SendFrame(msg string) error {
+	t := communication.t
+	defer func() { communication.handshakeStep++ }()
+	switch communication.handshakeStep {
+	case 0:
+		require.Equal(t, "I CAN HAS HANDSHAKE?", msg)
+		communication.frames <- []byte("OKAY!!")
+	case 1:
+		_, _, _, err := communication.handshake.ReadMessage(nil, []byte(msg))
+		require.NoError(t, err)
+		response, _, _, err := communication.handshake.WriteMessage(nil, nil)
+		require.NoError(t, err)
+		communication.frames <- response
+	case 2:
+		var err error
+		_, communication.receiveCipher, communication.sendCipher, err =
+			communication.handshake.ReadMessage(nil, []byte(msg))
+		require.NoError(t, err)
+		communication.frames <- []byte("ACCEPTED!")
+	default:
+		requestBytes, err := communication.receiveCipher.Decrypt(nil, nil, []byte(msg))
+		require.NoError(t, err)
+		request := &messages.Request{}
+		require.NoError(t, proto.Unmarshal(requestBytes, request))
+		responseBytes, err := proto.Marshal(communication.handleRequest(request))
+		require.NoError(t, err)
+		communication.frames <- communication.sendCipher.Encrypt(nil, nil, responseBytes)
+	}
+	return nil
+}
+
+// ReadFrame implements Communication.
+func (communication *communicationMock) ReadFrame() ([]byte, error) {
+	select {
+	case frame := <-communication.frames:
+		return frame, nil
+	case <-time.After(5 * time.Second):
+		return nil, errp.New("timeout")
+	}
+}
+
+// Close implements Communication.
+func (communication *communicationMock) Close() {}
+
+// newPairedDevice returns a device which completed the handshake with the simulated device.
+func newPairedDevice(
+	t *testing.T, handleRequest func(*messages.Request) *messages.Response) *Device {
+	device := NewDevice("device id", false, nil, newCommunicationMock(t, handleRequest))
+	paired := make(chan struct{})
+	device.SetOnEvent(func(event devicepkg.Event, data interface{}) {
+		if event == EventChannelHashChanged {
+			close(paired)
+		}
+	})
+	device.Init(true)
+	select {
+	case <-paired:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "pairing timed out")
+	}
+	return device
+}
+
+// TestETHUnsupported checks that no Ethereum requests are sent to the device, as the firmware does
+// not define them.
+func TestETHUnsupported(t *testing.T) {
+	device := newPairedDevice(t, func(request *messages.Request) *messages.Response {
+		require.FailNow(t, "unexpected request", request)
+		return nil
+	})
+	keystore := &keystore{device: device}
+	coin := eth.NewCoin("teth", params.TestnetChainConfig, "", "", nil)
+	keypath, err := signing.NewAbsoluteKeypath("m/44'/1'/0'/0/0")
+	require.NoError(t, err)
+
+	canVerifyAddress, err := keystore.CanVerifyAddress(
+		signing.NewAddressConfiguration(signing.ScriptTypeP2WPKH, keypath,
+			"0x04f264cf34440313b4a0192a352814fbe927b885"),
+		coin)
+	require.NoError(t, err)
+	require.False(t, canVerifyAddress)
+
+	_, err = keystore.ExtendedPublicKey(coin, keypath)
+	require.Equal(t, errETHUnsupported, errp.Cause(err))
+
+	err = keystore.SignTransaction(&eth.TxProposal{
+		Tx: types.NewTransaction(
+			0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil),
+		Signer:  types.NewEIP155Signer(params.TestnetChainConfig.ChainID),
+		Keypath: keypath,
+	})
+	require.Equal(t, errETHUnsupported, errp.Cause(err))
+}
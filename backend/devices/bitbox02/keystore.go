@@ -15,6 +15,8 @@
 package bitbox02
 
 import (
+	"errors"
+
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
@@ -28,6 +30,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// errETHUnsupported is returned for Ethereum requests. The BitBox02 protocol does not define
+// Ethereum messages yet, and unknown messages must not be sent to the firmware.
+var errETHUnsupported = errors.New("The BitBox02 does not support Ethereum yet.")
+
 type keystore struct {
 	device        *Device
 	configuration *signing.Configuration
@@ -40,10 +46,20 @@ func (keystore *keystore) CosignerIndex() int {
 	return keystore.cosignerIndex
 }
 
+// SupportsScriptType implements keystore.Keystore.
+func (keystore *keystore) SupportsScriptType(scriptType signing.ScriptType) bool {
+	// The BitBox02 protocol does not support taproot yet.
+	return scriptType != signing.ScriptTypeP2TR
+}
+
 // CanVerifyAddress implements keystore.Keystore.
 func (keystore *keystore) CanVerifyAddress(configuration *signing.Configuration, coin coinpkg.Coin) (bool, error) {
-	_, ok := msgCoinMap[coin.Code()]
-	return ok, nil
+	if _, ok := msgCoinMap[coin.Code()]; !ok {
+		return false, nil
+	}
+	// The BitBox02 protocol does not support multisig script configurations yet, so multisig
+	// addresses cannot be displayed.
+	return configuration.Singlesig() && keystore.SupportsScriptType(configuration.ScriptType()), nil
 }
 
 // VerifyAddress implements keystore.Keystore.
@@ -70,6 +86,11 @@ func (keystore *keystore) VerifyAddress(
 	return err
 }
 
+// RootFingerprint implements keystore.Keystore.
+func (keystore *keystore) RootFingerprint() ([]byte, error) {
+	return nil, errp.New("The BitBox02 does not support exporting the root fingerprint yet.")
+}
+
 // CanVerifyExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) CanVerifyExtendedPublicKey() bool {
 	return true
@@ -115,6 +136,9 @@ func (keystore *keystore) VerifyExtendedPublicKey(coin coinpkg.Coin, keyPath sig
 // ExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) ExtendedPublicKey(
 	coin coinpkg.Coin, keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	if _, ok := coin.(*eth.Coin); ok {
+		return nil, errp.WithStack(errETHUnsupported)
+	}
 	msgCoin, ok := msgCoinMap[coin.Code()]
 	if !ok {
 		return nil, errp.New("unsupported coin")
@@ -145,7 +169,7 @@ func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransact
 }
 
 func (keystore *keystore) signETHTransaction(*eth.TxProposal) error {
-	panic("todo")
+	return errp.WithStack(errETHUnsupported)
 }
 
 // SignTransaction implements keystore.Keystore.
@@ -159,3 +183,8 @@ func (keystore *keystore) SignTransaction(proposedTx interface{}) error {
 		panic("unknown proposal type")
 	}
 }
+
+// SignMessage implements keystore.Keystore.
+func (keystore *keystore) SignMessage(*signing.MessageProposal) error {
+	return errp.New("The BitBox02 does not support signing messages yet.")
+}
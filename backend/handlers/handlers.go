@@ -35,6 +35,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/accounts"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
 	accountHandlers "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/handlers"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/types"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
@@ -74,6 +75,23 @@ type Backend interface {
 		getSigningConfiguration func() (*signing.Configuration, error),
 		persist bool,
 	) error
+	SetAccountGapLimits(code string, gapLimits *types.GapLimits) error
+	CreateKeystoreAccount(coinCode string, scriptType signing.ScriptType, name string) (string, error)
+	CreateMultisigAccount(
+		coinCode string,
+		name string,
+		scriptType signing.ScriptType,
+		signingThreshold int,
+		cosignerXPubs []string,
+	) (string, error)
+	RenameAccount(code string, name string) error
+	SetAccountArchived(code string, archived bool) error
+	ArchivedAccounts() []config.Account
+	SetWatchOnly(enabled bool) error
+	WatchOnlyAccount(code string) bool
+	AccountVerification(code string) ([]backend.CosignerVerification, error)
+	VerifyAccountExtendedPublicKey(code string, cosignerIndex int) (bool, error)
+	VerifyBackup(mnemonic string, slip39Shares []string, passphrase string) (*backend.BackupVerification, error)
 	UserLanguage() language.Tag
 	OnAccountInit(f func(accounts.Interface))
 	OnAccountUninit(f func(accounts.Interface))
@@ -89,6 +107,7 @@ type Backend interface {
 	DownloadCert(string) (string, error)
 	CheckElectrumServer(string, string) error
 	RegisterTestKeystore(string)
+	RegisterMnemonicKeystore(string, string) error
 	NotifyUser(string)
 }
 
@@ -164,11 +183,23 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/version", handlers.getVersionHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/testing", handlers.getTestingHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/account-add", handlers.postAddAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/account-gap-limits", handlers.postAccountGapLimitsHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/create-keystore-account", handlers.postCreateKeystoreAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/account-add-multisig", handlers.postAddMultisigAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/account-rename", handlers.postAccountRenameHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/account-archived", handlers.postAccountArchivedHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts-archived", handlers.getAccountsArchivedHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/watch-only", handlers.postWatchOnlyHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/account-verification", handlers.getAccountVerificationHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/account-verify-extended-public-key",
+		handlers.postAccountVerifyExtendedPublicKeyHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/backup-verify", handlers.postBackupVerifyHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/accounts", handlers.getAccountsHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts-status", handlers.getAccountsStatusHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/export-account-summary", handlers.postExportAccountSummary).Methods("POST")
 	getAPIRouter(apiRouter)("/account-summary", handlers.getAccountSummary).Methods("GET")
 	getAPIRouter(apiRouter)("/test/register", handlers.postRegisterTestKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/software-keystore/register", handlers.postRegisterMnemonicKeystoreHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/test/deregister", handlers.postDeregisterTestKeystoreHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/rates", handlers.getRatesHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/convertToFiat", handlers.getConvertToFiatHandler).Methods("GET")
@@ -179,6 +210,7 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/coins/btc/headers/status", handlers.getHeadersStatus("btc")).Methods("GET")
 	getAPIRouter(apiRouter)("/certs/download", handlers.postCertsDownloadHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/certs/check", handlers.postCertsCheckHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/verify-message", handlers.postVerifyMessageHandler).Methods("POST")
 
 	devicesRouter := getAPIRouter(apiRouter.PathPrefix("/devices").Subrouter())
 	devicesRouter("/registered", handlers.getDevicesRegisteredHandler).Methods("GET")
@@ -360,27 +392,53 @@ func (handlers *Handlers) postAddAccountHandler(r *http.Request) (interface{}, e
 	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
 		return nil, errp.WithStack(err)
 	}
-	// The following parameters only work for watch-only singlesig accounts at the moment.
+	// The following parameters only work for watch-only singlesig accounts at the moment, except
+	// for descriptors, which can also describe multisig accounts.
 	jsonCoinCode := jsonBody["coinCode"]
 	jsonScriptType := jsonBody["scriptType"]
 	jsonAccountName := jsonBody["accountName"]
 	jsonExtendedPublicKey := jsonBody["extendedPublicKey"]
 	jsonAddress := jsonBody["address"]
+	jsonDescriptor := jsonBody["descriptor"]
 
 	coin, err := handlers.backend.Coin(jsonCoinCode)
 	if err != nil {
 		return nil, err
 	}
 
+	var configuration *signing.Configuration
+	var warningCode string
+
+	if jsonDescriptor != "" {
+		btcCoin, ok := coin.(*btc.Coin)
+		if !ok {
+			return map[string]interface{}{"success": false, "errorCode": "descriptorUnsupported"}, nil
+		}
+		configuration, err = signing.NewConfigurationFromDescriptor(jsonDescriptor)
+		if err != nil {
+			return map[string]interface{}{
+				"success":      false,
+				"errorCode":    "descriptorInvalid",
+				"errorMessage": err.Error(),
+			}, nil
+		}
+		expectedNet := &chaincfg.Params{
+			HDPublicKeyID: btc.XPubVersionForScriptType(btcCoin, signing.ScriptTypeP2PKH),
+		}
+		for _, extendedPublicKey := range configuration.ExtendedPublicKeys() {
+			if !extendedPublicKey.IsForNet(expectedNet) {
+				warningCode = "xpubWrongNet"
+			}
+		}
+		return handlers.addAccount(coin, jsonAccountName, configuration, warningCode)
+	}
+
 	scriptType, err := signing.DecodeScriptType(jsonScriptType)
 	if err != nil {
 		return nil, err
 	}
 	keypath := signing.NewEmptyAbsoluteKeypath()
 
-	var configuration *signing.Configuration
-	var warningCode string
-
 	if jsonAddress != "" {
 		switch jsonCoinCode {
 		case "btc", "ltc", "tbtc", "tltc":
@@ -418,13 +476,22 @@ func (handlers *Handlers) postAddAccountHandler(r *http.Request) (interface{}, e
 		}
 		configuration = signing.NewSinglesigConfiguration(scriptType, keypath, extendedPublicKey)
 	}
+	return handlers.addAccount(coin, jsonAccountName, configuration, warningCode)
+}
 
+// addAccount persists and adds a watch-only account with the given signing configuration.
+func (handlers *Handlers) addAccount(
+	coin coin.Coin,
+	name string,
+	configuration *signing.Configuration,
+	warningCode string,
+) (interface{}, error) {
 	getSigningConfiguration := func() (*signing.Configuration, error) {
 		return configuration, nil
 	}
 	accountCode := fmt.Sprintf("%s-%s", configuration.Hash(), coin.Code())
-	err = handlers.backend.CreateAndAddAccount(
-		coin, accountCode, jsonAccountName, getSigningConfiguration, true)
+	err := handlers.backend.CreateAndAddAccount(
+		coin, accountCode, name, getSigningConfiguration, true)
 	if errp.Cause(err) == backend.ErrAccountAlreadyExists {
 		return map[string]interface{}{"success": false, "errorCode": "alreadyExists"}, nil
 	}
@@ -442,12 +509,235 @@ func (handlers *Handlers) postAddAccountHandler(r *http.Request) (interface{}, e
 	}, nil
 }
 
+func (handlers *Handlers) postAccountGapLimitsHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		AccountCode string          `json:"accountCode"`
+		GapLimits   types.GapLimits `json:"gapLimits"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	err := handlers.backend.SetAccountGapLimits(jsonBody.AccountCode, &jsonBody.GapLimits)
+	if errp.Cause(err) == backend.ErrAccountNotPersisted {
+		return map[string]interface{}{"success": false, "errorCode": "accountNotPersisted"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postCreateKeystoreAccountHandler(r *http.Request) (interface{}, error) {
+	jsonBody := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	scriptType, err := signing.DecodeScriptType(jsonBody["scriptType"])
+	if err != nil {
+		return nil, err
+	}
+	accountCode, err := handlers.backend.CreateKeystoreAccount(
+		jsonBody["coinCode"], scriptType, jsonBody["accountName"])
+	switch errp.Cause(err) {
+	case nil:
+		return map[string]interface{}{"success": true, "accountCode": accountCode}, nil
+	case backend.ErrAccountTypeUnavailable:
+		return map[string]interface{}{"success": false, "errorCode": "accountTypeUnavailable"}, nil
+	case backend.ErrScriptTypeUnsupported:
+		return map[string]interface{}{"success": false, "errorCode": "scriptTypeUnsupported"}, nil
+	default:
+		return map[string]interface{}{
+			"success":      false,
+			"errorCode":    "unknown",
+			"errorMessage": err.Error(),
+		}, nil
+	}
+}
+
+func (handlers *Handlers) postAddMultisigAccountHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		CoinCode         string   `json:"coinCode"`
+		AccountName      string   `json:"accountName"`
+		ScriptType       string   `json:"scriptType"`
+		SigningThreshold int      `json:"signingThreshold"`
+		CosignerXPubs    []string `json:"cosignerXPubs"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	scriptType, err := signing.DecodeScriptType(jsonBody.ScriptType)
+	if err != nil {
+		return nil, err
+	}
+	accountCode, err := handlers.backend.CreateMultisigAccount(
+		jsonBody.CoinCode,
+		jsonBody.AccountName,
+		scriptType,
+		jsonBody.SigningThreshold,
+		jsonBody.CosignerXPubs,
+	)
+	switch errp.Cause(err) {
+	case nil:
+		return map[string]interface{}{"success": true, "accountCode": accountCode}, nil
+	case backend.ErrAccountAlreadyExists:
+		return map[string]interface{}{"success": false, "errorCode": "alreadyExists"}, nil
+	case backend.ErrAccountTypeUnavailable:
+		return map[string]interface{}{"success": false, "errorCode": "accountTypeUnavailable"}, nil
+	case backend.ErrInvalidMultisig:
+		return map[string]interface{}{
+			"success":      false,
+			"errorCode":    "invalidMultisig",
+			"errorMessage": err.Error(),
+		}, nil
+	default:
+		return map[string]interface{}{
+			"success":      false,
+			"errorCode":    "unknown",
+			"errorMessage": err.Error(),
+		}, nil
+	}
+}
+
+// postVerifyMessageHandler checks a signed message of a BTC or LTC address. No keystore is needed.
+func (handlers *Handlers) postVerifyMessageHandler(r *http.Request) (interface{}, error) {
+	jsonBody := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	coin, err := handlers.backend.Coin(jsonBody["coinCode"])
+	if err != nil {
+		return nil, err
+	}
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return nil, errp.New("Only BTC and LTC messages can be verified")
+	}
+	valid, err := btcCoin.VerifyMessage(
+		jsonBody["address"], []byte(jsonBody["message"]), jsonBody["signature"])
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "valid": valid}, nil
+}
+
+func (handlers *Handlers) postAccountRenameHandler(r *http.Request) (interface{}, error) {
+	jsonBody := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	err := handlers.backend.RenameAccount(jsonBody["accountCode"], jsonBody["accountName"])
+	if errp.Cause(err) == backend.ErrAccountNotPersisted {
+		return map[string]interface{}{"success": false, "errorCode": "accountNotPersisted"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postAccountArchivedHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		AccountCode string `json:"accountCode"`
+		Archived    bool   `json:"archived"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	err := handlers.backend.SetAccountArchived(jsonBody.AccountCode, jsonBody.Archived)
+	if errp.Cause(err) == backend.ErrAccountNotPersisted {
+		return map[string]interface{}{"success": false, "errorCode": "accountNotPersisted"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postWatchOnlyHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		Enabled bool `json:"enabled"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.SetWatchOnly(jsonBody.Enabled); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getAccountVerificationHandler(r *http.Request) (interface{}, error) {
+	return handlers.backend.AccountVerification(r.URL.Query().Get("code"))
+}
+
+func (handlers *Handlers) postAccountVerifyExtendedPublicKeyHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		AccountCode   string `json:"accountCode"`
+		CosignerIndex int    `json:"cosignerIndex"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	verified, err := handlers.backend.VerifyAccountExtendedPublicKey(
+		jsonBody.AccountCode, jsonBody.CosignerIndex)
+	if errp.Cause(err) == keystore.ErrNoKeystore {
+		return map[string]interface{}{"success": false, "errorCode": "noKeystore"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "verified": verified}, nil
+}
+
+func (handlers *Handlers) postBackupVerifyHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		Mnemonic     string   `json:"mnemonic"`
+		SLIP39Shares []string `json:"slip39Shares"`
+		Passphrase   string   `json:"passphrase"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	verification, err := handlers.backend.VerifyBackup(
+		jsonBody.Mnemonic, jsonBody.SLIP39Shares, jsonBody.Passphrase)
+	if errp.Cause(err) == keystore.ErrNoKeystore {
+		return map[string]interface{}{"success": false, "errorCode": "noKeystore"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{
+		"success":         true,
+		"matches":         verification.Matches,
+		"checkedAccounts": verification.CheckedAccounts,
+	}, nil
+}
+
+func (handlers *Handlers) getAccountsArchivedHandler(_ *http.Request) (interface{}, error) {
+	type archivedAccountJSON struct {
+		CoinCode string `json:"coinCode"`
+		Code     string `json:"code"`
+		Name     string `json:"name"`
+	}
+	archivedAccounts := []archivedAccountJSON{}
+	for _, account := range handlers.backend.ArchivedAccounts() {
+		archivedAccounts = append(archivedAccounts, archivedAccountJSON{
+			CoinCode: account.CoinCode,
+			Code:     account.Code,
+			Name:     account.Name,
+		})
+	}
+	return archivedAccounts, nil
+}
+
 func (handlers *Handlers) getAccountsHandler(_ *http.Request) (interface{}, error) {
 	type accountJSON struct {
 		CoinCode              string `json:"coinCode"`
 		Code                  string `json:"code"`
 		Name                  string `json:"name"`
 		BlockExplorerTxPrefix string `json:"blockExplorerTxPrefix"`
+		// WatchOnly is true if the keystore of the account is absent, so it cannot send.
+		WatchOnly bool `json:"watchOnly"`
 	}
 	accounts := []*accountJSON{}
 	for _, account := range handlers.backend.Accounts() {
@@ -456,6 +746,7 @@ func (handlers *Handlers) getAccountsHandler(_ *http.Request) (interface{}, erro
 			Code:                  account.Code(),
 			Name:                  account.Name(),
 			BlockExplorerTxPrefix: account.Coin().BlockExplorerTransactionURLPrefix(),
+			WatchOnly:             handlers.backend.WatchOnlyAccount(account.Code()),
 		})
 	}
 	return accounts, nil
@@ -486,6 +777,22 @@ func (handlers *Handlers) postRegisterTestKeystoreHandler(r *http.Request) (inte
 	return true, nil
 }
 
+func (handlers *Handlers) postRegisterMnemonicKeystoreHandler(r *http.Request) (interface{}, error) {
+	jsonBody := struct {
+		Mnemonic   string `json:"mnemonic"`
+		Passphrase string `json:"passphrase"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&jsonBody); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.RegisterMnemonicKeystore(
+		jsonBody.Mnemonic, jsonBody.Passphrase); err != nil {
+		handlers.log.WithError(err).Error("Failed to register the software keystore")
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
 func (handlers *Handlers) postDeregisterTestKeystoreHandler(_ *http.Request) (interface{}, error) {
 	handlers.backend.DeregisterKeystore()
 	return true, nil
@@ -798,7 +1105,7 @@ func (handlers *Handlers) postExportAccountSummary(_ *http.Request) (interface{}
 			switch specificAccount := account.(type) {
 			case *btc.Account:
 				scriptType = string(specificAccount.Info().SigningConfiguration.ScriptType())
-			case *eth.Account:
+			case *eth.Account, *eth.TokenAccount:
 				address = signingConfiguration.Address()
 			default:
 				return nil, nil
@@ -15,8 +15,11 @@
 package keystore
 
 import (
+	"bytes"
 	"errors"
 
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
@@ -25,7 +28,12 @@ import (
 // ErrSigningAborted is used when the user aborts a signing in process (e.g. abort on HW wallet).
 var ErrSigningAborted = errors.New("signing aborted by user")
 
+// ErrNoKeystore is returned when signing without a registered keystore, e.g. with a watch-only
+// account whose keystore is absent.
+var ErrNoKeystore = errors.New("no keystore registered")
+
 // Keystore supports hardened key derivation according to BIP32 and signing of transactions.
+//go:generate mockery -name Keystore
 type Keystore interface {
 	// // Configuration returns the configuration of the keystore.
 	// // The keypath is m/44' for singlesig and m/46' for multisig.
@@ -35,6 +43,14 @@ type Keystore interface {
 	// The returned value is always zero for a singlesig configuration.
 	CosignerIndex() int
 
+	// RootFingerprint returns the fingerprint of the root public key as defined in BIP32, the first
+	// 4 bytes of the hash160 of the master public key. It identifies the keystore across sessions.
+	RootFingerprint() ([]byte, error)
+
+	// SupportsScriptType returns whether the keystore can sign for outputs of the given script
+	// type. Accounts of unsupported script types are not added for the keystore.
+	SupportsScriptType(signing.ScriptType) bool
+
 	// CanVerifyAddress returns whether the keystore supports to output an address securely.
 	// This is typically done through a screen on the device or through a paired mobile phone.
 	CanVerifyAddress(*signing.Configuration, coin.Coin) (bool, error)
@@ -52,9 +68,42 @@ type Keystore interface {
 	// ExtendedPublicKey returns the extended public key at the given absolute keypath.
 	ExtendedPublicKey(coin.Coin, signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error)
 
-	// SignMessage(string, *signing.AbsoluteKeypath, accounts.Coin) (*big.Int, error)
+	// SignMessage signs the hash of the given message proposal, e.g. of a BIP137/BIP322 message of
+	// a BTC address, or of a personal_sign message or EIP-712 typed data of an ETH account. Returns
+	// ErrSigningAborted if the user aborts.
+	SignMessage(*signing.MessageProposal) error
 
 	// SignTransaction signs the given transaction proposal. Returns ErrSigningAborted if the user
 	// aborts.
 	SignTransaction(interface{}) error
 }
+
+// RootFingerprintOf returns the BIP32 fingerprint of the given root key.
+func RootFingerprintOf(root *hdkeychain.ExtendedKey) ([]byte, error) {
+	publicKey, err := root.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	return btcutil.Hash160(publicKey.SerializeCompressed())[:4], nil
+}
+
+// BackupMatches returns whether the backup keystore, e.g. a software keystore restored from a
+// mnemonic, derives the same extended public key as the keystore at the given keypath.
+func BackupMatches(
+	keystore Keystore, backup Keystore, coin coin.Coin, keypath signing.AbsoluteKeypath) (bool, error) {
+	extendedPublicKey, err := keystore.ExtendedPublicKey(coin, keypath)
+	if err != nil {
+		return false, err
+	}
+	backupExtendedPublicKey, err := backup.ExtendedPublicKey(coin, keypath)
+	if err != nil {
+		return false, err
+	}
+	// The serialization starts with the version, which depends on the network, and ends with a
+	// checksum over it.
+	const versionSize, serializedSize = 4, 78
+	return bytes.Equal(
+		base58.Decode(extendedPublicKey.String())[versionSize:serializedSize],
+		base58.Decode(backupExtendedPublicKey.String())[versionSize:serializedSize],
+	), nil
+}
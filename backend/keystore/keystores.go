@@ -65,6 +65,58 @@ func (keystores *Keystores) Remove(keystore Keystore) error {
 	return errp.New("The collection does not contain the given keystore.")
 }
 
+// Contains returns whether the given keystore is in the collection.
+func (keystores *Keystores) Contains(keystore Keystore) bool {
+	for _, element := range keystores.keystores {
+		if element == keystore {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveAll removes all keystores from the collection.
+func (keystores *Keystores) RemoveAll() {
+	keystores.keystores = nil
+}
+
+// RootFingerprints returns the root fingerprints of the keystores, in the order of the cosigner
+// indices.
+func (keystores *Keystores) RootFingerprints() ([][4]byte, error) {
+	rootFingerprints := make([][4]byte, len(keystores.keystores))
+	for index, keystore := range keystores.keystores {
+		rootFingerprint, err := keystore.RootFingerprint()
+		if err != nil {
+			return nil, err
+		}
+		if len(rootFingerprint) != 4 {
+			return nil, errp.New("The root fingerprint must be 4 bytes.")
+		}
+		copy(rootFingerprints[index][:], rootFingerprint)
+	}
+	return rootFingerprints, nil
+}
+
+// Wipe zeroes the key material of the keystores which hold keys in memory, like the software
+// keystore.
+func (keystores *Keystores) Wipe() {
+	for _, keystore := range keystores.keystores {
+		if wipeable, ok := keystore.(interface{ Wipe() }); ok {
+			wipeable.Wipe()
+		}
+	}
+}
+
+// SupportsScriptType returns whether all keystores can sign for outputs of the given script type.
+func (keystores *Keystores) SupportsScriptType(scriptType signing.ScriptType) bool {
+	for _, keystore := range keystores.keystores {
+		if !keystore.SupportsScriptType(scriptType) {
+			return false
+		}
+	}
+	return true
+}
+
 // CanVerifyAddresses returns whether any of the keystores can verify an address.
 func (keystores *Keystores) CanVerifyAddresses(
 	configuration *signing.Configuration, coin coin.Coin) (bool, error) {
@@ -117,8 +169,11 @@ func (keystores *Keystores) CanVerifyExtendedPublicKeys() []int {
 }
 
 // SignTransaction signs the given proposed transaction on all keystores. Returns ErrSigningAborted
-// if the user aborts.
+// if the user aborts, and ErrNoKeystore if there is no keystore.
 func (keystores *Keystores) SignTransaction(proposedTransaction interface{}) error {
+	if len(keystores.keystores) == 0 {
+		return errp.WithStack(ErrNoKeystore)
+	}
 	for _, keystore := range keystores.keystores {
 		if err := keystore.SignTransaction(proposedTransaction); err != nil {
 			return err
@@ -127,6 +182,20 @@ func (keystores *Keystores) SignTransaction(proposedTransaction interface{}) err
 	return nil
 }
 
+// SignMessage signs the given message proposal on all keystores. Returns ErrSigningAborted if the
+// user aborts, and ErrNoKeystore if there is no keystore.
+func (keystores *Keystores) SignMessage(messageProposal *signing.MessageProposal) error {
+	if len(keystores.keystores) == 0 {
+		return errp.WithStack(ErrNoKeystore)
+	}
+	for _, keystore := range keystores.keystores {
+		if err := keystore.SignMessage(messageProposal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Configuration returns the configuration at the given path with the given signing threshold.
 func (keystores *Keystores) Configuration(
 	coin coinpkg.Coin,
@@ -0,0 +1,328 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slip39 recovers the master secret from SLIP-39 (Shamir's secret sharing) mnemonic
+// shares. Creating shares is not supported.
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	radixBits = 10
+	// The share metadata: identifier, extendable flag, iteration exponent and share parameters in
+	// four words, and the checksum in three words.
+	metadataWords = 7
+	checksumWords = 3
+	minShareWords = 20
+
+	minSecretSize = 16
+
+	digestIndex = 254
+	secretIndex = 255
+	digestSize  = 4
+
+	baseIterationCount = 10000
+	roundCount         = 4
+)
+
+var wordIndices = func() map[string]int {
+	indices := make(map[string]int, len(wordlist))
+	for index, word := range wordlist {
+		indices[word] = index
+	}
+	return indices
+}()
+
+// share is a decoded mnemonic share.
+type share struct {
+	identifier        int
+	extendable        bool
+	iterationExponent int
+	groupIndex        int
+	groupThreshold    int
+	groupCount        int
+	memberIndex       int
+	memberThreshold   int
+	value             []byte
+}
+
+// rs1024Polymod computes the checksum of the Reed-Solomon code over GF(1024) used by SLIP-39.
+func rs1024Polymod(values []int) int {
+	generator := []int{
+		0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+		0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+	}
+	chk := 1
+	for _, value := range values {
+		top := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ value
+		for i := 0; i < 10; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func customizationString(extendable bool) string {
+	if extendable {
+		return "shamir_extendable"
+	}
+	return "shamir"
+}
+
+// decodeShare decodes and validates a mnemonic share.
+func decodeShare(mnemonic string) (*share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < minShareWords {
+		return nil, errp.Newf("A share has at least %d words.", minShareWords)
+	}
+	data := make([]int, len(words))
+	for i, word := range words {
+		index, ok := wordIndices[word]
+		if !ok {
+			return nil, errp.Newf("Unknown word %q.", word)
+		}
+		data[i] = index
+	}
+	paddingSize := radixBits * (len(data) - metadataWords) % 16
+	if paddingSize > 8 {
+		return nil, errp.New("Invalid share length.")
+	}
+
+	identifierExponent := data[0]<<radixBits | data[1]
+	extendable := (identifierExponent>>4)&1 == 1
+	checksumValues := []int{}
+	for _, char := range []byte(customizationString(extendable)) {
+		checksumValues = append(checksumValues, int(char))
+	}
+	if rs1024Polymod(append(checksumValues, data...)) != 1 {
+		return nil, errp.New("Invalid share checksum.")
+	}
+	parameters := data[2]<<radixBits | data[3]
+	decoded := &share{
+		identifier:        identifierExponent >> 5,
+		extendable:        extendable,
+		iterationExponent: identifierExponent & 0xf,
+		groupIndex:        (parameters >> 16) & 0xf,
+		groupThreshold:    (parameters>>12)&0xf + 1,
+		groupCount:        (parameters>>8)&0xf + 1,
+		memberIndex:       (parameters >> 4) & 0xf,
+		memberThreshold:   parameters&0xf + 1,
+	}
+	if decoded.groupThreshold > decoded.groupCount {
+		return nil, errp.New("The group threshold exceeds the number of groups.")
+	}
+
+	value := new(big.Int)
+	for _, index := range data[4 : len(data)-checksumWords] {
+		value.Lsh(value, radixBits)
+		value.Or(value, big.NewInt(int64(index)))
+	}
+	valueSize := (radixBits*(len(data)-metadataWords) - paddingSize) / 8
+	if value.BitLen() > valueSize*8 {
+		return nil, errp.New("Invalid share padding.")
+	}
+	decoded.value = make([]byte, valueSize)
+	valueBytes := value.Bytes()
+	copy(decoded.value[valueSize-len(valueBytes):], valueBytes)
+	return decoded, nil
+}
+
+// The exp and log tables of GF(256) with the Rijndael polynomial x^8 + x^4 + x^3 + x + 1 and the
+// generator x + 1.
+var expTable, logTable = func() ([255]byte, [256]int) {
+	var exp [255]byte
+	var log [256]int
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = i
+		// Multiply by x + 1.
+		doubled := x << 1
+		if doubled&0x100 != 0 {
+			doubled ^= 0x11b
+		}
+		x ^= doubled
+	}
+	return exp, log
+}()
+
+func multiply(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(logTable[a]+logTable[b])%255]
+}
+
+func divide(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[(logTable[a]-logTable[b]+255)%255]
+}
+
+// point is a point of the secret sharing polynomials, one for each byte of the secret.
+type point struct {
+	x byte
+	y []byte
+}
+
+// interpolate evaluates the polynomials through the points at x.
+func interpolate(points []point, x byte) []byte {
+	for _, p := range points {
+		if p.x == x {
+			return p.y
+		}
+	}
+	result := make([]byte, len(points[0].y))
+	for i, p := range points {
+		basis := byte(1)
+		for j, other := range points {
+			if j != i {
+				basis = multiply(basis, divide(x^other.x, p.x^other.x))
+			}
+		}
+		for k := range result {
+			result[k] ^= multiply(basis, p.y[k])
+		}
+	}
+	return result
+}
+
+// recoverSecret recovers the shared secret from threshold points and checks its digest.
+func recoverSecret(threshold int, points []point) ([]byte, error) {
+	if len(points) != threshold {
+		return nil, errp.Newf("%d shares are needed, got %d.", threshold, len(points))
+	}
+	seen := map[byte]bool{}
+	for _, p := range points {
+		if seen[p.x] {
+			return nil, errp.New("The shares have to be distinct.")
+		}
+		seen[p.x] = true
+	}
+	if threshold == 1 {
+		return points[0].y, nil
+	}
+	secret := interpolate(points, secretIndex)
+	digestShare := interpolate(points, digestIndex)
+	mac := hmac.New(sha256.New, digestShare[digestSize:])
+	_, _ = mac.Write(secret)
+	if !hmac.Equal(mac.Sum(nil)[:digestSize], digestShare[:digestSize]) {
+		return nil, errp.New("The shares do not belong together.")
+	}
+	return secret, nil
+}
+
+// decrypt decrypts the encrypted master secret with the passphrase, using the Feistel network of
+// SLIP-39.
+func decrypt(encryptedMasterSecret []byte, passphrase []byte, first *share) []byte {
+	half := len(encryptedMasterSecret) / 2
+	left := append([]byte{}, encryptedMasterSecret[:half]...)
+	right := append([]byte{}, encryptedMasterSecret[half:]...)
+	salt := []byte{}
+	if !first.extendable {
+		salt = append([]byte("shamir"), byte(first.identifier>>8), byte(first.identifier))
+	}
+	iterations := (baseIterationCount << uint(first.iterationExponent)) / roundCount
+	for round := roundCount - 1; round >= 0; round-- {
+		roundKey := pbkdf2.Key(
+			append([]byte{byte(round)}, passphrase...),
+			append(append([]byte{}, salt...), right...),
+			iterations, len(right), sha256.New)
+		for i := range left {
+			left[i] ^= roundKey[i]
+		}
+		left, right = right, left
+	}
+	return append(right, left...)
+}
+
+// Combine recovers the master secret from the mnemonic shares and the optional passphrase. Exactly
+// as many groups as the group threshold are needed, each with exactly as many shares as its member
+// threshold. A wrong passphrase cannot be detected and yields a different master secret.
+func Combine(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, errp.New("No shares given.")
+	}
+	for _, char := range []byte(passphrase) {
+		if char < 32 || char > 126 {
+			return nil, errp.New("The passphrase may only contain printable ASCII characters.")
+		}
+	}
+	shares := make([]*share, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		decoded, err := decodeShare(mnemonic)
+		if err != nil {
+			return nil, errp.WithMessage(err, fmt.Sprintf("Invalid share %d", i+1))
+		}
+		shares[i] = decoded
+	}
+	first := shares[0]
+	if len(first.value) < minSecretSize || len(first.value)%2 != 0 {
+		return nil, errp.New("Invalid master secret length.")
+	}
+	groups := map[int][]*share{}
+	groupIndices := []int{}
+	for _, s := range shares {
+		if s.identifier != first.identifier || s.extendable != first.extendable ||
+			s.iterationExponent != first.iterationExponent {
+			return nil, errp.New("The shares belong to different backups.")
+		}
+		if s.groupThreshold != first.groupThreshold || s.groupCount != first.groupCount ||
+			len(s.value) != len(first.value) {
+			return nil, errp.New("The shares have different parameters.")
+		}
+		if _, ok := groups[s.groupIndex]; !ok {
+			groupIndices = append(groupIndices, s.groupIndex)
+		}
+		groups[s.groupIndex] = append(groups[s.groupIndex], s)
+	}
+	if len(groups) != first.groupThreshold {
+		return nil, errp.Newf(
+			"Shares of %d groups are needed, got %d.", first.groupThreshold, len(groups))
+	}
+	groupPoints := []point{}
+	for _, groupIndex := range groupIndices {
+		group := groups[groupIndex]
+		memberPoints := []point{}
+		for _, s := range group {
+			if s.memberThreshold != group[0].memberThreshold {
+				return nil, errp.New("The shares of a group have different thresholds.")
+			}
+			memberPoints = append(memberPoints, point{x: byte(s.memberIndex), y: s.value})
+		}
+		groupSecret, err := recoverSecret(group[0].memberThreshold, memberPoints)
+		if err != nil {
+			return nil, errp.WithMessage(err, fmt.Sprintf("Group %d", groupIndex+1))
+		}
+		groupPoints = append(groupPoints, point{x: byte(groupIndex), y: groupSecret})
+	}
+	encryptedMasterSecret, err := recoverSecret(first.groupThreshold, groupPoints)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(encryptedMasterSecret, []byte(passphrase), first), nil
+}
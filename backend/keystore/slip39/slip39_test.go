@@ -0,0 +1,115 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slip39
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of SLIP-39 (https://github.com/trezor/python-shamir-mnemonic/blob/master/vectors.json).
+const (
+	singleShare = "duckling enlarge academic academic agency result length solution fridge kidney " +
+		"coal piece deal husband erode duke ajar critical decision keyboard"
+	basicShare1 = "shadow pistol academic always adequate wildlife fancy gross oasis cylinder " +
+		"mustang wrist rescue view short owner flip making coding armed"
+	basicShare3 = "shadow pistol academic acid actress prayer class unknown daughter sweater " +
+		"depict flip twice unkind craft early superior advocate guest smoking"
+)
+
+var groupShares = []string{
+	"eraser senior decision roster beard treat identify grumpy salt index fake aviation theater " +
+		"cubic bike cause research dragon emphasis counter",
+	"eraser senior ceramic snake clay various huge numb argue hesitate auction category timber " +
+		"browser greatest hanger petition script leaf pickup",
+	"eraser senior ceramic shaft dynamic become junior wrist silver peasant force math alto coal " +
+		"amazing segment yelp velvet image paces",
+	"eraser senior ceramic round column hawk trust auction smug shame alive greatest sheriff " +
+		"living perfect corner chest sled fumes adequate",
+	"eraser senior decision smug corner ruin rescue cubic angel tackle skin skunk program roster " +
+		"trash rumor slush angel flea amazing",
+}
+
+func TestWordlist(t *testing.T) {
+	require.Len(t, wordlist, 1024)
+	prefixes := map[string]bool{}
+	for i, word := range wordlist {
+		if i > 0 {
+			require.True(t, wordlist[i-1] < word)
+		}
+		prefixes[word[:4]] = true
+	}
+	require.Len(t, prefixes, 1024)
+}
+
+func TestCombine(t *testing.T) {
+	for _, test := range []struct {
+		shares       []string
+		masterSecret string
+	}{
+		{[]string{singleShare}, "bb54aac4b89dc868ba37d9cc21b2cece"},
+		{[]string{basicShare1, basicShare3}, "b43ceb7e57a0ea8766221624d01b0864"},
+		// The order of the shares does not matter.
+		{[]string{basicShare3, basicShare1}, "b43ceb7e57a0ea8766221624d01b0864"},
+		// Two shares of group 3 and three shares of group 4.
+		{groupShares, "7c3397a292a5941682d7a4ae2d898d11"},
+	} {
+		masterSecret, err := Combine(test.shares, "TREZOR")
+		require.NoError(t, err)
+		require.Equal(t, test.masterSecret, hex.EncodeToString(masterSecret))
+	}
+
+	// Whitespace and case do not matter.
+	masterSecret, err := Combine(
+		[]string{"  " + strings.ToUpper(strings.Replace(singleShare, " ", "\n", -1))}, "TREZOR")
+	require.NoError(t, err)
+	require.Equal(t, "bb54aac4b89dc868ba37d9cc21b2cece", hex.EncodeToString(masterSecret))
+
+	// A different passphrase leads to a different master secret.
+	masterSecret, err = Combine([]string{singleShare}, "")
+	require.NoError(t, err)
+	require.NotEqual(t, "bb54aac4b89dc868ba37d9cc21b2cece", hex.EncodeToString(masterSecret))
+}
+
+func TestCombineInvalid(t *testing.T) {
+	for _, shares := range [][]string{
+		{},
+		// Invalid checksum.
+		{strings.Replace(singleShare, "keyboard", "kidney", 1)},
+		// Unknown word.
+		{strings.Replace(singleShare, "keyboard", "bitbox", 1)},
+		// Too few words.
+		{strings.Join(strings.Fields(singleShare)[:19], " ")},
+		// Too few shares.
+		{basicShare1},
+		// The same share twice.
+		{basicShare1, basicShare1},
+		// Shares of different backups.
+		{basicShare1, singleShare},
+		// Too few groups.
+		groupShares[1:4],
+		// Too few shares in a group.
+		groupShares[:4],
+	} {
+		_, err := Combine(shares, "TREZOR")
+		require.Error(t, err, shares)
+	}
+
+	_, err := Combine([]string{singleShare}, "pässphrase")
+	require.Error(t, err)
+}
@@ -15,20 +15,28 @@
 package software
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/slip39"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/sirupsen/logrus"
+	"github.com/tyler-smith/go-bip39"
 	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Keystore implements a keystore in software.
@@ -65,6 +73,74 @@ func NewKeystoreFromPIN(cosignerIndex int, pin string) *Keystore {
 	return NewKeystore(cosignerIndex, master)
 }
 
+// NewKeystoreFromMnemonic creates a new keystore from a BIP39 mnemonic and an optional passphrase.
+// The master key is created for the given network. The key material is only held in memory until
+// Wipe() is called.
+func NewKeystoreFromMnemonic(
+	cosignerIndex int,
+	mnemonic string,
+	passphrase string,
+	net *chaincfg.Params,
+) (*Keystore, error) {
+	// BIP39 specifies the NFKD normalization of the mnemonic and the passphrase.
+	mnemonic = strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, norm.NFKD.String(passphrase))
+	if err != nil {
+		return nil, errp.WithMessage(err, "Invalid mnemonic")
+	}
+	defer func() {
+		for i := range seed {
+			seed[i] = 0
+		}
+	}()
+	master, err := hdkeychain.NewMaster(seed, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewKeystore(cosignerIndex, master), nil
+}
+
+// NewKeystoreFromSLIP39Shares creates a new keystore from SLIP-39 mnemonic shares and an optional
+// passphrase. The recovered master secret is used as the BIP32 seed.
+func NewKeystoreFromSLIP39Shares(
+	cosignerIndex int,
+	shares []string,
+	passphrase string,
+	net *chaincfg.Params,
+) (*Keystore, error) {
+	masterSecret, err := slip39.Combine(shares, passphrase)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Invalid shares")
+	}
+	defer func() {
+		for i := range masterSecret {
+			masterSecret[i] = 0
+		}
+	}()
+	master, err := hdkeychain.NewMaster(masterSecret, net)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewKeystore(cosignerIndex, master), nil
+}
+
+// Wipe zeroes the key material of the keystore. The keystore cannot be used afterwards.
+func (keystore *Keystore) Wipe() {
+	if keystore.master == nil {
+		return
+	}
+	keystore.master.Zero()
+	keystore.master = nil
+}
+
+// masterKey returns the master extended private key, or an error if the keystore has been wiped.
+func (keystore *Keystore) masterKey() (*hdkeychain.ExtendedKey, error) {
+	if keystore.master == nil {
+		return nil, errp.New("The keystore has been wiped.")
+	}
+	return keystore.master, nil
+}
+
 // Configuration implements keystore.Keystore.
 func (keystore *Keystore) Configuration() *signing.Configuration {
 	return nil
@@ -80,6 +156,15 @@ func (keystore *Keystore) Identifier() (string, error) {
 	return keystore.identifier, nil
 }
 
+// RootFingerprint implements keystore.Keystore.
+func (keystore *Keystore) RootFingerprint() ([]byte, error) {
+	master, err := keystore.masterKey()
+	if err != nil {
+		return nil, err
+	}
+	return keystorePkg.RootFingerprintOf(master)
+}
+
 // CanVerifyAddress implements keystore.Keystore.
 func (keystore *Keystore) CanVerifyAddress(*signing.Configuration, coin.Coin) (bool, error) {
 	return false, nil
@@ -90,6 +175,11 @@ func (keystore *Keystore) VerifyAddress(*signing.Configuration, coin.Coin) error
 	return errp.New("The software-based keystore has no secure output to display the address.")
 }
 
+// SupportsScriptType implements keystore.Keystore.
+func (keystore *Keystore) SupportsScriptType(signing.ScriptType) bool {
+	return true
+}
+
 // CanVerifyExtendedPublicKey implements keystore.Keystore.
 func (keystore *Keystore) CanVerifyExtendedPublicKey() bool {
 	return false
@@ -104,7 +194,11 @@ func (keystore *Keystore) VerifyExtendedPublicKey(coin coin.Coin, keyPath signin
 func (keystore *Keystore) ExtendedPublicKey(
 	coin coin.Coin, absoluteKeypath signing.AbsoluteKeypath,
 ) (*hdkeychain.ExtendedKey, error) {
-	extendedPrivateKey, err := absoluteKeypath.Derive(keystore.master)
+	master, err := keystore.masterKey()
+	if err != nil {
+		return nil, err
+	}
+	extendedPrivateKey, err := absoluteKeypath.Derive(master)
 	if err != nil {
 		return nil, err
 	}
@@ -118,9 +212,13 @@ func (keystore *Keystore) sign(
 	if len(signatureHashes) != len(keyPaths) {
 		return nil, errp.New("The number of hashes to sign has to be equal to the number of paths.")
 	}
+	master, err := keystore.masterKey()
+	if err != nil {
+		return nil, err
+	}
 	signatures := make([]btcec.Signature, len(keyPaths))
 	for i, keyPath := range keyPaths {
-		xprv, err := keyPath.Derive(keystore.master)
+		xprv, err := keyPath.Derive(master)
 		if err != nil {
 			return nil, err
 		}
@@ -137,6 +235,35 @@ func (keystore *Keystore) sign(
 	return signatures, nil
 }
 
+// signTaproot creates a Schnorr signature of the signature hash of a BIP86 taproot input with the
+// tweaked private key at the given keypath.
+func (keystore *Keystore) signTaproot(
+	signatureHash []byte,
+	keyPath signing.AbsoluteKeypath,
+) (*btcec.Signature, error) {
+	master, err := keystore.masterKey()
+	if err != nil {
+		return nil, err
+	}
+	xprv, err := keyPath.Derive(master)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	tweaked, err := taproot.TweakPrivateKey(prv)
+	if err != nil {
+		return nil, err
+	}
+	auxRand := make([]byte, 32)
+	if _, err := rand.Read(auxRand); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return taproot.Sign(tweaked, signatureHash, auxRand)
+}
+
 // SignTransaction implements keystore.Keystore.
 func (keystore *Keystore) SignTransaction(
 	proposedTransaction interface{},
@@ -148,6 +275,8 @@ func (keystore *Keystore) SignTransaction(
 	keystore.log.Info("Sign transaction.")
 	signatureHashes := [][]byte{}
 	keyPaths := []signing.AbsoluteKeypath{}
+	// ecdsaInputs are the indices of the inputs signed with ECDSA signatures.
+	ecdsaInputs := []int{}
 	transaction := btcProposedTx.TXProposal.Transaction
 	for index, txIn := range transaction.TxIn {
 		spentOutput, ok := btcProposedTx.PreviousOutputs[txIn.PreviousOutPoint]
@@ -155,6 +284,19 @@ func (keystore *Keystore) SignTransaction(
 			keystore.log.Panic("There needs to be exactly one output being spent per input!")
 		}
 		address := btcProposedTx.GetAddress(spentOutput.ScriptHashHex())
+		if address.Configuration.ScriptType() == signing.ScriptTypeP2TR {
+			signatureHash, err := taproot.SigHash(transaction, index, btcProposedTx.SpentOutputs())
+			if err != nil {
+				return errp.WithMessage(err, "Failed to calculate taproot signature hash")
+			}
+			signature, err := keystore.signTaproot(
+				signatureHash, address.Configuration.AbsoluteKeypath())
+			if err != nil {
+				return errp.WithMessage(err, "Failed to sign signature hash")
+			}
+			btcProposedTx.Signatures[index][keystore.CosignerIndex()] = signature
+			continue
+		}
 		isSegwit, subScript := address.ScriptForHashToSign()
 		var signatureHash []byte
 		if isSegwit {
@@ -177,18 +319,42 @@ func (keystore *Keystore) SignTransaction(
 
 		signatureHashes = append(signatureHashes, signatureHash)
 		keyPaths = append(keyPaths, address.Configuration.AbsoluteKeypath())
+		ecdsaInputs = append(ecdsaInputs, index)
 	}
 
 	signatures, err := keystore.sign(signatureHashes, keyPaths)
 	if err != nil {
 		return errp.WithMessage(err, "Failed to sign signature hash")
 	}
-	if len(signatures) != len(transaction.TxIn) {
+	if len(signatures) != len(ecdsaInputs) {
 		panic("number of signatures doesn't match number of inputs")
 	}
 	for i, signature := range signatures {
 		signature := signature
-		btcProposedTx.Signatures[i][keystore.CosignerIndex()] = &signature
+		btcProposedTx.Signatures[ecdsaInputs[i]][keystore.CosignerIndex()] = &signature
+	}
+	return nil
+}
+
+// SignMessage implements keystore.Keystore.
+func (keystore *Keystore) SignMessage(messageProposal *signing.MessageProposal) error {
+	keystore.log.Info("Sign message.")
+	master, err := keystore.masterKey()
+	if err != nil {
+		return err
+	}
+	xprv, err := messageProposal.Keypath.Derive(master)
+	if err != nil {
+		return err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	signature, err := crypto.Sign(messageProposal.Hash, prv.ToECDSA())
+	if err != nil {
+		return errp.WithStack(err)
 	}
+	messageProposal.Signature = signature
 	return nil
 }
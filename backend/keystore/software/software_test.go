@@ -0,0 +1,198 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon " +
+	"abandon abandon about"
+
+func TestNewKeystoreFromMnemonic(t *testing.T) {
+	// Test vector of BIP39 (https://github.com/trezor/python-mnemonic/blob/master/vectors.json).
+	keystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "TREZOR", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t,
+		"xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF",
+		keystore.master.String())
+
+	// Whitespace between the words does not matter.
+	sameKeystore, err := NewKeystoreFromMnemonic(
+		0, "  "+strings.Replace(testMnemonic, " ", "\n", -1)+" ", "TREZOR", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, keystore.identifier, sameKeystore.identifier)
+
+	// The passphrase changes the seed.
+	otherKeystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.NotEqual(t, keystore.identifier, otherKeystore.identifier)
+
+	testnetKeystore, err := NewKeystoreFromMnemonic(
+		0, testMnemonic, "TREZOR", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(testnetKeystore.master.String(), "tprv"))
+}
+
+func TestNewKeystoreFromMnemonicInvalid(t *testing.T) {
+	for _, mnemonic := range []string{
+		"",
+		// Invalid checksum.
+		strings.Replace(testMnemonic, "about", "abandon", 1),
+		// Unknown word.
+		strings.Replace(testMnemonic, "about", "bitbox", 1),
+		// Too few words.
+		"abandon about",
+	} {
+		_, err := NewKeystoreFromMnemonic(0, mnemonic, "", &chaincfg.MainNetParams)
+		require.Error(t, err, mnemonic)
+	}
+}
+
+func TestRootFingerprint(t *testing.T) {
+	keystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	rootFingerprint, err := keystore.RootFingerprint()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x73, 0xc5, 0xda, 0x0a}, rootFingerprint)
+
+	// The passphrase leads to a different root key.
+	keystore, err = NewKeystoreFromMnemonic(0, testMnemonic, "passphrase", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	otherRootFingerprint, err := keystore.RootFingerprint()
+	require.NoError(t, err)
+	require.NotEqual(t, rootFingerprint, otherRootFingerprint)
+
+	keystore.Wipe()
+	_, err = keystore.RootFingerprint()
+	require.Error(t, err)
+}
+
+func TestWipe(t *testing.T) {
+	keystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/0'")
+	require.NoError(t, err)
+	_, err = keystore.ExtendedPublicKey(nil, keypath)
+	require.NoError(t, err)
+
+	master := keystore.master
+	keystore.Wipe()
+	require.Nil(t, keystore.master)
+	// The key material is zeroed, not just dropped.
+	require.False(t, master.IsPrivate())
+	_, err = master.ECPrivKey()
+	require.Error(t, err)
+	_, err = keystore.ExtendedPublicKey(nil, keypath)
+	require.Error(t, err)
+	_, err = keystore.sign([][]byte{make([]byte, 32)}, []signing.AbsoluteKeypath{keypath})
+	require.Error(t, err)
+	// Wiping twice is fine.
+	keystore.Wipe()
+}
+
+func TestSignMessage(t *testing.T) {
+	keystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/0'/0/0")
+	require.NoError(t, err)
+	messageProposal := &signing.MessageProposal{
+		Message: []byte("message"),
+		Hash:    chainhash.DoubleHashB([]byte("message")),
+		Keypath: keypath,
+	}
+	require.NoError(t, keystore.SignMessage(messageProposal))
+
+	xpub, err := keystore.ExtendedPublicKey(nil, keypath)
+	require.NoError(t, err)
+	publicKey, err := xpub.ECPubKey()
+	require.NoError(t, err)
+	// The signature is R, S and the recovery ID.
+	require.Len(t, messageProposal.Signature, 65)
+	recoveredPublicKey, err := crypto.SigToPub(messageProposal.Hash, messageProposal.Signature)
+	require.NoError(t, err)
+	require.True(t, (*btcec.PublicKey)(recoveredPublicKey).IsEqual(publicKey))
+}
+
+func TestSignTaproot(t *testing.T) {
+	keystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.True(t, keystore.SupportsScriptType(signing.ScriptTypeP2TR))
+	keypath, err := signing.NewAbsoluteKeypath("m/86'/0'/0'/0/0")
+	require.NoError(t, err)
+	signatureHash := chainhash.HashB([]byte("transaction"))
+	signature, err := keystore.signTaproot(signatureHash, keypath)
+	require.NoError(t, err)
+
+	// The signature is valid for the output key, not for the internal key.
+	xpub, err := keystore.ExtendedPublicKey(nil, keypath)
+	require.NoError(t, err)
+	internalKey, err := xpub.ECPubKey()
+	require.NoError(t, err)
+	outputKey, err := taproot.OutputKey(internalKey)
+	require.NoError(t, err)
+	serializedSignature := taproot.SerializeSignature(signature)
+	require.True(t, taproot.Verify(taproot.SerializePublicKey(outputKey), signatureHash, serializedSignature))
+	require.False(t, taproot.Verify(taproot.SerializePublicKey(internalKey), signatureHash, serializedSignature))
+}
+
+func TestNewKeystoreFromSLIP39Shares(t *testing.T) {
+	// Test vector of SLIP-39 (https://github.com/trezor/python-shamir-mnemonic/blob/master/vectors.json).
+	shares := []string{
+		"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist " +
+			"rescue view short owner flip making coding armed",
+		"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip " +
+			"twice unkind craft early superior advocate guest smoking",
+	}
+	keystore, err := NewKeystoreFromSLIP39Shares(0, shares, "TREZOR", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t,
+		"xprv9s21ZrQH143K2nNuAbfWPHBtfiSCS14XQgb3otW4pX655q58EEZeC8zmjEUwucBu9dPnxdpbZLCn57yx45RBkwJHnwHFjZK4XPJ8SyeYjYg",
+		keystore.master.String())
+
+	_, err = NewKeystoreFromSLIP39Shares(0, shares[:1], "TREZOR", &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestBackupMatches(t *testing.T) {
+	keystore, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/0'")
+	require.NoError(t, err)
+
+	// The network of the backup does not matter.
+	backup, err := NewKeystoreFromMnemonic(0, testMnemonic, "", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	matches, err := keystorePkg.BackupMatches(keystore, backup, nil, keypath)
+	require.NoError(t, err)
+	require.True(t, matches)
+
+	// A different passphrase is a different wallet.
+	backup, err = NewKeystoreFromMnemonic(0, testMnemonic, "passphrase", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	matches, err = keystorePkg.BackupMatches(keystore, backup, nil, keypath)
+	require.NoError(t, err)
+	require.False(t, matches)
+}
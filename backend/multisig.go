@@ -0,0 +1,125 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// maxMultisigSigners is the maximum number of keys of a standard multisig script.
+const maxMultisigSigners = 15
+
+// ErrInvalidMultisig is returned when creating a multisig account with an invalid threshold or
+// invalid cosigners.
+var ErrInvalidMultisig = errors.New("invalid multisig configuration")
+
+// multisigKeypath returns the keypath of the first multisig account of the given coin and script
+// type, as specified by BIP48: m/48'/coin'/0'/script type'.
+func multisigKeypath(coinCode string, scriptType signing.ScriptType) (signing.AbsoluteKeypath, error) {
+	coinType := map[string]uint32{coinBTC: 0, coinLTC: 2}[coinCode]
+	if _, isTestnet := testnetCoins[coinCode]; isTestnet || coinCode == "rbtc" {
+		coinType = 1
+	}
+	scriptTypeIndex := map[signing.ScriptType]uint32{
+		signing.ScriptTypeP2WSHP2SH: 1,
+		signing.ScriptTypeP2WSH:     2,
+	}
+	index, ok := scriptTypeIndex[scriptType]
+	if !ok {
+		return signing.AbsoluteKeypath{}, errp.Newf("unsupported multisig script type %s", scriptType)
+	}
+	return signing.NewEmptyAbsoluteKeypath().
+		Child(48, signing.Hardened).
+		Child(coinType, signing.Hardened).
+		Child(0, signing.Hardened).
+		Child(index, signing.Hardened), nil
+}
+
+// CreateMultisigAccount creates and persists a segwit M-of-N multisig account. The cosigners are
+// the registered keystores in the order of their cosigner indices, e.g. two local devices,
+// followed by the given cosigner xpubs, which are watch-only: their signatures are collected in
+// PSBTs. It returns the code of the new account, which is the hash of its configuration.
+func (backend *Backend) CreateMultisigAccount(
+	coinCode string,
+	name string,
+	scriptType signing.ScriptType,
+	signingThreshold int,
+	cosignerXPubs []string,
+) (string, error) {
+	coin, err := backend.Coin(coinCode)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := coin.(*btc.Coin); !ok {
+		return "", errp.New("Multisig accounts are only supported for bitcoin based coins.")
+	}
+	if backend.keystores.Count() == 0 {
+		return "", errp.WithStack(ErrAccountTypeUnavailable)
+	}
+	keypath, err := multisigKeypath(coinCode, scriptType)
+	if err != nil {
+		return "", err
+	}
+	localConfiguration, err := backend.keystores.Configuration(
+		coin, scriptType, keypath, signingThreshold)
+	if err != nil {
+		return "", err
+	}
+	extendedPublicKeys := localConfiguration.ExtendedPublicKeys()
+	for _, cosignerXPub := range cosignerXPubs {
+		extendedPublicKey, err := hdkeychain.NewKeyFromString(cosignerXPub)
+		if err != nil {
+			return "", errp.WithMessage(ErrInvalidMultisig, fmt.Sprintf("invalid xpub %s", cosignerXPub))
+		}
+		if extendedPublicKey.IsPrivate() {
+			return "", errp.WithMessage(ErrInvalidMultisig, "an xprv was entered instead of an xpub")
+		}
+		extendedPublicKeys = append(extendedPublicKeys, extendedPublicKey)
+	}
+	seen := map[string]struct{}{}
+	for _, extendedPublicKey := range extendedPublicKeys {
+		publicKey, err := extendedPublicKey.ECPubKey()
+		if err != nil {
+			return "", errp.WithStack(err)
+		}
+		if _, ok := seen[string(publicKey.SerializeCompressed())]; ok {
+			return "", errp.WithMessage(ErrInvalidMultisig, "the same xpub was entered twice")
+		}
+		seen[string(publicKey.SerializeCompressed())] = struct{}{}
+	}
+	numberOfSigners := len(extendedPublicKeys)
+	if numberOfSigners < 2 || numberOfSigners > maxMultisigSigners ||
+		signingThreshold < 1 || signingThreshold > numberOfSigners {
+		return "", errp.WithMessage(ErrInvalidMultisig,
+			fmt.Sprintf("%d-of-%d is not supported", signingThreshold, numberOfSigners))
+	}
+	configuration := signing.NewConfiguration(
+		scriptType, keypath, extendedPublicKeys, "", signingThreshold)
+	getSigningConfiguration := func() (*signing.Configuration, error) {
+		return configuration, nil
+	}
+	// The account is identified by its configuration, which is persisted at most once.
+	code := configuration.Hash()
+	if err := backend.CreateAndAddAccount(coin, code, name, getSigningConfiguration, true); err != nil {
+		return "", err
+	}
+	return code, nil
+}
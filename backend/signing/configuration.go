@@ -21,8 +21,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/jsonp"
@@ -33,13 +35,17 @@ type Configuration struct {
 	scriptType         ScriptType // Only used in btc and ltc, dummy for eth
 	absoluteKeypath    AbsoluteKeypath
 	extendedPublicKeys []*hdkeychain.ExtendedKey // Should be empty for address based watch only accounts
-	signingThreshold   int                       // TODO Multisig Only
+	signingThreshold   int                       // Multisig only
 	address            string                    // For address based accounts only
+	// rootFingerprints are the fingerprints of the root keys of the extended public keys, in the
+	// same order. Optional, nil if unknown.
+	rootFingerprints [][4]byte
 }
 
-// NewConfiguration creates a new configuration. At the moment, multisig is a predefined
-// multisig-P2SH script, and is active if there are more than one xpubs. Otherwise, it's single sig
-// and `scriptType` defines the type of script.
+// NewConfiguration creates a new configuration. Multisig is active if there are more than one xpubs,
+// and produces a sorted multisig script (BIP67) wrapped according to `scriptType` (P2WSH,
+// P2WSH-P2SH or legacy P2SH). Otherwise, it's single sig and `scriptType` defines the type of
+// script.
 func NewConfiguration(
 	scriptType ScriptType,
 	absoluteKeypath AbsoluteKeypath,
@@ -84,11 +90,20 @@ func NewAddressConfiguration(
 		scriptType, absoluteKeypath, []*hdkeychain.ExtendedKey{}, address, 1)
 }
 
-// ScriptType returns the configuration's keypath.
-func (configuration *Configuration) ScriptType() ScriptType {
-	if configuration.Multisig() {
-		panic("scriptType is only defined for single sig")
+// WithRootFingerprints returns a copy of the configuration in which the extended public keys are
+// annotated with the fingerprints of the root keys they were derived from (see RootFingerprints).
+func (configuration *Configuration) WithRootFingerprints(
+	rootFingerprints [][4]byte) (*Configuration, error) {
+	if len(rootFingerprints) != configuration.NumberOfSigners() {
+		return nil, errp.New("There must be one root fingerprint per extended public key.")
 	}
+	result := *configuration
+	result.rootFingerprints = rootFingerprints
+	return &result, nil
+}
+
+// ScriptType returns the configuration's script type.
+func (configuration *Configuration) ScriptType() ScriptType {
 	return configuration.scriptType
 }
 
@@ -102,6 +117,16 @@ func (configuration *Configuration) ExtendedPublicKeys() []*hdkeychain.ExtendedK
 	return configuration.extendedPublicKeys
 }
 
+// RootFingerprints returns the fingerprints of the root keys of the extended public keys, which
+// are the first 4 bytes of hash160(root public key). The fingerprint of a key is all zeros if it is
+// unknown.
+func (configuration *Configuration) RootFingerprints() [][4]byte {
+	if configuration.rootFingerprints != nil {
+		return configuration.rootFingerprints
+	}
+	return make([][4]byte, configuration.NumberOfSigners())
+}
+
 // Address returns the configuration's address
 func (configuration *Configuration) Address() string {
 	return configuration.address
@@ -177,6 +202,7 @@ func (configuration *Configuration) Derive(relativeKeypath RelativeKeypath) (*Co
 		absoluteKeypath:    configuration.absoluteKeypath.Append(relativeKeypath),
 		extendedPublicKeys: derivedPublicKeys,
 		signingThreshold:   configuration.signingThreshold,
+		rootFingerprints:   configuration.rootFingerprints,
 	}, nil
 }
 
@@ -186,6 +212,8 @@ type configurationEncoding struct {
 	Threshold  int             `json:"threshold"`
 	Xpubs      []string        `json:"xpubs"`
 	Address    string          `json:"address"`
+	// RootFingerprints are hex encoded.
+	RootFingerprints []string `json:"rootFingerprints,omitempty"`
 }
 
 // MarshalJSON implements json.Marshaler.
@@ -195,12 +223,17 @@ func (configuration Configuration) MarshalJSON() ([]byte, error) {
 	for i := 0; i < length; i++ {
 		xpubs[i] = configuration.extendedPublicKeys[i].String()
 	}
+	var rootFingerprints []string
+	for _, rootFingerprint := range configuration.rootFingerprints {
+		rootFingerprints = append(rootFingerprints, hex.EncodeToString(rootFingerprint[:]))
+	}
 	return json.Marshal(&configurationEncoding{
-		ScriptType: string(configuration.scriptType),
-		Keypath:    configuration.absoluteKeypath,
-		Threshold:  configuration.signingThreshold,
-		Xpubs:      xpubs,
-		Address:    configuration.address,
+		ScriptType:       string(configuration.scriptType),
+		Keypath:          configuration.absoluteKeypath,
+		Threshold:        configuration.signingThreshold,
+		Xpubs:            xpubs,
+		Address:          configuration.address,
+		RootFingerprints: rootFingerprints,
 	})
 }
 
@@ -223,20 +256,58 @@ func (configuration *Configuration) UnmarshalJSON(bytes []byte) error {
 			return errp.Wrap(err, "Could not read an extended public key.")
 		}
 	}
+	configuration.rootFingerprints = nil
+	if len(encoding.RootFingerprints) != 0 {
+		if len(encoding.RootFingerprints) != length {
+			return errp.New("There must be one root fingerprint per extended public key.")
+		}
+		configuration.rootFingerprints = make([][4]byte, length)
+		for i, rootFingerprint := range encoding.RootFingerprints {
+			decoded, err := hex.DecodeString(rootFingerprint)
+			if err != nil || len(decoded) != 4 {
+				return errp.Newf("Invalid root fingerprint %s.", rootFingerprint)
+			}
+			copy(configuration.rootFingerprints[i][:], decoded)
+		}
+	}
 	return nil
 }
 
-// Hash returns a hash of the configuration.
+// Hash returns a hash of the configuration. The root fingerprints are not part of the hash, as they
+// do not change the addresses of the account.
 func (configuration *Configuration) Hash() string {
-	hash := sha256.Sum256(jsonp.MustMarshal(configuration))
+	withoutRootFingerprints := *configuration
+	withoutRootFingerprints.rootFingerprints = nil
+	hash := sha256.Sum256(jsonp.MustMarshal(withoutRootFingerprints))
 	return hex.EncodeToString(hash[:])
 }
 
+// PolicyHash returns a hash of the spending policy of a multisig configuration: the script type,
+// the signing threshold and the set of extended public keys, regardless of their order and version
+// bytes. Other than Hash(), it does not depend on how a cosigner stores the configuration, so the
+// cosigners can compare it to make sure that they share the same wallet.
+func (configuration *Configuration) PolicyHash() (string, error) {
+	extendedPublicKeys := make([]string, configuration.NumberOfSigners())
+	for index, extendedPublicKey := range configuration.extendedPublicKeys {
+		// SetNet modifies the key, so we work on a copy.
+		normalized, err := hdkeychain.NewKeyFromString(extendedPublicKey.String())
+		if err != nil {
+			return "", errp.WithStack(err)
+		}
+		normalized.SetNet(&chaincfg.MainNetParams)
+		extendedPublicKeys[index] = normalized.String()
+	}
+	sort.Strings(extendedPublicKeys)
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s", configuration.scriptType,
+		configuration.signingThreshold, strings.Join(extendedPublicKeys, ","))))
+	return hex.EncodeToString(hash[:]), nil
+}
+
 // String returns a short summary of the configuration to be used in logs, etc.
 func (configuration *Configuration) String() string {
 	if configuration.Multisig() {
-		return fmt.Sprintf("multisig, %d/%d",
-			configuration.SigningThreshold(), configuration.NumberOfSigners())
+		return fmt.Sprintf("multisig, %d/%d, scriptType: %s",
+			configuration.SigningThreshold(), configuration.NumberOfSigners(), configuration.scriptType)
 	}
 	return fmt.Sprintf("single sig, scriptType: %s", configuration.scriptType)
 }
@@ -0,0 +1,64 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyHash(t *testing.T) {
+	keypath, err := signing.NewAbsoluteKeypath("m/48'/1'/0'/2'")
+	require.NoError(t, err)
+	extendedPublicKeys := make([]*hdkeychain.ExtendedKey, 3)
+	for i := range extendedPublicKeys {
+		master, err := hdkeychain.NewMaster(make([]byte, 32+i), &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		extendedPublicKeys[i], err = master.Neuter()
+		require.NoError(t, err)
+	}
+	policyHash := func(
+		scriptType signing.ScriptType,
+		extendedPublicKeys []*hdkeychain.ExtendedKey,
+		signingThreshold int,
+	) string {
+		hash, err := signing.NewConfiguration(
+			scriptType, keypath, extendedPublicKeys, "", signingThreshold).PolicyHash()
+		require.NoError(t, err)
+		return hash
+	}
+	expected := policyHash(signing.ScriptTypeP2WSH, extendedPublicKeys, 2)
+
+	// The order of the cosigners and the version bytes of the keys do not matter.
+	reordered := []*hdkeychain.ExtendedKey{
+		extendedPublicKeys[2], extendedPublicKeys[0], extendedPublicKeys[1]}
+	require.Equal(t, expected, policyHash(signing.ScriptTypeP2WSH, reordered, 2))
+	mainnetKey, err := hdkeychain.NewKeyFromString(extendedPublicKeys[0].String())
+	require.NoError(t, err)
+	mainnetKey.SetNet(&chaincfg.MainNetParams)
+	require.Equal(t, expected, policyHash(signing.ScriptTypeP2WSH,
+		[]*hdkeychain.ExtendedKey{mainnetKey, extendedPublicKeys[1], extendedPublicKeys[2]}, 2))
+	// The original keys are not modified.
+	require.True(t, strings.HasPrefix(extendedPublicKeys[0].String(), "tpub"))
+
+	require.NotEqual(t, expected, policyHash(signing.ScriptTypeP2WSH, extendedPublicKeys, 3))
+	require.NotEqual(t, expected, policyHash(signing.ScriptTypeP2WSHP2SH, extendedPublicKeys, 2))
+	require.NotEqual(t, expected, policyHash(signing.ScriptTypeP2WSH, extendedPublicKeys[:2], 2))
+}
@@ -0,0 +1,296 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// See BIP380.
+const (
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+func descriptorPolymod(c uint64, value int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(value)
+	for i, generator := range []uint64{
+		0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+	} {
+		if c0&(1<<uint(i)) != 0 {
+			c ^= generator
+		}
+	}
+	return c
+}
+
+// DescriptorChecksum computes the 8 character checksum of an output script descriptor, which is
+// appended to the descriptor after a '#'.
+func DescriptorChecksum(descriptor string) (string, error) {
+	c := uint64(1)
+	class := 0
+	classCount := 0
+	for _, char := range descriptor {
+		position := strings.IndexRune(descriptorInputCharset, char)
+		if position == -1 {
+			return "", errp.Newf("Invalid character %q in the descriptor.", char)
+		}
+		c = descriptorPolymod(c, position&31)
+		class = class*3 + position>>5
+		classCount++
+		if classCount == 3 {
+			c = descriptorPolymod(c, class)
+			class = 0
+			classCount = 0
+		}
+	}
+	if classCount > 0 {
+		c = descriptorPolymod(c, class)
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolymod(c, 0)
+	}
+	c ^= 1
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>uint(5*(7-i)))&31]
+	}
+	return string(checksum), nil
+}
+
+// Descriptor returns the output script descriptor (BIP380) of the receive addresses, or of the
+// change addresses if change is true, including the checksum. The extended public keys are
+// encoded with the HDPublicKeyID of the given net, which should be the xpub or tpub version, as
+// other wallets do not accept the ypub/zpub variants in descriptors. Keys with an unknown root
+// fingerprint are exported with the fingerprint 00000000.
+func (configuration *Configuration) Descriptor(net *chaincfg.Params, change bool) (string, error) {
+	if configuration.IsAddressBased() {
+		return withDescriptorChecksum(fmt.Sprintf("addr(%s)", configuration.Address()))
+	}
+	chain := 0
+	if change {
+		chain = 1
+	}
+	origin := strings.Replace(
+		keypath(configuration.absoluteKeypath).encode(), hardenedKeySymbol, "h", -1)
+	rootFingerprints := configuration.RootFingerprints()
+	keys := make([]string, configuration.NumberOfSigners())
+	for index, extendedPublicKey := range configuration.ExtendedPublicKeys() {
+		// SetNet modifies the key, so we work on a copy.
+		extendedPublicKey, err := hdkeychain.NewKeyFromString(extendedPublicKey.String())
+		if err != nil {
+			return "", errp.WithStack(err)
+		}
+		extendedPublicKey.SetNet(net)
+		key := fmt.Sprintf("[%s", hex.EncodeToString(rootFingerprints[index][:]))
+		if origin != "" {
+			key += "/" + origin
+		}
+		keys[index] = fmt.Sprintf("%s]%s/%d/*", key, extendedPublicKey, chain)
+	}
+	var descriptor string
+	if configuration.Multisig() {
+		multi := fmt.Sprintf("sortedmulti(%d,%s)",
+			configuration.SigningThreshold(), strings.Join(keys, ","))
+		switch configuration.ScriptType() {
+		case ScriptTypeP2WSH:
+			descriptor = fmt.Sprintf("wsh(%s)", multi)
+		case ScriptTypeP2WSHP2SH:
+			descriptor = fmt.Sprintf("sh(wsh(%s))", multi)
+		default:
+			descriptor = fmt.Sprintf("sh(%s)", multi)
+		}
+	} else {
+		switch configuration.ScriptType() {
+		case ScriptTypeP2PKH:
+			descriptor = fmt.Sprintf("pkh(%s)", keys[0])
+		case ScriptTypeP2WPKHP2SH:
+			descriptor = fmt.Sprintf("sh(wpkh(%s))", keys[0])
+		case ScriptTypeP2WPKH:
+			descriptor = fmt.Sprintf("wpkh(%s)", keys[0])
+		default:
+			return "", errp.Newf("Descriptors are not supported for the script type %s.",
+				configuration.ScriptType())
+		}
+	}
+	return withDescriptorChecksum(descriptor)
+}
+
+func withDescriptorChecksum(descriptor string) (string, error) {
+	checksum, err := DescriptorChecksum(descriptor)
+	if err != nil {
+		return "", err
+	}
+	return descriptor + "#" + checksum, nil
+}
+
+// unwrapDescriptor returns the arguments of `function(arguments)`, or false if the expression is
+// not a call of the given function.
+func unwrapDescriptor(expression string, function string) (string, bool) {
+	if !strings.HasPrefix(expression, function+"(") || !strings.HasSuffix(expression, ")") {
+		return "", false
+	}
+	return expression[len(function)+1 : len(expression)-1], true
+}
+
+// parseDescriptorKey parses a key expression like `[d34db33f/84h/0h/0h]xpub.../0/*`. The key has
+// to be an extended public key which derives the receive addresses.
+func parseDescriptorKey(expression string) (
+	*hdkeychain.ExtendedKey, [4]byte, AbsoluteKeypath, error) {
+	var rootFingerprint [4]byte
+	absoluteKeypath := NewEmptyAbsoluteKeypath()
+	if strings.HasPrefix(expression, "[") {
+		end := strings.Index(expression, "]")
+		if end == -1 {
+			return nil, rootFingerprint, nil, errp.New("Unterminated key origin.")
+		}
+		origin := strings.Split(expression[1:end], "/")
+		decoded, err := hex.DecodeString(origin[0])
+		if err != nil || len(decoded) != 4 {
+			return nil, rootFingerprint, nil, errp.Newf("Invalid fingerprint %s.", origin[0])
+		}
+		copy(rootFingerprint[:], decoded)
+		for _, element := range origin[1:] {
+			hardened := strings.HasSuffix(element, "h") || strings.HasSuffix(element, "H") ||
+				strings.HasSuffix(element, hardenedKeySymbol)
+			if hardened {
+				element = element[:len(element)-1]
+			}
+			index, err := strconv.ParseUint(element, 10, 31)
+			if err != nil {
+				return nil, rootFingerprint, nil, errp.Newf("Invalid key origin %s.", expression[:end+1])
+			}
+			absoluteKeypath = absoluteKeypath.Child(uint32(index), hardened)
+		}
+		expression = expression[end+1:]
+	}
+	var suffix string
+	for _, receiveSuffix := range []string{"/0/*", "/<0;1>/*"} {
+		if strings.HasSuffix(expression, receiveSuffix) {
+			suffix = receiveSuffix
+		}
+	}
+	if suffix == "" {
+		return nil, rootFingerprint, nil, errp.New(
+			"The key must be followed by /0/* or /<0;1>/*, the descriptor of the receive addresses.")
+	}
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(strings.TrimSuffix(expression, suffix))
+	if err != nil {
+		return nil, rootFingerprint, nil, errp.Wrap(err, "Invalid extended public key.")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return nil, rootFingerprint, nil, errp.New(
+			"The descriptor contains a private key. Only extended public keys are accepted.")
+	}
+	return extendedPublicKey, rootFingerprint, absoluteKeypath, nil
+}
+
+// NewConfigurationFromDescriptor creates a configuration from an output script descriptor
+// (BIP380) with a valid checksum. Supported are pkh, sh(wpkh), wpkh and sortedmulti in sh, sh(wsh)
+// or wsh. All keys of a multisig descriptor need to have the same derivation path.
+func NewConfigurationFromDescriptor(descriptor string) (*Configuration, error) {
+	descriptor = strings.TrimSpace(descriptor)
+	separator := strings.LastIndex(descriptor, "#")
+	if separator == -1 {
+		return nil, errp.New("The descriptor checksum is missing.")
+	}
+	checksum, err := DescriptorChecksum(descriptor[:separator])
+	if err != nil {
+		return nil, err
+	}
+	if descriptor[separator+1:] != checksum {
+		return nil, errp.New("The descriptor checksum is invalid.")
+	}
+	descriptor = descriptor[:separator]
+
+	var scriptType ScriptType
+	var keyExpressions []string
+	signingThreshold := 1
+	parseMultisig := func(expression string) error {
+		if _, ok := unwrapDescriptor(expression, "multi"); ok {
+			return errp.New("Only sortedmulti is supported for multisig descriptors.")
+		}
+		arguments, ok := unwrapDescriptor(expression, "sortedmulti")
+		if !ok {
+			return errp.New("Unsupported descriptor.")
+		}
+		splits := strings.Split(arguments, ",")
+		threshold, err := strconv.Atoi(splits[0])
+		if err != nil || len(splits) < 3 || threshold < 1 || threshold > len(splits)-1 {
+			return errp.Newf("Invalid multisig %s.", expression)
+		}
+		signingThreshold = threshold
+		keyExpressions = splits[1:]
+		return nil
+	}
+	if inner, ok := unwrapDescriptor(descriptor, "pkh"); ok {
+		scriptType = ScriptTypeP2PKH
+		keyExpressions = []string{inner}
+	} else if inner, ok := unwrapDescriptor(descriptor, "wpkh"); ok {
+		scriptType = ScriptTypeP2WPKH
+		keyExpressions = []string{inner}
+	} else if inner, ok := unwrapDescriptor(descriptor, "wsh"); ok {
+		scriptType = ScriptTypeP2WSH
+		if err := parseMultisig(inner); err != nil {
+			return nil, err
+		}
+	} else if inner, ok := unwrapDescriptor(descriptor, "sh"); ok {
+		if innerWPKH, ok := unwrapDescriptor(inner, "wpkh"); ok {
+			scriptType = ScriptTypeP2WPKHP2SH
+			keyExpressions = []string{innerWPKH}
+		} else if innerWSH, ok := unwrapDescriptor(inner, "wsh"); ok {
+			scriptType = ScriptTypeP2WSHP2SH
+			if err := parseMultisig(innerWSH); err != nil {
+				return nil, err
+			}
+		} else {
+			// Legacy P2SH multisig uses the P2PKH script type.
+			scriptType = ScriptTypeP2PKH
+			if err := parseMultisig(inner); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		return nil, errp.New("Unsupported descriptor.")
+	}
+
+	extendedPublicKeys := make([]*hdkeychain.ExtendedKey, len(keyExpressions))
+	rootFingerprints := make([][4]byte, len(keyExpressions))
+	var absoluteKeypath AbsoluteKeypath
+	for index, keyExpression := range keyExpressions {
+		extendedPublicKey, rootFingerprint, keyKeypath, err := parseDescriptorKey(keyExpression)
+		if err != nil {
+			return nil, err
+		}
+		if index > 0 && keyKeypath.Encode() != absoluteKeypath.Encode() {
+			return nil, errp.New("All keys need to have the same derivation path.")
+		}
+		extendedPublicKeys[index] = extendedPublicKey
+		rootFingerprints[index] = rootFingerprint
+		absoluteKeypath = keyKeypath
+	}
+	return NewConfiguration(scriptType, absoluteKeypath, extendedPublicKeys, "", signingThreshold).
+		WithRootFingerprints(rootFingerprints)
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+// The account xpub at m/84'/0'/0' of the BIP84 test mnemonic, whose root fingerprint is 73c5da0a.
+const bip84XPub = "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+
+func withChecksum(t *testing.T, descriptor string) string {
+	checksum, err := signing.DescriptorChecksum(descriptor)
+	require.NoError(t, err)
+	return descriptor + "#" + checksum
+}
+
+func TestDescriptorChecksum(t *testing.T) {
+	// Test vector of BIP380.
+	checksum, err := signing.DescriptorChecksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+
+	_, err = signing.DescriptorChecksum("raw(deadbeef)ä")
+	require.Error(t, err)
+}
+
+func TestDescriptorSinglesig(t *testing.T) {
+	descriptor := withChecksum(t, "wpkh([73c5da0a/84h/0h/0h]"+bip84XPub+"/0/*)")
+	configuration, err := signing.NewConfigurationFromDescriptor(descriptor)
+	require.NoError(t, err)
+	require.Equal(t, signing.ScriptTypeP2WPKH, configuration.ScriptType())
+	require.Equal(t, "m/84'/0'/0'", configuration.AbsoluteKeypath().Encode())
+	require.True(t, configuration.Singlesig())
+	require.Equal(t, bip84XPub, configuration.ExtendedPublicKeys()[0].String())
+	require.Equal(t, [][4]byte{{0x73, 0xc5, 0xda, 0x0a}}, configuration.RootFingerprints())
+
+	exported, err := configuration.Descriptor(&chaincfg.MainNetParams, false)
+	require.NoError(t, err)
+	require.Equal(t, descriptor, exported)
+	change, err := configuration.Descriptor(&chaincfg.MainNetParams, true)
+	require.NoError(t, err)
+	require.Equal(t, withChecksum(t, "wpkh([73c5da0a/84h/0h/0h]"+bip84XPub+"/1/*)"), change)
+
+	// The root fingerprints survive persisting, but do not change the hash.
+	jsonBytes, err := json.Marshal(configuration)
+	require.NoError(t, err)
+	var decoded signing.Configuration
+	require.NoError(t, json.Unmarshal(jsonBytes, &decoded))
+	require.Equal(t, configuration.RootFingerprints(), decoded.RootFingerprints())
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(bip84XPub)
+	require.NoError(t, err)
+	require.Equal(t,
+		signing.NewSinglesigConfiguration(
+			signing.ScriptTypeP2WPKH, configuration.AbsoluteKeypath(), extendedPublicKey).Hash(),
+		configuration.Hash())
+
+	// Alternative notations of the same account.
+	for _, alternative := range []string{
+		"wpkh([73c5da0a/84'/0'/0']" + bip84XPub + "/0/*)",
+		"wpkh([73c5da0a/84H/0H/0H]" + bip84XPub + "/<0;1>/*)",
+	} {
+		configuration, err := signing.NewConfigurationFromDescriptor(withChecksum(t, alternative))
+		require.NoError(t, err)
+		exported, err := configuration.Descriptor(&chaincfg.MainNetParams, false)
+		require.NoError(t, err)
+		require.Equal(t, descriptor, exported)
+	}
+
+	for descriptor, scriptType := range map[string]signing.ScriptType{
+		"pkh(" + bip84XPub + "/0/*)":      signing.ScriptTypeP2PKH,
+		"sh(wpkh(" + bip84XPub + "/0/*))": signing.ScriptTypeP2WPKHP2SH,
+	} {
+		configuration, err := signing.NewConfigurationFromDescriptor(withChecksum(t, descriptor))
+		require.NoError(t, err)
+		require.Equal(t, scriptType, configuration.ScriptType())
+		require.Equal(t, signing.NewEmptyAbsoluteKeypath(), configuration.AbsoluteKeypath())
+		// Without a key origin, the fingerprint is unknown.
+		require.Equal(t, [][4]byte{{}}, configuration.RootFingerprints())
+	}
+}
+
+func TestDescriptorMultisig(t *testing.T) {
+	keys := make([]string, 3)
+	for i := range keys {
+		master, err := hdkeychain.NewMaster(make([]byte, 32+i), &chaincfg.TestNet3Params)
+		require.NoError(t, err)
+		xpub, err := master.Neuter()
+		require.NoError(t, err)
+		keys[i] = fmt.Sprintf("[0000000%d/48h/1h/0h/2h]%s/0/*", i+1, xpub)
+	}
+	multi := "sortedmulti(2," + strings.Join(keys, ",") + ")"
+	for descriptor, scriptType := range map[string]signing.ScriptType{
+		"wsh(" + multi + ")":     signing.ScriptTypeP2WSH,
+		"sh(wsh(" + multi + "))": signing.ScriptTypeP2WSHP2SH,
+		"sh(" + multi + ")":      signing.ScriptTypeP2PKH,
+	} {
+		descriptor = withChecksum(t, descriptor)
+		configuration, err := signing.NewConfigurationFromDescriptor(descriptor)
+		require.NoError(t, err)
+		require.Equal(t, scriptType, configuration.ScriptType())
+		require.True(t, configuration.Multisig())
+		require.Equal(t, 2, configuration.SigningThreshold())
+		require.Equal(t, 3, configuration.NumberOfSigners())
+		require.Equal(t, "m/48'/1'/0'/2'", configuration.AbsoluteKeypath().Encode())
+		exported, err := configuration.Descriptor(&chaincfg.TestNet3Params, false)
+		require.NoError(t, err)
+		require.Equal(t, descriptor, exported)
+	}
+}
+
+func TestDescriptorInvalid(t *testing.T) {
+	valid := "wpkh([73c5da0a/84h/0h/0h]" + bip84XPub + "/0/*)"
+	_, err := signing.NewConfigurationFromDescriptor(valid)
+	require.Error(t, err, "missing checksum")
+	_, err = signing.NewConfigurationFromDescriptor(valid + "#aaaaaaaa")
+	require.Error(t, err, "wrong checksum")
+
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	for _, descriptor := range []string{
+		"wpkh(" + master.String() + "/0/*)",
+		"wpkh(" + bip84XPub + "/1/*)",
+		"wpkh(" + bip84XPub + ")",
+		"wpkh([73c5da/84h]" + bip84XPub + "/0/*)",
+		"wpkh([73c5da0a/84x]" + bip84XPub + "/0/*)",
+		"wsh(multi(1," + bip84XPub + "/0/*," + bip84XPub + "/0/*))",
+		"wsh(sortedmulti(3," + bip84XPub + "/0/*," + bip84XPub + "/0/*))",
+		"wsh(sortedmulti(1,[73c5da0a/1h]" + bip84XPub + "/0/*,[73c5da0a/2h]" + bip84XPub + "/0/*))",
+		"wsh(wpkh(" + bip84XPub + "/0/*))",
+		"tr(" + bip84XPub + "/0/*)",
+	} {
+		_, err := signing.NewConfigurationFromDescriptor(withChecksum(t, descriptor))
+		require.Error(t, err, descriptor)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signing
+
+// MessageProposal contains all the info needed to sign a message with the key at a keypath. The
+// hash commits to the message in the format of the coin, e.g. BIP137 or personal_sign. Keystores
+// without a screen sign the hash blindly, so the message has to be shown to the user before.
+type MessageProposal struct {
+	// Message is the message to be signed. For EIP-712 typed data, it is the JSON encoded data.
+	Message []byte
+	// Hash is the 32 byte hash to be signed.
+	Hash []byte
+	// Keypath is the keypath of the signing key.
+	Keypath AbsoluteKeypath
+	// Signature is set by the keystore: 32 bytes R, 32 bytes S and the recovery ID.
+	Signature []byte
+}
@@ -16,7 +16,9 @@ package signing
 
 import "github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 
-// ScriptType indicates which type of output should be produced in case of singlesig.
+// ScriptType indicates which type of output should be produced. Singlesig configurations use the
+// P2PKH, P2WPKH and P2TR types, multisig configurations use the P2WSH types. Multisig configurations with
+// any other script type produce legacy P2SH outputs.
 type ScriptType string
 
 const (
@@ -28,6 +30,16 @@ const (
 
 	// ScriptTypeP2WPKH is a segwit PayToPubKeyHash output.
 	ScriptTypeP2WPKH ScriptType = "p2wpkh"
+
+	// ScriptTypeP2TR is a taproot output spent with the key path, whose output key is the public
+	// key tweaked according to BIP86.
+	ScriptTypeP2TR ScriptType = "p2tr"
+
+	// ScriptTypeP2WSHP2SH is a segwit multisig PayToScriptHash output wrapped in p2sh.
+	ScriptTypeP2WSHP2SH ScriptType = "p2wsh-p2sh"
+
+	// ScriptTypeP2WSH is a segwit multisig PayToScriptHash output.
+	ScriptTypeP2WSH ScriptType = "p2wsh"
 )
 
 // DecodeScriptType decodes the given script type or returns an error.
@@ -39,6 +51,12 @@ func DecodeScriptType(scriptType string) (ScriptType, error) {
 		return ScriptTypeP2WPKHP2SH, nil
 	case "p2wpkh":
 		return ScriptTypeP2WPKH, nil
+	case "p2tr":
+		return ScriptTypeP2TR, nil
+	case "p2wsh-p2sh":
+		return ScriptTypeP2WSHP2SH, nil
+	case "p2wsh":
+		return ScriptTypeP2WSH, nil
 	default:
 		return "", errp.Newf("The given script type %s is unknown.", scriptType)
 	}
@@ -0,0 +1,177 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"sort"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// CosignerVerification is the verification status of the extended public key of a cosigner of an
+// account. Singlesig accounts have one cosigner, the keystore of the account.
+type CosignerVerification struct {
+	// CosignerIndex is the position of the extended public key in the signing configuration.
+	CosignerIndex     int    `json:"cosignerIndex"`
+	ExtendedPublicKey string `json:"extendedPublicKey"`
+	// CanVerify is true if the keystore of the cosigner is registered and can display the
+	// extended public key on its screen.
+	CanVerify bool `json:"canVerify"`
+	// Verified is true if the extended public key has been verified on the screen of its keystore.
+	Verified bool `json:"verified"`
+}
+
+// initializedBTCAccount returns the loaded and initialized btc based account with the given code.
+func (backend *Backend) initializedBTCAccount(code string) (*btc.Account, error) {
+	btcAccount, ok := backend.account(code).(*btc.Account)
+	if !ok {
+		return nil, errp.New("Only the extended public keys of bitcoin based accounts can be verified.")
+	}
+	if !btcAccount.Initialized() {
+		return nil, errp.New("The account is not initialized yet.")
+	}
+	return btcAccount, nil
+}
+
+// AccountVerification returns the verification status of the extended public keys of the account
+// with the given code, in the order of the cosigners. To verify the wallet, the user walks through
+// the cosigners and verifies each key with VerifyAccountExtendedPublicKey() whose keystore is
+// registered.
+func (backend *Backend) AccountVerification(code string) ([]CosignerVerification, error) {
+	btcAccount, err := backend.initializedBTCAccount(code)
+	if err != nil {
+		return nil, err
+	}
+	verifiedExtendedPublicKeys := map[string]bool{}
+	persistedAccount := backend.config.AccountsConfig().LookupForKeystore(
+		code, backend.accountRootFingerprint(code))
+	if persistedAccount != nil {
+		for _, extendedPublicKey := range persistedAccount.VerifiedExtendedPublicKeys {
+			verifiedExtendedPublicKeys[extendedPublicKey] = true
+		}
+	}
+	keystores := btcAccount.Keystores()
+	verifications := []CosignerVerification{}
+	for index, extendedPublicKey := range btcAccount.Info().SigningConfiguration.ExtendedPublicKeys() {
+		canVerify := index < keystores.Count() &&
+			keystores.AccessKeystoreByIndex(index).CanVerifyExtendedPublicKey()
+		verifications = append(verifications, CosignerVerification{
+			CosignerIndex:     index,
+			ExtendedPublicKey: extendedPublicKey.String(),
+			CanVerify:         canVerify,
+			Verified:          verifiedExtendedPublicKeys[extendedPublicKey.String()],
+		})
+	}
+	return verifications, nil
+}
+
+// VerifyAccountExtendedPublicKey displays the extended public key of the cosigner with the given
+// index of the account with the given code on the screen of its keystore. Once the keystore
+// returns, which requires the confirmation of the user, the key is recorded as verified in the
+// accounts config. Returns false, nil if the keystore has no screen to display the key.
+func (backend *Backend) VerifyAccountExtendedPublicKey(code string, cosignerIndex int) (bool, error) {
+	btcAccount, err := backend.initializedBTCAccount(code)
+	if err != nil {
+		return false, err
+	}
+	canVerify, err := btcAccount.VerifyExtendedPublicKey(cosignerIndex)
+	if err != nil || !canVerify {
+		return canVerify, err
+	}
+	extendedPublicKey := btcAccount.Info().SigningConfiguration.ExtendedPublicKeys()[cosignerIndex].String()
+	_, err = backend.modifyPersistedAccount(code, func(account *config.Account) {
+		for _, verifiedExtendedPublicKey := range account.VerifiedExtendedPublicKeys {
+			if verifiedExtendedPublicKey == extendedPublicKey {
+				return
+			}
+		}
+		account.VerifiedExtendedPublicKeys = append(
+			account.VerifiedExtendedPublicKeys, extendedPublicKey)
+	})
+	return true, err
+}
+
+// BackupVerification is the result of the verification of a backup against the registered
+// keystore.
+type BackupVerification struct {
+	// Matches is true if the backup derives the same extended public keys as the keystore for all
+	// accounts.
+	Matches bool `json:"matches"`
+	// CheckedAccounts is the number of accounts whose extended public keys were compared.
+	CheckedAccounts int `json:"checkedAccounts"`
+}
+
+// VerifyBackup checks whether a BIP39 mnemonic or a set of SLIP-39 shares, each with the optional
+// passphrase, restores the main keystore, so the user can drill their paper backup. The
+// extended public keys of all keystore accounts are derived from the backup in software and
+// compared with the ones of the keystore. The seed is only held in memory during the call and is
+// never sent anywhere.
+func (backend *Backend) VerifyBackup(
+	mnemonic string, slip39Shares []string, passphrase string) (*BackupVerification, error) {
+	keystores := backend.mainKeystores()
+	if keystores.Count() == 0 {
+		return nil, errp.WithStack(keystore.ErrNoKeystore)
+	}
+	registeredKeystore := keystores.AccessKeystoreByIndex(0)
+
+	var backup *software.Keystore
+	var err error
+	if len(slip39Shares) > 0 {
+		backup, err = software.NewKeystoreFromSLIP39Shares(
+			0, slip39Shares, passphrase, backend.softwareKeystoreNet())
+	} else {
+		backup, err = software.NewKeystoreFromMnemonic(
+			0, mnemonic, passphrase, backend.softwareKeystoreNet())
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer backup.Wipe()
+
+	var keystoreAccounts []config.Account
+	func() {
+		defer backend.keystoreAccountsLock.RLock()()
+		for _, account := range backend.keystoreAccounts {
+			keystoreAccounts = append(keystoreAccounts, account)
+		}
+	}()
+	if len(keystoreAccounts) == 0 {
+		return nil, errp.New("There are no accounts to compare the backup with.")
+	}
+	sort.Slice(keystoreAccounts, func(i, j int) bool {
+		return keystoreAccounts[i].Code < keystoreAccounts[j].Code
+	})
+	verification := &BackupVerification{Matches: true}
+	for _, account := range keystoreAccounts {
+		coin, err := backend.Coin(account.CoinCode)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := keystore.BackupMatches(registeredKeystore, backup, coin, account.Keypath)
+		if err != nil {
+			return nil, err
+		}
+		verification.CheckedAccounts++
+		if !matches {
+			backend.log.WithField("code", account.Code).Info("The backup does not match the keystore")
+			verification.Matches = false
+		}
+	}
+	return verification, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2019 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+)
+
+// keystoreAccountConfiguration derives the signing configuration of a keystore account from the
+// main keystore. The root fingerprints are added to the configuration if they are known.
+func (backend *Backend) keystoreAccountConfiguration(
+	coin coin.Coin,
+	scriptType signing.ScriptType,
+	keypath signing.AbsoluteKeypath,
+	rootFingerprints [][4]byte,
+) (*signing.Configuration, error) {
+	configuration, err := backend.mainKeystores().Configuration(coin, scriptType, keypath, 1)
+	if err != nil || rootFingerprints == nil {
+		return configuration, err
+	}
+	return configuration.WithRootFingerprints(rootFingerprints)
+}
+
+// accountRootFingerprint returns the root fingerprint of the keystore the loaded account with the
+// given code belongs to: the one remembered for watch-only accounts, and the one of the registered
+// keystores otherwise.
+func (backend *Backend) accountRootFingerprint(code string) string {
+	defer backend.keystoreAccountsLock.RLock()()
+	if rootFingerprint, ok := backend.watchOnlyAccounts[code]; ok {
+		return rootFingerprint
+	}
+	return encodeRootFingerprints(backend.rootFingerprints)
+}
+
+// rememberKeystoreAccount persists the signing configuration of the keystore account with the
+// given code in watch-only mode, so that the account can be loaded while the keystore is absent.
+// The configuration is only remembered if the keystore is identified by its root fingerprint, as
+// the fingerprint decides whether the keystore of a watch-only account is registered.
+func (backend *Backend) rememberKeystoreAccount(
+	code string, rootFingerprint string, configuration *signing.Configuration) {
+	if !backend.config.AppConfig().Backend.WatchOnly || rootFingerprint == "" ||
+		rootFingerprint != backend.rootFingerprint() {
+		return
+	}
+	persistedAccount := backend.config.AccountsConfig().LookupForKeystore(code, rootFingerprint)
+	if persistedAccount != nil && persistedAccount.Configuration != nil &&
+		persistedAccount.Configuration.Hash() == configuration.Hash() {
+		return
+	}
+	_, err := backend.modifyPersistedAccount(code, func(account *config.Account) {
+		account.Configuration = configuration
+	})
+	if err != nil {
+		backend.log.WithField("code", code).WithError(err).Error(
+			"could not remember the signing configuration")
+	}
+}
+
+// watchOnlyAccountAvailable returns whether the persisted keystore account can be loaded as a
+// watch-only account: the watch-only mode is enabled, the signing configuration is remembered and
+// the keystore of the account is not registered.
+func (backend *Backend) watchOnlyAccountAvailable(account config.Account) bool {
+	return backend.config.AppConfig().Backend.WatchOnly && account.Keystore &&
+		account.Configuration != nil && account.RootFingerprint != "" &&
+		account.RootFingerprint != backend.rootFingerprint()
+}
+
+// loadWatchOnlyAccount adds the persisted keystore account as a watch-only account if it is
+// available. Watch-only accounts get a keystore collection of their own which stays empty, so
+// they can show the balance and the transactions, but cannot sign or verify addresses. To do so,
+// the keystore has to be registered, which replaces the watch-only accounts with its accounts.
+func (backend *Backend) loadWatchOnlyAccount(coin coin.Coin, account config.Account) {
+	if !backend.watchOnlyAccountAvailable(account) {
+		return
+	}
+	backend.log.WithField("code", account.Code).WithField("name", account.Name).
+		Info("init watch-only account")
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		backend.watchOnlyAccounts[account.Code] = account.RootFingerprint
+	}()
+	getSigningConfiguration := func() (*signing.Configuration, error) {
+		return account.Configuration, nil
+	}
+	backend.loadAccount(coin, account.Code, account.Name, getSigningConfiguration,
+		keystore.NewKeystores(), account.GapLimits)
+}
+
+// uninitWatchOnlyAccounts closes the loaded watch-only accounts for which f returns true, given
+// their code and root fingerprint.
+func (backend *Backend) uninitWatchOnlyAccounts(f func(code string, rootFingerprint string) bool) {
+	codes := []string{}
+	func() {
+		defer backend.keystoreAccountsLock.Lock()()
+		for code, rootFingerprint := range backend.watchOnlyAccounts {
+			if f(code, rootFingerprint) {
+				codes = append(codes, code)
+			}
+		}
+	}()
+	for _, code := range codes {
+		backend.removeAccount(code)
+	}
+}
+
+// WatchOnlyAccount returns whether the loaded account with the given code is a watch-only account
+// whose keystore is absent, or a token account of one.
+func (backend *Backend) WatchOnlyAccount(code string) bool {
+	if tokenAccount, ok := backend.account(code).(*eth.TokenAccount); ok {
+		code = tokenAccount.Parent().Code()
+	}
+	defer backend.keystoreAccountsLock.RLock()()
+	_, ok := backend.watchOnlyAccounts[code]
+	return ok
+}
+
+// SetWatchOnly enables or disables the watch-only mode. If enabled, the signing configurations of
+// the keystore accounts are remembered in the accounts config, so that the accounts stay loaded
+// when the keystore is absent. If disabled, the remembered signing configurations are forgotten
+// and the watch-only accounts are closed.
+func (backend *Backend) SetWatchOnly(enabled bool) error {
+	appConfig := backend.config.AppConfig()
+	appConfig.Backend.WatchOnly = enabled
+	if err := backend.config.SetAppConfig(appConfig); err != nil {
+		return err
+	}
+	if !enabled {
+		backend.uninitWatchOnlyAccounts(func(string, string) bool { return true })
+		return backend.config.ModifyAccountsConfig(func(accountsConfig *config.AccountsConfig) error {
+			for index := range accountsConfig.Accounts {
+				if accountsConfig.Accounts[index].Keystore {
+					accountsConfig.Accounts[index].Configuration = nil
+				}
+			}
+			return nil
+		})
+	}
+	var rootFingerprints [][4]byte
+	keystoreAccounts := []config.Account{}
+	func() {
+		defer backend.keystoreAccountsLock.RLock()()
+		rootFingerprints = backend.rootFingerprints
+		for _, keystoreAccount := range backend.keystoreAccounts {
+			keystoreAccounts = append(keystoreAccounts, keystoreAccount)
+		}
+	}()
+	// The accounts added from now on are remembered when they are loaded, the ones already loaded
+	// are remembered here.
+	for _, keystoreAccount := range keystoreAccounts {
+		coin, err := backend.Coin(keystoreAccount.CoinCode)
+		if err != nil {
+			return err
+		}
+		configuration, err := backend.keystoreAccountConfiguration(
+			coin, keystoreAccount.ScriptType, keystoreAccount.Keypath, rootFingerprints)
+		if err != nil {
+			return err
+		}
+		backend.rememberKeystoreAccount(
+			keystoreAccount.Code, keystoreAccount.RootFingerprint, configuration)
+	}
+	return nil
+}
@@ -62,8 +62,9 @@ func (webdevEnvironment) NotifyUser(text string) {
 func main() {
 	mainnet := flag.Bool("mainnet", false, "switch to mainnet instead of testnet coins")
 	regtest := flag.Bool("regtest", false, "use regtest instead of testnet coins")
-	multisig := flag.Bool("multisig", false, "use the app in multisig mode")
 	devmode := flag.Bool("devmode", true, "switch to dev mode")
+	softwareKeystore := flag.Bool("softwarekeystore", false,
+		"allow loading a software keystore from a mnemonic, exposing the seed to this computer")
 	flag.Parse()
 
 	logging.Set(&logging.Configuration{Output: "STDERR", Level: logrus.DebugLevel})
@@ -80,7 +81,8 @@ func main() {
 	// since we are in dev-mode, we can drop the authorization token
 	connectionData := backendHandlers.NewConnectionData(-1, "")
 	backend, err := backend.NewBackend(
-		arguments.NewArguments("appfolder.dev", !*mainnet, *regtest, *multisig, *devmode),
+		arguments.NewArguments(
+			"appfolder.dev", !*mainnet, *regtest, *devmode, *softwareKeystore),
 		webdevEnvironment{},
 	)
 	if err != nil {
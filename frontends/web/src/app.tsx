@@ -31,6 +31,7 @@ import { AddAccount } from './routes/account/add/addaccount';
 import Info from './routes/account/info/info';
 import Receive from './routes/account/receive/receive';
 import { Send } from './routes/account/send/send';
+import { SignMessage } from './routes/account/sign-message/sign-message';
 import { InitializeAllAccounts } from './routes/account/summary/initializeall';
 import { Devices, DeviceSwitch } from './routes/device/deviceswitch';
 import ManageBackups from './routes/device/manage-backups/manage-backups';
@@ -165,6 +166,10 @@ class App extends Component<Props, State> {
                         <Info
                             path="/account/:code/info"
                             accounts={accounts} />
+                        <SignMessage
+                            path="/account/:code/sign-message"
+                            code={'' /* dummy to satisfy TS */}
+                            accounts={accounts} />
                         <Account
                             path="/account/:code?"
                             code={'' /* dummy to satisfy TS */}
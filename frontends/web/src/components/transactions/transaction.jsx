@@ -66,6 +66,7 @@ export default class Transaction extends Component {
         fee,
         feeRatePerKb,
         gas,
+        contractCall,
         vsize,
         size,
         weight,
@@ -123,6 +124,21 @@ export default class Transaction extends Component {
                                         </div>
                                     ) : ''
                                 }
+                                {
+                                    contractCall ? (
+                                        <div>
+                                            <div class={style.transactionLabel}>{t('transaction.contractCall')}</div>
+                                            <div class={[style.address, style.multiline].join(' ')}>
+                                                {contractCall.method}
+                                                {
+                                                    contractCall.arguments.map(argument => (
+                                                        <div key={argument.name}>{argument.name}: {argument.value}</div>
+                                                    ))
+                                                }
+                                            </div>
+                                        </div>
+                                    ) : ''
+                                }
                                 {
                                     vsize ? (
                                         <div>
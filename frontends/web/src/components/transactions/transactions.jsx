@@ -29,9 +29,10 @@ export default class Transactions extends Component {
         return (
             <div className={className} style="flex-grow: 1;">
                 {
-                    transactions.length > 0 ? transactions.map(props => (
+                    // The id is not unique, as token transfers of one transaction share it.
+                    transactions.length > 0 ? transactions.map((props, index) => (
                         <Transaction
-                            key={props.id}
+                            key={`${props.id}-${index}`}
                             explorerURL={explorerURL}
                             {...props} />
                     )) : (
@@ -17,6 +17,7 @@
 import { Component, h } from 'preact';
 import { ButtonLink } from '../../../components/forms';
 import { Balance } from '../../../components/balance/balance';
+import { CopyableInput } from '../../../components/copy/Copy';
 import { route } from 'preact-router';
 import { translate } from 'react-i18next';
 import { apiGet } from '../../../utils/request';
@@ -82,6 +83,14 @@ export default class Info extends Component {
                             <div class={style.infoContent}>
                                 <SigningConfiguration
                                     info={info.signingConfiguration} code={code} />
+                                { info.policyHash ? (
+                                    <div>
+                                        <strong>
+                                            {t('accountInfo.policyHash')}
+                                        </strong><br />
+                                        <CopyableInput value={info.policyHash} />
+                                    </div>
+                                ) : null }
                             </div>
                         </div>
                         <div class={style.bottomButtons}>
@@ -90,6 +99,11 @@ export default class Info extends Component {
                                 href={`/account/${code}`}>
                                 {t('button.back')}
                             </ButtonLink>
+                            <ButtonLink
+                                primary
+                                href={`/account/${code}/sign-message`}>
+                                {t('signMessage.title')}
+                            </ButtonLink>
                         </div>
                     </div>
                 </div>
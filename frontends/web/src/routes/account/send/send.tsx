@@ -74,6 +74,8 @@ interface State {
     proposedFee?: ProposedAmount;
     proposedTotal?: ProposedAmount;
     recipientAddress?: string;
+    // resolved address of an ENS name entered as recipient address.
+    proposedRecipient?: string;
     proposedAmount?: ProposedAmount;
     valid: boolean;
     amount?: string;
@@ -206,13 +208,16 @@ class Send extends Component<Props, State> {
             return;
         }
         this.setState({ signProgress: undefined, isConfirming: true });
-        apiPost('account/' + this.getAccount()!.code + '/sendtx', this.txInput()).then(result => {
+        // Send to the address an ENS name was resolved to in the proposal, which the user confirmed.
+        const txInput = { ...this.txInput(), address: this.state.proposedRecipient || this.state.recipientAddress };
+        apiPost('account/' + this.getAccount()!.code + '/sendtx', txInput).then(result => {
             if (result.success) {
                 this.setState({
                     sendAll: false,
                     isConfirming: false,
                     isSent: true,
                     recipientAddress: undefined,
+                    proposedRecipient: undefined,
                     proposedAmount: undefined,
                     proposedFee: undefined,
                     proposedTotal: undefined,
@@ -257,6 +262,7 @@ class Send extends Component<Props, State> {
     private validateAndDisplayFee = (updateFiat: boolean) => {
         this.setState({
             proposedTotal: undefined,
+            proposedRecipient: undefined,
             addressError: undefined,
             amountError: undefined,
             dataError: undefined,
@@ -275,6 +281,7 @@ class Send extends Component<Props, State> {
                     proposedFee: result.fee,
                     proposedAmount: result.amount,
                     proposedTotal: result.total,
+                    proposedRecipient: result.recipient,
                 });
                 if (updateFiat) {
                     this.convertToFiat(result.amount.amount);
@@ -475,6 +482,7 @@ class Send extends Component<Props, State> {
             proposedFee,
             proposedTotal,
             recipientAddress,
+            proposedRecipient,
             proposedAmount,
             valid,
             amount,
@@ -668,6 +676,11 @@ class Send extends Component<Props, State> {
                                             {t('send.address.label')}
                                         </p>
                                         <p class={style.confirmationValue}>{recipientAddress || 'N/A'}</p>
+                                        {
+                                            proposedRecipient && proposedRecipient.toLowerCase() !== (recipientAddress || '').toLowerCase() && (
+                                                <p class={style.confirmationValue}>{proposedRecipient}</p>
+                                            )
+                                        }
                                     </div>
                                     <div class={['flex flex-row flex-start', style.block, style.ignorePadding].join(' ')}>
                                         <div class={style.half}>
@@ -0,0 +1,275 @@
+/**
+ * Copyright 2019 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h, RenderableProps } from 'preact';
+import { alertUser } from '../../../components/alert/Alert';
+import { CopyableInput } from '../../../components/copy/Copy';
+import { Dialog } from '../../../components/dialog/dialog';
+import { Button, ButtonLink, Checkbox, Select } from '../../../components/forms';
+import { Header } from '../../../components/layout';
+import WaitDialog from '../../../components/wait-dialog/wait-dialog';
+import { translate, TranslateProps } from '../../../decorators/translate';
+import { apiGet, apiPost } from '../../../utils/request';
+import { AccountInterface } from '../account';
+import { isBitcoinBased } from '../utils';
+import * as style from './sign-message.css';
+
+interface SignMessageProps {
+    code: string;
+    accounts: AccountInterface[];
+}
+
+type Props = SignMessageProps & TranslateProps;
+
+interface ReceiveAddress {
+    addressID: string;
+    address: string;
+}
+
+interface SignResult {
+    signature: string;
+    address: string;
+}
+
+interface State {
+    message: string;
+    isTypedData: boolean;
+    receiveAddresses: ReceiveAddress[] | null;
+    addressID: string;
+    format: 'bip137' | 'bip322';
+    // The message as it is shown to the user before signing, null if not reviewing.
+    review: string | null;
+    isSigning: boolean;
+    result: SignResult | null;
+}
+
+class SignMessage extends Component<Props, State> {
+    constructor(props: Props) {
+        super(props);
+        this.state = {
+            message: '',
+            isTypedData: false,
+            receiveAddresses: null,
+            addressID: '',
+            format: 'bip137',
+            review: null,
+            isSigning: false,
+            result: null,
+        };
+    }
+
+    public componentDidMount() {
+        const account = this.getAccount();
+        if (account && isBitcoinBased(account.coinCode)) {
+            apiGet(`account/${this.props.code}/receive-addresses`).then((receiveAddresses: ReceiveAddress[]) => {
+                this.setState({
+                    receiveAddresses,
+                    addressID: receiveAddresses.length > 0 ? receiveAddresses[0].addressID : '',
+                });
+            });
+        }
+    }
+
+    private getAccount(): AccountInterface | undefined {
+        if (!this.props.accounts) {
+            return undefined;
+        }
+        return this.props.accounts.find(({ code }) => code === this.props.code);
+    }
+
+    private handleMessageInput = (event: Event) => {
+        this.setState({ message: (event.target as HTMLTextAreaElement).value, result: null });
+    }
+
+    private handleTypedDataChange = (event: Event) => {
+        this.setState({ isTypedData: (event.target as HTMLInputElement).checked, result: null });
+    }
+
+    private handleAddressChange = (event: Event) => {
+        this.setState({ addressID: (event.target as HTMLSelectElement).value, result: null });
+    }
+
+    private handleFormatChange = (event: Event) => {
+        const format = (event.target as HTMLSelectElement).value as State['format'];
+        this.setState({ format, result: null });
+    }
+
+    // The BitBox signs the hash of the message without displaying the message, so the full message
+    // is shown to the user before the device is asked to sign.
+    private review = () => {
+        if (!this.state.isTypedData) {
+            this.setState({ review: this.state.message });
+            return;
+        }
+        try {
+            this.setState({ review: JSON.stringify(JSON.parse(this.state.message), null, 2) });
+        } catch (error) {
+            alertUser(this.props.t('signMessage.invalidTypedData'));
+        }
+    }
+
+    private cancelReview = () => {
+        this.setState({ review: null });
+    }
+
+    private sign = () => {
+        const { message, isTypedData, addressID, format } = this.state;
+        this.setState({ isSigning: true });
+        apiPost(`account/${this.props.code}/sign-message`, {
+            message: isTypedData ? '' : message,
+            typedData: isTypedData ? JSON.parse(message) : null,
+            addressID,
+            format,
+        })
+        .then(({ success, signature, address, aborted, errorMessage }) => {
+            this.setState({ isSigning: false, review: null });
+            if (success) {
+                this.setState({ result: { signature, address } });
+            } else if (aborted) {
+                alertUser(this.props.t('signMessage.aborted'));
+            } else {
+                alertUser(this.props.t('unknownError', { errorMessage }));
+            }
+        })
+        .catch(() => this.setState({ isSigning: false, review: null }));
+    }
+
+    public render(
+        { t, code }: RenderableProps<Props>,
+        { message, isTypedData, receiveAddresses, addressID, format, review, isSigning, result }: Readonly<State>,
+    ) {
+        const account = this.getAccount();
+        if (!account) {
+            return null;
+        }
+        const bitcoinBased = isBitcoinBased(account.coinCode);
+        const reviewedMessage = (
+            <div>
+                <p class={style.warning}>{t('signMessage.blindSigning')}</p>
+                <pre class={style.message}>{review}</pre>
+            </div>
+        );
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('signMessage.title')}</h2>} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            {
+                                bitcoinBased && receiveAddresses && (
+                                    <div class="row">
+                                        <div class="flex flex-1 flex-row flex-between flex-items-center spaced">
+                                            <Select
+                                                label={t('signMessage.address')}
+                                                options={receiveAddresses.map(receiveAddress => ({
+                                                    value: receiveAddress.addressID,
+                                                    text: receiveAddress.address,
+                                                }))}
+                                                onInput={this.handleAddressChange}
+                                                value={addressID}
+                                                id="addressID"
+                                            />
+                                            <Select
+                                                label={t('signMessage.format.label')}
+                                                options={['bip137', 'bip322'].map(item => ({
+                                                    value: item,
+                                                    text: t(`signMessage.format.${item}`),
+                                                }))}
+                                                onInput={this.handleFormatChange}
+                                                value={format}
+                                                id="format"
+                                            />
+                                        </div>
+                                    </div>
+                                )
+                            }
+                            {
+                                !bitcoinBased && (
+                                    <div class="row">
+                                        <Checkbox
+                                            id="isTypedData"
+                                            label={t('signMessage.typedData')}
+                                            checked={isTypedData}
+                                            onChange={this.handleTypedDataChange}
+                                        />
+                                    </div>
+                                )
+                            }
+                            <div class="row">
+                                <label for="message">
+                                    {isTypedData ? t('signMessage.typedDataLabel') : t('signMessage.message')}
+                                </label>
+                                <textarea
+                                    id="message"
+                                    class={style.textarea}
+                                    rows={10}
+                                    onInput={this.handleMessageInput}
+                                    value={message}
+                                />
+                            </div>
+                            {
+                                result && (
+                                    <div class="row">
+                                        <strong>{t('signMessage.address')}</strong><br />
+                                        <CopyableInput value={result.address} />
+                                        <strong>{t('signMessage.signature')}</strong><br />
+                                        <CopyableInput value={result.signature} />
+                                    </div>
+                                )
+                            }
+                            <div class="row buttons flex flex-row flex-between flex-start">
+                                <ButtonLink secondary href={`/account/${code}/info`}>
+                                    {t('button.back')}
+                                </ButtonLink>
+                                <Button
+                                    primary
+                                    onClick={this.review}
+                                    disabled={message === '' || (bitcoinBased && addressID === '')}>
+                                    {t('signMessage.review')}
+                                </Button>
+                            </div>
+                        </div>
+                    </div>
+                    {
+                        review !== null && !isSigning && (
+                            <Dialog title={t('signMessage.title')} onClose={this.cancelReview}>
+                                {reviewedMessage}
+                                <div class={['buttons', 'flex', 'flex-row', 'flex-between'].join(' ')}>
+                                    <Button secondary onClick={this.cancelReview}>
+                                        {t('button.back')}
+                                    </Button>
+                                    <Button primary onClick={this.sign}>
+                                        {t('signMessage.sign')}
+                                    </Button>
+                                </div>
+                            </Dialog>
+                        )
+                    }
+                    {
+                        isSigning && (
+                            <WaitDialog title={t('signMessage.title')} includeDefault>
+                                {reviewedMessage}
+                            </WaitDialog>
+                        )
+                    }
+                </div>
+            </div>
+        );
+    }
+}
+
+const HOC = translate<SignMessageProps>()(SignMessage);
+export { HOC as SignMessage };